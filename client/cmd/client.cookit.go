@@ -2,23 +2,26 @@ package main
 
 import (
 	"fmt"
+	"github.com/krasimiraMilkova/cookit/client/internal/apis"
 	"github.com/krasimiraMilkova/cookit/client/internal/menu"
-	"net/http"
 )
 
 func main() {
 	userMenu := menu.GetUserMenu()
-	var tokenCookies []*http.Cookie
+	var credentials *apis.Credentials
 
-	for ; len(tokenCookies) == 0; {
-		tokenCookies = userMenu.GetAccessToken()
+	for ; credentials == nil; {
+		credentials = userMenu.GetAccessToken()
 	}
 
 	quit := make(chan bool)
-	recipeMenu := menu.GetRecipeMenu(tokenCookies, quit)
+	recipeMenu := menu.GetRecipeMenu(credentials, quit)
 	recipeMenu.PrintMenu()
 	recipeMenu.RecipeMenuChannel <- 3
 
 	<-quit
+	if err := userMenu.UserApi.LogOut(credentials); err != nil {
+		fmt.Println("Failed to log out!")
+	}
 	fmt.Println("Goodbye")
 }
@@ -1,3 +1,9 @@
 package apis
 
+import "os"
+
 var serverUrl = "http://127.0.0.1:8080"
+
+// useBearerToken selects sending the access token in the Authorization header instead of the cookie,
+// it is enabled by setting COOKIT_AUTH=bearer
+var useBearerToken = os.Getenv("COOKIT_AUTH") == "bearer"
@@ -0,0 +1,32 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// problem holds the details of an error response of the server
+type problem struct {
+	Detail string `json:"detail"`
+	Errors []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// responseError returns an error with the given message followed by the details sent by the server
+// or only with the message if the response has no details
+func responseError(response *http.Response, failure string) error {
+	details := problem{}
+	if err := json.NewDecoder(response.Body).Decode(&details); err != nil || details.Detail == "" {
+		return errors.New(failure)
+	}
+
+	message := failure + ": " + details.Detail
+	for _, field := range details.Errors {
+		message += "\n  " + field.Field + " " + field.Message
+	}
+
+	return errors.New(message)
+}
@@ -6,36 +6,127 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type RecipeApi struct {
 	*http.Client
-	cookies []*http.Cookie
+	credentials *Credentials
 }
 
-func GetRecipeApi(cookies []*http.Cookie) *RecipeApi {
+func GetRecipeApi(credentials *Credentials) *RecipeApi {
 	return &RecipeApi{
-		Client:  &http.Client{},
-		cookies: cookies,
+		Client:      &http.Client{},
+		credentials: credentials,
 	}
 }
 
 type Recipe struct {
-	Title       string       `json:"title"`
-	Ingredients []Ingredient `json:"ingredients"`
-	Directions  string       `json:"directions"`
+	Title         string       `json:"title"`
+	Servings      uint         `json:"servings,omitempty"`
+	Ingredients   []Ingredient `json:"ingredients"`
+	Directions    string       `json:"directions"`
+	Steps         []Step       `json:"steps,omitempty"`
+	PrepMinutes   uint         `json:"prep_minutes,omitempty"`
+	CookMinutes   uint         `json:"cook_minutes,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+	Cuisine       string       `json:"cuisine,omitempty"`
+	Course        string       `json:"course,omitempty"`
+	MealType      string       `json:"meal_type,omitempty"`
+	Difficulty    string       `json:"difficulty,omitempty"`
+	Dietary       *Dietary     `json:"dietary,omitempty"`
+	AverageRating float64      `json:"average_rating,omitempty"`
+	RatingCount   int          `json:"rating_count,omitempty"`
+}
+
+// Dietary is derived by the server from the ingredients of a recipe
+type Dietary struct {
+	Diets     []string `json:"diets"`
+	Allergens []string `json:"allergens"`
+	Unknown   []string `json:"unknown_ingredients"`
+}
+
+// Nutrients are the kcal and the grams of protein, fat, carbs and fibre of a recipe or a serving
+type Nutrients struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Fat      float64 `json:"fat"`
+	Carbs    float64 `json:"carbs"`
+	Fibre    float64 `json:"fibre"`
+}
+
+// Nutrition lists the ingredients left out of the totals in Unmatched
+type Nutrition struct {
+	Total      Nutrients  `json:"total"`
+	PerServing *Nutrients `json:"per_serving"`
+	Unmatched  []struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	} `json:"unmatched_ingredients"`
+}
+
+// Step is one of the ordered steps of a recipe, the server splits free text directions into steps
+type Step struct {
+	Text            string       `json:"text"`
+	DurationMinutes uint         `json:"duration_minutes,omitempty"`
+	Temperature     *Temperature `json:"temperature,omitempty"`
+}
+
+type Temperature struct {
+	Degrees float64 `json:"degrees"`
+	Unit    string  `json:"unit"`
 }
 
 type Ingredient struct {
-	Name        string `json:"name"`
-	Quantity    int    `json:"quantity"`
-	Measurement string `json:"measurement"`
+	Name        string   `json:"name"`
+	Quantity    Quantity `json:"quantity"`
+	Measurement string   `json:"measurement"`
+}
+
+// Quantity is the amount of an ingredient as the user typed it, like "2", "0.25" or "1 1/2",
+// it is sent as text, which the server parses, and the server returns it as a number
+type Quantity string
+
+func (quantity Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(quantity))
+}
+
+func (quantity *Quantity) UnmarshalJSON(data []byte) error {
+	*quantity = Quantity(strings.Trim(string(data), `"`))
+	return nil
 }
 
 type RecipeSearchResult struct {
-	ID    int    `json:"id"`
-	Title string `json:"title"`
+	ID              int    `json:"id"`
+	Title           string `json:"title"`
+	Author          string `json:"author"`
+	IngredientCount int     `json:"ingredient_count"`
+	AverageRating   float64 `json:"average_rating"`
+	RatingCount     int     `json:"rating_count"`
+}
+
+type Comment struct {
+	ID        int        `json:"id"`
+	Author    string     `json:"author"`
+	Comment   string     `json:"comment"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+}
+
+type RecipeSearchPage struct {
+	Items      []RecipeSearchResult    `json:"items"`
+	NextCursor string                  `json:"next_cursor"`
+	Total      int                     `json:"total"`
+	Facets     map[string][]FacetCount `json:"facets"`
+}
+
+// FacetCount is the number of found recipes with a value of a facet like cuisine or with a free tag
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
 }
 
 // CreateRecipe function sends recipe creation request to the server
@@ -49,9 +140,7 @@ func (ra *RecipeApi) CreateRecipe(recipe Recipe) error {
 	}
 
 	request, _ := http.NewRequest("POST", serverUrl+"/api/v1/recipe", payloadBuffer)
-	for _, cookie := range ra.cookies {
-		request.AddCookie(cookie)
-	}
+	ra.credentials.authorize(request)
 	response, err := ra.Client.Do(request)
 
 	if err != nil {
@@ -61,35 +150,46 @@ func (ra *RecipeApi) CreateRecipe(recipe Recipe) error {
 	defer response.Body.Close()
 
 	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
-		return errors.New("failed to create recipe")
+		return responseError(response, "failed to create recipe")
 	}
 
 	return nil
 }
 
 // FindByTitle function sends search by title request to the server
-// Returns error if such occurs or the obtained search results
-func (ra *RecipeApi) FindByTitle(title string) ([]RecipeSearchResult, error) {
-	return ra.findRecipes(serverUrl + "/api/v1/recipe?title=" + title)
+// The cursor selects the page to fetch and is empty for the first page
+// Returns error if such occurs or the obtained page of search results
+func (ra *RecipeApi) FindByTitle(title string, cursor string) (*RecipeSearchPage, error) {
+	return ra.findRecipes(url.Values{"title": {title}}, cursor)
 }
 
 // FindByIngredients function sends search by ingredients request to the server
-// Returns error if such occurs or the obtained search results
-func (ra *RecipeApi) FindByIngredients(ingredients string) ([]RecipeSearchResult, error) {
-	return ra.findRecipes(serverUrl + "/api/v1/recipe?ingredients=" + ingredients)
+// The cursor selects the page to fetch and is empty for the first page
+// Returns error if such occurs or the obtained page of search results
+func (ra *RecipeApi) FindByIngredients(ingredients string, cursor string) (*RecipeSearchPage, error) {
+	return ra.findRecipes(url.Values{"ingredients": {ingredients}}, cursor)
+}
+
+// FindByTags function sends search by free tags and facets, like tag=vegan&cuisine=italian, request to the server
+// The cursor selects the page to fetch and is empty for the first page
+// Returns error if such occurs or the obtained page of search results
+func (ra *RecipeApi) FindByTags(tags url.Values, cursor string) (*RecipeSearchPage, error) {
+	return ra.findRecipes(tags, cursor)
 }
 
-func (ra *RecipeApi) findRecipes(url string) ([]RecipeSearchResult, error) {
-	request, err := http.NewRequest("GET", url, nil)
+func (ra *RecipeApi) findRecipes(query url.Values, cursor string) (*RecipeSearchPage, error) {
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	request, err := http.NewRequest("GET", serverUrl+"/api/v1/recipe?"+query.Encode(), nil)
 
 	if err != nil {
 		fmt.Print(err)
 		return nil, err
 	}
 
-	for _, cookie := range ra.cookies {
-		request.AddCookie(cookie)
-	}
+	ra.credentials.authorize(request)
 	response, err := ra.Client.Do(request)
 
 	if err != nil {
@@ -100,27 +200,28 @@ func (ra *RecipeApi) findRecipes(url string) ([]RecipeSearchResult, error) {
 	defer response.Body.Close()
 
 	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
-		return nil, errors.New("failed to find recipes")
+		return nil, responseError(response, "failed to find recipes")
 	}
 
-	var recipes []RecipeSearchResult
-	err = json.NewDecoder(response.Body).Decode(&recipes)
+	page := &RecipeSearchPage{}
+	err = json.NewDecoder(response.Body).Decode(page)
 	if err != nil {
 		fmt.Print(err)
 		return nil, errors.New("failed to decode search result")
 	}
 
-	return recipes, nil
+	return page, nil
 }
 
-// GetById function sends a get recipe request to the server
+// GetById function sends a get recipe request to the server, the quantities are scaled to the servings if they are not 0
 // Returns error if such occurs or the obtained recipe
-func (ra *RecipeApi) GetById(id int) (*Recipe, error) {
+func (ra *RecipeApi) GetById(id int, servings uint) (*Recipe, error) {
 	url := serverUrl + "/api/v1/recipe/" + strconv.Itoa(id)
-	request, _ := http.NewRequest("GET", url, nil)
-	for _, cookie := range ra.cookies {
-		request.AddCookie(cookie)
+	if servings > 0 {
+		url += "?servings=" + strconv.FormatUint(uint64(servings), 10)
 	}
+	request, _ := http.NewRequest("GET", url, nil)
+	ra.credentials.authorize(request)
 	response, err := ra.Client.Do(request)
 
 	if err != nil {
@@ -130,7 +231,7 @@ func (ra *RecipeApi) GetById(id int) (*Recipe, error) {
 	defer response.Body.Close()
 
 	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
-		return nil, errors.New("failed to fetch recipe")
+		return nil, responseError(response, "failed to fetch recipe")
 	}
 
 	var recipe *Recipe
@@ -145,12 +246,10 @@ func (ra *RecipeApi) GetById(id int) (*Recipe, error) {
 
 // GetCommentsById function sends requests for retrieving recipe comments to the server
 // Returns error if such occurs or the obtained comments
-func (ra *RecipeApi) GetCommentsById(id int) ([]string, error) {
+func (ra *RecipeApi) GetCommentsById(id int) ([]Comment, error) {
 	url := serverUrl + "/api/v1/recipe/" + strconv.Itoa(id) + "/comment"
 	request, _ := http.NewRequest("GET", url, nil)
-	for _, cookie := range ra.cookies {
-		request.AddCookie(cookie)
-	}
+	ra.credentials.authorize(request)
 	response, err := ra.Client.Do(request)
 
 	if err != nil {
@@ -163,10 +262,10 @@ func (ra *RecipeApi) GetCommentsById(id int) ([]string, error) {
 		if response.StatusCode == 404 {
 			return nil, nil
 		}
-		return nil, errors.New("failed to fetch comments")
+		return nil, responseError(response, "failed to fetch comments")
 	}
 
-	var comments []string
+	var comments []Comment
 	err = json.NewDecoder(response.Body).Decode(&comments)
 
 	if err != nil {
@@ -176,11 +275,39 @@ func (ra *RecipeApi) GetCommentsById(id int) ([]string, error) {
 	return comments, nil
 }
 
+// RateById function sends put request with the rating of the user for a recipe to the server
+// Returns error if such occurs
+func (ra *RecipeApi) RateById(recipeId int, rating int) error {
+	payloadBuffer := new(bytes.Buffer)
+	err := json.NewEncoder(payloadBuffer).Encode(map[string]int{"rating": rating})
+
+	if err != nil {
+		return err
+	}
+
+	url := serverUrl + "/api/v1/recipe/" + strconv.Itoa(recipeId) + "/rating"
+	request, _ := http.NewRequest("PUT", url, payloadBuffer)
+	ra.credentials.authorize(request)
+	response, err := ra.Client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
+		return responseError(response, "failed to rate recipe")
+	}
+
+	return nil
+}
+
 // AddCommentById function sends post request for creating a recipe comments to the server
 // Returns error if such occurs
 func (ra *RecipeApi) AddCommentById(recipeId int, comment string) error {
 	payloadBuffer := new(bytes.Buffer)
-	err := json.NewEncoder(payloadBuffer).Encode(comment)
+	err := json.NewEncoder(payloadBuffer).Encode(map[string]string{"comment": comment})
 
 	if err != nil {
 		return err
@@ -188,9 +315,7 @@ func (ra *RecipeApi) AddCommentById(recipeId int, comment string) error {
 
 	url := serverUrl + "/api/v1/recipe/" + strconv.Itoa(recipeId) + "/comment"
 	request, _ := http.NewRequest("POST", url, payloadBuffer)
-	for _, cookie := range ra.cookies {
-		request.AddCookie(cookie)
-	}
+	ra.credentials.authorize(request)
 	response, err := ra.Client.Do(request)
 
 	if err != nil {
@@ -200,8 +325,33 @@ func (ra *RecipeApi) AddCommentById(recipeId int, comment string) error {
 	defer response.Body.Close()
 
 	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
-		return errors.New("failed to add comment")
+		return responseError(response, "failed to add comment")
 	}
 
 	return nil
 }
+
+// GetNutrition function sends a get nutrition facts request for the recipe to the server
+// Returns error if such occurs or the obtained nutrition
+func (ra *RecipeApi) GetNutrition(id int) (*Nutrition, error) {
+	request, _ := http.NewRequest("GET", serverUrl+"/api/v1/recipe/"+strconv.Itoa(id)+"/nutrition", nil)
+	ra.credentials.authorize(request)
+	response, err := ra.Client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
+		return nil, responseError(response, "failed to fetch nutrition")
+	}
+
+	nutrition := &Nutrition{}
+	if err = json.NewDecoder(response.Body).Decode(nutrition); err != nil {
+		return nil, errors.New("failed to decode nutrition")
+	}
+
+	return nutrition, nil
+}
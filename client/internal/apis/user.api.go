@@ -4,7 +4,6 @@ package apis
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 )
@@ -17,6 +16,33 @@ func GetUserApi() *UserApi {
 	return &UserApi{&http.Client{}}
 }
 
+// Credentials authorize the requests of a logged in user either with the token cookies
+// or with the access token in the Authorization header
+type Credentials struct {
+	Cookies     []*http.Cookie
+	AccessToken string
+	TokenType   string
+	UseBearer   bool
+}
+
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// authorize adds the credentials to the request in the selected way
+func (credentials *Credentials) authorize(request *http.Request) {
+	if credentials.UseBearer {
+		request.Header.Set("Authorization", credentials.TokenType+" "+credentials.AccessToken)
+		return
+	}
+
+	for _, cookie := range credentials.Cookies {
+		request.AddCookie(cookie)
+	}
+}
+
 type User struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
@@ -43,15 +69,15 @@ func (ua *UserApi) RegisterUser(user User) error {
 	defer response.Body.Close()
 
 	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
-		return errors.New("failed to register")
+		return responseError(response, "failed to register")
 	}
 
 	return nil
 }
 
-// RegisterUser function sends login request to the server for the given user information
-// Returns the obtained cookies
-func (ua *UserApi) LogIn(email string, password string) []*http.Cookie {
+// LogIn function sends login request to the server for the given user information
+// Returns the obtained credentials or nil if the login failed
+func (ua *UserApi) LogIn(email string, password string) *Credentials {
 	user := User{}
 	user.Email = email
 	user.Password = password
@@ -74,9 +100,82 @@ func (ua *UserApi) LogIn(email string, password string) []*http.Cookie {
 
 	defer response.Body.Close()
 
+	if response.StatusCode == http.StatusTooManyRequests {
+		fmt.Printf("Too many failed logins, try again in %s seconds!\n", response.Header.Get("Retry-After"))
+		return nil
+	}
+
 	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
+		fmt.Println(responseError(response, "Failed to login"))
+		return nil
+	}
+
+	token := accessTokenResponse{}
+	if err = json.NewDecoder(response.Body).Decode(&token); err != nil {
 		fmt.Println("Failed to login!")
+		return nil
+	}
+
+	return &Credentials{
+		Cookies:     response.Cookies(),
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		UseBearer:   useBearerToken,
+	}
+}
+
+// LogOut function sends logout request to the server, so the session of the given credentials cannot be used anymore
+// Returns an error if such occurs
+func (ua *UserApi) LogOut(credentials *Credentials) error {
+	request, _ := http.NewRequest("POST", serverUrl+"/logout", nil)
+	credentials.authorize(request)
+
+	response, err := ua.Client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
+		return responseError(response, "failed to log out")
+	}
+
+	return nil
+}
+
+// ForgotPassword function asks the server to email a password reset token to the given email
+// Returns an error if such occurs
+func (ua *UserApi) ForgotPassword(email string) error {
+	return ua.post("/password/forgot", map[string]string{"email": email}, "failed to request a password reset")
+}
+
+// ResetPassword function sends the token from the password reset email together with the new password
+// Returns an error if such occurs or the token is not valid
+func (ua *UserApi) ResetPassword(token string, password string) error {
+	return ua.post("/password/reset", map[string]string{"token": token, "password": password}, "failed to reset the password")
+}
+
+// post sends the payload to the path and returns an error with the given message if the request fails
+func (ua *UserApi) post(path string, payload interface{}, failure string) error {
+	payloadBuffer := new(bytes.Buffer)
+	if err := json.NewEncoder(payloadBuffer).Encode(payload); err != nil {
+		return err
+	}
+
+	request, _ := http.NewRequest("POST", serverUrl+path, payloadBuffer)
+	response, err := ua.Client.Do(request)
+
+	if err != nil {
+		return err
 	}
 
-	return response.Cookies()
+	defer response.Body.Close()
+
+	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
+		return responseError(response, failure)
+	}
+
+	return nil
 }
@@ -4,7 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"github.com/krasimiraMilkova/cookit/client/internal/apis"
-	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -18,10 +18,10 @@ type RecipeMenu struct {
 
 var recipeMenu *RecipeMenu
 
-func GetRecipeMenu(cookies []*http.Cookie, quit chan bool) *RecipeMenu {
+func GetRecipeMenu(credentials *apis.Credentials, quit chan bool) *RecipeMenu {
 	if recipeMenu == nil {
 		recipeMenu = &RecipeMenu{
-			RecipeApi:         apis.GetRecipeApi(cookies),
+			RecipeApi:         apis.GetRecipeApi(credentials),
 			RecipeMenuChannel: make(chan int, 1),
 			quit:              quit,
 		}
@@ -58,12 +58,12 @@ func (rm *RecipeMenu) printMainMenu() {
 }
 
 func (rm *RecipeMenu) printSearch() {
-	fmt.Print("Search by title (1) or by ingredients (2) or exit this menu (3): ")
+	fmt.Print("Search by title (1), by ingredients (2), by tags (3) or exit this menu (4): ")
 
 	var command int
 	fmt.Scan(&command)
 
-	var found []apis.RecipeSearchResult
+	var search func(cursor string) (*apis.RecipeSearchPage, error)
 	reader := bufio.NewReader(os.Stdin)
 	switch command {
 	case 1:
@@ -78,7 +78,9 @@ func (rm *RecipeMenu) printSearch() {
 			}
 
 			title = strings.Trim(title, "\n")
-			found, err = rm.RecipeApi.FindByTitle(title)
+			search = func(cursor string) (*apis.RecipeSearchPage, error) {
+				return rm.RecipeApi.FindByTitle(title, cursor)
+			}
 		}
 	case 2:
 		{
@@ -92,31 +94,79 @@ func (rm *RecipeMenu) printSearch() {
 			}
 
 			ingredients = strings.Trim(ingredients, "\n")
-			found, err = rm.RecipeApi.FindByIngredients(ingredients)
+			search = func(cursor string) (*apis.RecipeSearchPage, error) {
+				return rm.RecipeApi.FindByIngredients(ingredients, cursor)
+			}
+		}
+	case 3:
+		{
+			fmt.Print("Enter tags and filters (like quick, cuisine=italian, diet=vegan, exclude_allergens=peanut): ")
+			tags, err := reader.ReadString('\n')
+
+			if err != nil {
+				fmt.Println("Failed to read tags. Try again!")
+				rm.RecipeMenuChannel <- 2
+				return
+			}
+
+			query := url.Values{}
+			for _, tag := range strings.Split(strings.Trim(tags, "\n"), ",") {
+				if parts := strings.SplitN(strings.TrimSpace(tag), "=", 2); len(parts) == 2 {
+					query.Set(parts[0], parts[1])
+				} else if parts[0] != "" {
+					query.Add("tag", parts[0])
+				}
+			}
+			search = func(cursor string) (*apis.RecipeSearchPage, error) {
+				return rm.RecipeApi.FindByTags(query, cursor)
+			}
 		}
 	default:
 		rm.RecipeMenuChannel <- 3
 		return
 	}
 
-	if len(found) == 0 {
+	found, err := search("")
+
+	if err != nil || len(found.Items) == 0 {
 		fmt.Println("No recipes found. Try again!")
 		rm.RecipeMenuChannel <- 2
 		return
 	}
 
-	rm.printSearchResults(found)
+	rm.printSearchResults(found, search)
 	rm.RecipeMenuChannel <- 3
 }
 
-func (rm *RecipeMenu) printSearchResults(searchResults []apis.RecipeSearchResult) {
-	if len(searchResults) == 0 {
-		fmt.Println("No recipes found")
-		return
-	}
+func (rm *RecipeMenu) printSearchResults(page *apis.RecipeSearchPage, search func(cursor string) (*apis.RecipeSearchPage, error)) {
+	var searchResults []apis.RecipeSearchResult
+
+	printFacets(page.Facets)
+	for {
+		for _, sr := range page.Items {
+			fmt.Println(strconv.Itoa(len(searchResults)) + " - " + sr.Title + " by " + sr.Author + " " +
+				formatRating(sr.AverageRating, sr.RatingCount))
+			searchResults = append(searchResults, sr)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
 
-	for i, sr := range searchResults {
-		fmt.Println(strconv.Itoa(i) + " - " + sr.Title)
+		fmt.Print("Showing " + strconv.Itoa(len(searchResults)) + " of " + strconv.Itoa(page.Total) + ". Load more? (y/n): ")
+		var more string
+		fmt.Scan(&more)
+
+		if more != "y" {
+			break
+		}
+
+		next, err := search(page.NextCursor)
+		if err != nil {
+			fmt.Println("Failed to load more recipes")
+			break
+		}
+		page = next
 	}
 
 	var index int
@@ -125,7 +175,7 @@ func (rm *RecipeMenu) printSearchResults(searchResults []apis.RecipeSearchResult
 		fmt.Print("Choose recipe (enter #):")
 		_, err := fmt.Scan(&index)
 
-		if err == nil {
+		if err == nil && index >= 0 && index < len(searchResults) {
 			break
 		}
 
@@ -137,27 +187,151 @@ func (rm *RecipeMenu) printSearchResults(searchResults []apis.RecipeSearchResult
 }
 
 func (rm *RecipeMenu) printRecipe(id int) {
-	recipe, err := rm.RecipeApi.GetById(id)
+	recipe, err := rm.RecipeApi.GetById(id, 0)
 
 	if err != nil {
 		fmt.Println("Could not load the recipe.")
 		return
 	}
 
-	fmt.Println(recipe.Title)
+	fmt.Println(recipe.Title + " " + formatRating(recipe.AverageRating, recipe.RatingCount))
+	printTags(recipe)
+	printDietary(recipe)
+	printIngredients(recipe)
+	printSteps(recipe)
+	rm.printNutrition(id)
 
-	for _, ingredient := range recipe.Ingredients {
-		fmt.Println(ingredient.Name + " - " + strconv.Itoa(ingredient.Quantity) + " " + ingredient.Measurement)
+	if recipe.Servings > 0 {
+		rm.printScaledRecipe(id)
 	}
 
-	fmt.Println(recipe.Directions)
 	rm.printCommentsMenu(id)
 }
 
+// printScaledRecipe offers to show the ingredients of the recipe for a different number of servings
+func (rm *RecipeMenu) printScaledRecipe(id int) {
+	var servings uint
+	fmt.Print("Show for a different number of servings? (enter number or leave empty): ")
+	if _, err := fmt.Scanln(&servings); err != nil || servings == 0 {
+		return
+	}
+
+	scaled, err := rm.RecipeApi.GetById(id, servings)
+
+	if err != nil {
+		fmt.Println("Could not scale the recipe.")
+		return
+	}
+
+	printIngredients(scaled)
+}
+
+// printTags prints the facets and the free tags of the recipe
+func printTags(recipe *apis.Recipe) {
+	var tags []string
+	for _, value := range []string{recipe.Cuisine, recipe.Course, recipe.MealType, recipe.Difficulty} {
+		if value != "" {
+			tags = append(tags, value)
+		}
+	}
+	for _, tag := range recipe.Tags {
+		tags = append(tags, "#"+tag)
+	}
+
+	if len(tags) > 0 {
+		fmt.Println(strings.Join(tags, " "))
+	}
+}
+
+// printNutrition prints the nutrition facts of the recipe per serving, or of the whole recipe
+// if it does not say how many servings it makes
+func (rm *RecipeMenu) printNutrition(id int) {
+	nutrition, err := rm.RecipeApi.GetNutrition(id)
+
+	if err != nil {
+		return
+	}
+
+	label, nutrients := "Nutrition", nutrition.Total
+	if nutrition.PerServing != nil {
+		label, nutrients = "Nutrition per serving", *nutrition.PerServing
+	}
+
+	fmt.Printf("%s: %.0f kcal, protein %.1f g, fat %.1f g, carbs %.1f g, fibre %.1f g\n",
+		label, nutrients.Calories, nutrients.Protein, nutrients.Fat, nutrients.Carbs, nutrients.Fibre)
+	for _, unmatched := range nutrition.Unmatched {
+		fmt.Println("Not counted: " + unmatched.Name + " (" + unmatched.Reason + ")")
+	}
+}
+
+// printDietary prints the diets the recipe is suitable for and the allergens it contains
+func printDietary(recipe *apis.Recipe) {
+	if recipe.Dietary == nil {
+		return
+	}
+
+	if len(recipe.Dietary.Diets) > 0 {
+		fmt.Println("Suitable for: " + strings.Join(recipe.Dietary.Diets, ", "))
+	}
+	if len(recipe.Dietary.Allergens) > 0 {
+		fmt.Println("Contains: " + strings.Join(recipe.Dietary.Allergens, ", "))
+	}
+	if len(recipe.Dietary.Unknown) > 0 {
+		fmt.Println("Unknown ingredients: " + strings.Join(recipe.Dietary.Unknown, ", "))
+	}
+}
+
+// printFacets prints how many of the found recipes have each value of the facets, like "Italian (42)"
+func printFacets(facets map[string][]apis.FacetCount) {
+	for _, facet := range []string{"cuisine", "course", "meal_type", "difficulty", "tag"} {
+		var counts []string
+		for _, count := range facets[facet] {
+			counts = append(counts, count.Value+" ("+strconv.Itoa(count.Count)+")")
+		}
+
+		if len(counts) > 0 {
+			fmt.Println(facet + ": " + strings.Join(counts, ", "))
+		}
+	}
+}
+
+// printSteps prints the numbered steps of the recipe with the minutes and the temperature they need
+func printSteps(recipe *apis.Recipe) {
+	if recipe.PrepMinutes > 0 || recipe.CookMinutes > 0 {
+		fmt.Printf("Prep %d min, cook %d min\n", recipe.PrepMinutes, recipe.CookMinutes)
+	}
+
+	for i, step := range recipe.Steps {
+		var details []string
+		if step.DurationMinutes > 0 {
+			details = append(details, strconv.FormatUint(uint64(step.DurationMinutes), 10)+" min")
+		}
+		if step.Temperature != nil {
+			details = append(details, strconv.FormatFloat(step.Temperature.Degrees, 'f', -1, 64)+" "+step.Temperature.Unit)
+		}
+
+		line := strconv.Itoa(i+1) + ". " + step.Text
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+func printIngredients(recipe *apis.Recipe) {
+	if recipe.Servings > 0 {
+		fmt.Println("Serves " + strconv.FormatUint(uint64(recipe.Servings), 10))
+	}
+
+	for _, ingredient := range recipe.Ingredients {
+		fmt.Println(ingredient.Name + " - " + string(ingredient.Quantity) + " " + ingredient.Measurement)
+	}
+}
+
 func (rm *RecipeMenu) printCommentsMenu(recipeId int) {
 	var command int
-	for ; command != 3; {
-		fmt.Println("Print comments for recipe (1), add comment to recipe (2), exit recipe (3): ")
+	for ; command != 4; {
+		fmt.Println("Print comments for recipe (1), add comment to recipe (2), rate recipe (3), exit recipe (4): ")
 		fmt.Scanln(&command)
 
 		switch command {
@@ -165,6 +339,8 @@ func (rm *RecipeMenu) printCommentsMenu(recipeId int) {
 			rm.printComments(recipeId)
 		case 2:
 			rm.printAddComment(recipeId)
+		case 3:
+			rm.printRateRecipe(recipeId)
 		default:
 			break
 		}
@@ -181,7 +357,15 @@ func (rm *RecipeMenu) printComments(recipeId int) {
 	} else {
 		fmt.Println("Comments:")
 		for _, comment := range comments {
-			fmt.Println(comment)
+			written := comment.CreatedAt.Local().Format("2006-01-02 15:04")
+			if comment.EditedAt != nil {
+				written += " (edited)"
+			}
+			author := comment.Author
+			if author == "" {
+				author = "Anonymous"
+			}
+			fmt.Println(author + " on " + written + ": " + comment.Comment)
 		}
 	}
 }
@@ -202,6 +386,32 @@ func (rm *RecipeMenu) printAddComment(recipeId int) {
 	}
 }
 
+func (rm *RecipeMenu) printRateRecipe(recipeId int) {
+	var rating int
+	fmt.Print("Enter rating (1-5): ")
+	_, err := fmt.Scanln(&rating)
+
+	if err != nil || rating < 1 || rating > 5 {
+		fmt.Println("Rating must be a number between 1 and 5. Try again!")
+		return
+	}
+
+	if err = rm.RecipeApi.RateById(recipeId, rating); err != nil {
+		fmt.Println("Failed to rate the recipe")
+	} else {
+		fmt.Println("Recipe is rated")
+	}
+}
+
+// formatRating shows the average rating with one decimal and the number of ratings
+func formatRating(average float64, count int) string {
+	if count == 0 {
+		return "(not rated)"
+	}
+
+	return "(" + strconv.FormatFloat(average, 'f', 1, 64) + "/5 from " + strconv.Itoa(count) + ")"
+}
+
 func (rm *RecipeMenu) printCreateRecipe() {
 	fmt.Println("Create recipe")
 
@@ -216,6 +426,10 @@ func (rm *RecipeMenu) printCreateRecipe() {
 	}
 	title = strings.Trim(title, "\n")
 
+	var servings uint
+	fmt.Print("Servings (leave empty if unknown): ")
+	fmt.Scanln(&servings)
+
 	var ingredients []apis.Ingredient
 	for hasNext := true; hasNext; {
 		fmt.Println("Enter information for ingredient")
@@ -237,10 +451,21 @@ func (rm *RecipeMenu) printCreateRecipe() {
 	}
 	directions = strings.Trim(directions, "\n")
 
+	fmt.Print("Enter tags (separated by , or leave empty): ")
+	tags, err := reader.ReadString('\n')
+
+	if err != nil {
+		fmt.Println("Failed to load the tags.")
+		rm.RecipeMenuChannel <- 3
+		return
+	}
+
 	err = rm.RecipeApi.CreateRecipe(apis.Recipe{
 		Title:       title,
+		Servings:    servings,
 		Ingredients: ingredients,
 		Directions:  directions,
+		Tags:        strings.Split(strings.Trim(tags, "\n"), ","),
 	})
 
 	if err != nil {
@@ -253,18 +478,21 @@ func (rm *RecipeMenu) printCreateRecipe() {
 }
 
 func readIngredient() apis.Ingredient {
-	var name, measurement string
-	var quantity int
+	var name, quantity, fraction, measurement string
 	fmt.Print("Name: ")
 	fmt.Scanln(&name)
-	fmt.Print("Quantity: ")
-	fmt.Scanln(&quantity)
-	fmt.Print("Measurement: ")
+	fmt.Print("Quantity (like 2, 0.25 or 1 1/2): ")
+	// a mixed number is typed as two words
+	fmt.Scanln(&quantity, &fraction)
+	if fraction != "" {
+		quantity += " " + fraction
+	}
+	fmt.Print("Measurement (like g, ml, tbsp, cup or pcs): ")
 	fmt.Scanln(&measurement)
 
 	return apis.Ingredient{
 		Name:        name,
-		Quantity:    quantity,
+		Quantity:    apis.Quantity(quantity),
 		Measurement: measurement,
 	}
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/krasimiraMilkova/cookit/client/internal/apis"
 	"golang.org/x/term"
-	"net/http"
 	"syscall"
 )
 
@@ -24,8 +23,8 @@ func GetUserMenu() *UserMenu {
 
 // GetAccessToken function handles stdin/stdout operations for registration and login
 // Uses the user api for the requests and to obtain the user token
-func (um *UserMenu) GetAccessToken() []*http.Cookie {
-	fmt.Print("Registration (1) or Log in (2): ")
+func (um *UserMenu) GetAccessToken() *apis.Credentials {
+	fmt.Print("Registration (1), Log in (2) or Forgot password (3): ")
 
 	var command int
 	fmt.Scan(&command)
@@ -36,6 +35,14 @@ func (um *UserMenu) GetAccessToken() []*http.Cookie {
 			fmt.Println("Failed to register")
 			return um.GetAccessToken()
 		}
+		fmt.Println("Confirm your email with the link we sent you before posting recipes and comments")
+	}
+
+	if command == 3 {
+		if err := um.resetPassword(); err != nil {
+			fmt.Println("Failed to reset the password")
+		}
+		return um.GetAccessToken()
 	}
 
 	return um.logIn()
@@ -64,7 +71,7 @@ func (um *UserMenu) register() error {
 	return err
 }
 
-func (um *UserMenu) logIn() []*http.Cookie {
+func (um *UserMenu) logIn() *apis.Credentials {
 	fmt.Println("Log in")
 
 	var email, password string
@@ -76,8 +83,40 @@ func (um *UserMenu) logIn() []*http.Cookie {
 		}
 	}
 
-	cookies := um.UserApi.LogIn(email, password)
-	return cookies
+	credentials := um.UserApi.LogIn(email, password)
+	return credentials
+}
+
+func (um *UserMenu) resetPassword() error {
+	fmt.Print("Enter user email: ")
+	var email string
+	if _, err := fmt.Scan(&email); err != nil {
+		return err
+	}
+
+	if err := um.UserApi.ForgotPassword(email); err != nil {
+		return err
+	}
+
+	fmt.Print("Enter the token from the email: ")
+	var token string
+	if _, err := fmt.Scan(&token); err != nil {
+		return err
+	}
+
+	fmt.Print("Enter new Password: ")
+	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+
+	if err = um.UserApi.ResetPassword(token, string(bytePassword)); err != nil {
+		return err
+	}
+
+	fmt.Println("Password changed, log in with the new password")
+	return nil
 }
 
 func getCredentials(full bool) (string, string, string, error) {
@@ -1,21 +1,75 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"github.com/krasimiraMilkova/cookit/internal/appconfig"
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	rs "github.com/krasimiraMilkova/cookit/internal/recipes/service"
 	"github.com/krasimiraMilkova/cookit/internal/routes"
 	"github.com/krasimiraMilkova/cookit/internal/users/auth"
+	us "github.com/krasimiraMilkova/cookit/internal/users/service"
+	"github.com/krasimiraMilkova/cookit/pkg/recipes"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"github.com/rs/cors"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	migrateUsage   = "usage: cookit migrate up|down|status|to N"
+	genkeyUsage    = "usage: cookit genkey <kid> rsa|ec|ed25519"
+	roleUsage      = "usage: cookit role <email> user|moderator|admin"
+	nutrientsUsage = "usage: cookit nutrients <file.csv>"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		if err := reindex(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "nutrients" {
+		if err := nutrients(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "genkey" {
+		if err := genkey(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		if err := role(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	router := routes.Handlers()
 
 	http.Handle("/", router)
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowCredentials: true,
-		AllowedHeaders:   []string{auth.TokenName},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders:   []string{auth.TokenName, "Authorization"},
 	})
 
 	handler := c.Handler(router)
@@ -25,3 +79,214 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// migrate handles the migrate command for the configured storage and prints the resulting schema status
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	database, dialect, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if dialect.Name() == db.Memory {
+		return errors.New("the memory storage is migrated when the server starts")
+	}
+
+	migrator, err := db.NewMigrator(database, dialect)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return errors.New(migrateUsage)
+		}
+
+		err = migrator.To(version)
+	case "status":
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	current, _ := migrator.Current()
+	fmt.Printf("schema version %d, latest %d\n", current, migrator.Latest())
+	for _, status := range statuses {
+		state := "pending"
+		if status.Applied {
+			state = "applied"
+		}
+		fmt.Printf("%04d %s %s\n", status.Version, status.Name, state)
+	}
+
+	return nil
+}
+
+// reindex handles the reindex command canonicalizing the ingredient names and rebuilding
+// the full text search index of all recipes in the configured storage
+func reindex() error {
+	database, dialect, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database, dialect)
+	if err != nil {
+		return err
+	}
+
+	if err = migrator.Check(); err != nil {
+		return err
+	}
+
+	repository := &rs.RecipeRepository{DB: database, Dialect: dialect}
+	canonicalized, err := repository.CanonicalizeIngredients()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("canonicalized %d ingredients\n", canonicalized)
+	indexed, err := repository.ReindexRecipes()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("indexed %d recipes\n", indexed)
+	return nil
+}
+
+// nutrients handles the nutrients command loading the nutrients of ingredients from a csv, like a USDA export,
+// into the configured storage, replacing the nutrients of ingredients which are already known
+func nutrients(args []string) error {
+	if len(args) != 1 {
+		return errors.New(nutrientsUsage)
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ingredients, err := recipes.ParseNutrientsCSV(file)
+	if err != nil {
+		return err
+	}
+
+	database, dialect, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if dialect.Name() == db.Memory {
+		return errors.New("the memory storage cannot be reached by another process")
+	}
+
+	migrator, err := db.NewMigrator(database, dialect)
+	if err != nil {
+		return err
+	}
+
+	if err = migrator.Check(); err != nil {
+		return err
+	}
+
+	repository := &rs.RecipeRepository{DB: database, Dialect: dialect}
+	saved, err := repository.SaveIngredientNutrients(ingredients)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("loaded the nutrients of %d ingredients\n", saved)
+	return nil
+}
+
+// genkey handles the genkey command writing a new token signing key with the given id to the keys directory
+// The key only verifies tokens until JWT_SIGNING_KEY is set to its id
+func genkey(args []string) error {
+	if len(args) != 2 {
+		return errors.New(genkeyUsage)
+	}
+
+	dir := filepath.Join(appconfig.Get().GetProjectDir(), "keys")
+	if err := auth.GenerateKeyFiles(dir, args[0], args[1]); err != nil {
+		return err
+	}
+
+	fmt.Printf("generated %s key %s in %s\n", args[1], args[0], dir)
+	return nil
+}
+
+// role handles the role command changing the role of the user with the given email,
+// which is how the first admin is appointed
+func role(args []string) error {
+	if len(args) != 2 || !users.Role(args[1]).Valid() {
+		return errors.New(roleUsage)
+	}
+
+	database, dialect, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if dialect.Name() == db.Memory {
+		return errors.New("the memory storage cannot be reached by another process")
+	}
+
+	migrator, err := db.NewMigrator(database, dialect)
+	if err != nil {
+		return err
+	}
+
+	if err = migrator.Check(); err != nil {
+		return err
+	}
+
+	repository := &us.UserRepository{DB: database, Dialect: dialect}
+	user, err := repository.FindUserByEmail(args[0])
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return users.ErrUserNotFound
+	}
+
+	if err = repository.UpdateUserRole(user.ID, users.Role(args[1])); err != nil {
+		return err
+	}
+
+	// the sessions are ended, so the new role is used from the next login
+	sessions := &auth.SessionRepository{DB: database, Dialect: dialect}
+	if err = sessions.RevokeUserSessions(user.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("user %s is now %s\n", user.Email, args[1])
+	return nil
+}
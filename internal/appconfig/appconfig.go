@@ -4,25 +4,76 @@ import (
 	"github.com/spf13/viper"
 	"log"
 	"os"
+	"strings"
 	"sync"
+	"time"
 )
 
 // appConfig provides db and project config values
 type appConfig struct {
-	db_name     string
-	db_password string
-	db_username string
-	db_host     string
-	project_dir string
+	storage_driver string
+	sqlite_path    string
+	db_name        string
+	db_password    string
+	db_username    string
+	db_host        string
+	project_dir    string
+	secure_cookies bool
+	signing_key_id string
+	app_url        string
+	mailer         string
+	mail_dir       string
+	mail_from      string
+	smtp_host      string
+	smtp_port      int
+	smtp_username  string
+	smtp_password  string
+	// failed logins throttling
+	login_attempts_store       string
+	login_max_failures         int
+	login_max_address_failures int
+	login_backoff              time.Duration
+	login_max_backoff          time.Duration
+	login_lockout              time.Duration
+	max_body_bytes             int64
 }
 
 // AppConfig interface provide methods for obtaining config values
 type AppConfig interface {
+	// GetStorageConfig function returns the storage driver (mysql, sqlite or memory)
+	// and the database file used by the sqlite driver
+	GetStorageConfig() (driver string, sqlitePath string)
+
 	// GetDBConfig function returns db connection information
 	GetDBConfig() (username string, password string, databaseName string, databaseHost string)
 
 	// GetProjectDir function returns the project directory path
 	GetProjectDir() (projectDir string)
+
+	// GetSecureCookies function tells if the token cookies should be sent only over https
+	GetSecureCookies() (secure bool)
+
+	// GetSigningKeyID function returns the id of the key signing new access tokens
+	GetSigningKeyID() (keyID string)
+
+	// GetAppURL function returns the public url of the app used in the links sent by email
+	GetAppURL() (appURL string)
+
+	// GetMailConfig function returns the mailer driver (smtp or file), the directory the file mailer
+	// writes the emails to and the sender address
+	GetMailConfig() (driver string, dir string, from string)
+
+	// GetSMTPConfig function returns the SMTP server connection information
+	GetSMTPConfig() (host string, port int, username string, password string)
+
+	// GetLoginLockoutConfig function returns the store of the failed logins (database or memory),
+	// the failures after which an account and a client address are locked out, the first and the longest delay
+	// between failed logins and the duration of the lockout
+	GetLoginLockoutConfig() (store string, maxFailures int, maxAddressFailures int,
+		backoff time.Duration, maxBackoff time.Duration, lockout time.Duration)
+
+	// GetMaxBodyBytes function returns the largest request body in bytes the handlers read
+	GetMaxBodyBytes() (maxBytes int64)
 }
 
 var config appConfig
@@ -37,6 +88,10 @@ func Get() AppConfig {
 	return &config
 }
 
+func (config *appConfig) GetStorageConfig() (driver string, sqlitePath string) {
+	return config.storage_driver, config.sqlite_path
+}
+
 func (config *appConfig) GetDBConfig() (username string, password string, databaseName string, databaseHost string) {
 	username = config.db_username
 	password = config.db_password
@@ -49,6 +104,36 @@ func (config *appConfig) GetProjectDir() (projectDir string) {
 	return config.project_dir
 }
 
+func (config *appConfig) GetSecureCookies() (secure bool) {
+	return config.secure_cookies
+}
+
+func (config *appConfig) GetSigningKeyID() (keyID string) {
+	return config.signing_key_id
+}
+
+func (config *appConfig) GetAppURL() (appURL string) {
+	return config.app_url
+}
+
+func (config *appConfig) GetMailConfig() (driver string, dir string, from string) {
+	return config.mailer, config.mail_dir, config.mail_from
+}
+
+func (config *appConfig) GetSMTPConfig() (host string, port int, username string, password string) {
+	return config.smtp_host, config.smtp_port, config.smtp_username, config.smtp_password
+}
+
+func (config *appConfig) GetLoginLockoutConfig() (store string, maxFailures int, maxAddressFailures int,
+	backoff time.Duration, maxBackoff time.Duration, lockout time.Duration) {
+	return config.login_attempts_store, config.login_max_failures, config.login_max_address_failures,
+		config.login_backoff, config.login_max_backoff, config.login_lockout
+}
+
+func (config *appConfig) GetMaxBodyBytes() (maxBytes int64) {
+	return config.max_body_bytes
+}
+
 func (config *appConfig) loadConfiguration() {
 	config.project_dir, _ = os.Getwd()
 
@@ -58,15 +143,49 @@ func (config *appConfig) loadConfiguration() {
 	// Enable VIPER to read Environment Variables
 	viper.AutomaticEnv()
 	viper.SetConfigType("env")
+	viper.SetDefault("STORAGE_DRIVER", "mysql")
+	viper.SetDefault("SQLITE_PATH", "cookit.db")
+	viper.SetDefault("SECURE_COOKIES", false)
+	viper.SetDefault("JWT_SIGNING_KEY", "app")
+	viper.SetDefault("APP_URL", "http://localhost:8080")
+	viper.SetDefault("MAILER", "file")
+	viper.SetDefault("MAIL_FROM", "cookit@localhost")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("LOGIN_ATTEMPTS_STORE", "database")
+	viper.SetDefault("LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("LOGIN_MAX_ADDRESS_FAILURES", 20)
+	viper.SetDefault("LOGIN_BACKOFF", "1s")
+	viper.SetDefault("LOGIN_MAX_BACKOFF", "30s")
+	viper.SetDefault("LOGIN_LOCKOUT", "15m")
+	viper.SetDefault("MAX_BODY_BYTES", 1<<20)
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file, %s", err)
 	}
 
+	config.storage_driver = viper.GetString("STORAGE_DRIVER")
+	config.sqlite_path = viper.GetString("SQLITE_PATH")
 	config.db_name = viper.GetString("MYSQL_DATABASE")
 	config.db_host = viper.GetString("MYSQL_SERVICE_HOST")
 	config.db_username = viper.GetString("MYSQL_USERNAME")
 	config.db_password = viper.GetString("MYSQL_PASSWORD")
+	config.secure_cookies = viper.GetBool("SECURE_COOKIES")
+	config.signing_key_id = viper.GetString("JWT_SIGNING_KEY")
+	config.app_url = strings.TrimSuffix(viper.GetString("APP_URL"), "/")
+	config.mailer = viper.GetString("MAILER")
+	config.mail_dir = viper.GetString("MAIL_DIR")
+	config.mail_from = viper.GetString("MAIL_FROM")
+	config.smtp_host = viper.GetString("SMTP_HOST")
+	config.smtp_port = viper.GetInt("SMTP_PORT")
+	config.smtp_username = viper.GetString("SMTP_USERNAME")
+	config.smtp_password = viper.GetString("SMTP_PASSWORD")
+	config.login_attempts_store = viper.GetString("LOGIN_ATTEMPTS_STORE")
+	config.login_max_failures = viper.GetInt("LOGIN_MAX_FAILURES")
+	config.login_max_address_failures = viper.GetInt("LOGIN_MAX_ADDRESS_FAILURES")
+	config.login_backoff = viper.GetDuration("LOGIN_BACKOFF")
+	config.login_max_backoff = viper.GetDuration("LOGIN_MAX_BACKOFF")
+	config.login_lockout = viper.GetDuration("LOGIN_LOCKOUT")
+	config.max_body_bytes = viper.GetInt64("MAX_BODY_BYTES")
 
 	return
 }
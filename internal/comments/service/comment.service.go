@@ -3,12 +3,13 @@ package service
 import (
 	"encoding/json"
 	"github.com/gorilla/mux"
+	"github.com/krasimiraMilkova/cookit/internal/problem"
+	"github.com/krasimiraMilkova/cookit/internal/request"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
 	"github.com/krasimiraMilkova/cookit/pkg/comments"
-	"io/ioutil"
-	"log"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 type CommentService struct {
@@ -25,56 +26,157 @@ func Get() *CommentService {
 	return commentService
 }
 
-type Comment struct {
-	Comment string `json:"comment"`
-}
+// errInvalidRecipeId is written when the recipe id path variable is not a number
+var errInvalidRecipeId = apperror.Field("recipeId", "must be a number")
 
 func (cs *CommentService) AddComment(w http.ResponseWriter, r *http.Request) {
+	user, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
 	recipeId, err := strconv.Atoi(mux.Vars(r)["recipeId"])
 
 	if err != nil {
-		log.Print("Cannot parse recipe id")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, errInvalidRecipeId)
 		return
 	}
 
-	body, err := ioutil.ReadAll(r.Body)
-	comment := string(body)
+	payload, ok := decodeComment(w, r)
+
+	if !ok {
+		return
+	}
 
-	err = cs.CommentRepository.AddComment(recipeId, comment)
+	comment := &comments.Comment{RecipeID: uint(recipeId), AuthorID: user.ID, Author: user.Name, Comment: payload.Comment}
+	err = cs.CommentRepository.AddComment(comment)
 
 	if err != nil {
-		if strings.Contains(err.Error(), "Cannot add or update") {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+		problem.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
 }
 
 func (cs *CommentService) GetComments(w http.ResponseWriter, r *http.Request) {
 	recipeId, err := strconv.Atoi(mux.Vars(r)["recipeId"])
 
 	if err != nil {
-		log.Print("Cannot parse recipe id")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, errInvalidRecipeId)
 		return
 	}
 
 	foundComments, err := cs.CommentRepository.GetComments(recipeId)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
 	if len(foundComments) == 0 {
-		w.WriteHeader(http.StatusNotFound)
+		problem.Write(w, r, apperror.NotFound("the recipe has no comments"))
 		return
 	}
 
 	json.NewEncoder(w).Encode(foundComments)
 }
+
+func (cs *CommentService) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := cs.findOwnedComment(w, r, "")
+
+	if !ok {
+		return
+	}
+
+	payload, ok := decodeComment(w, r)
+
+	if !ok {
+		return
+	}
+
+	comment.Comment = payload.Comment
+
+	if err := cs.CommentRepository.UpdateComment(comment); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (cs *CommentService) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := cs.findOwnedComment(w, r, users.RoleModerator)
+
+	if !ok {
+		return
+	}
+
+	if err := cs.CommentRepository.DeleteComment(int(comment.ID)); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeComment reads the comment payload of the request, only its text is used
+// Writes Status BadRequest and returns false if it cannot be decoded or the comment is empty or too long
+func decodeComment(w http.ResponseWriter, r *http.Request) (*comments.Comment, bool) {
+	payload := &comments.Comment{}
+
+	if !request.DecodeValid(w, r, "comment", payload) {
+		return nil, false
+	}
+
+	return payload, true
+}
+
+// findOwnedComment fetches the comment with id and recipeId from the path variables and checks that
+// the user from the request context is its author or has the given role, an empty role allows only the author
+// Writes the error status and returns false if the comment cannot be modified by the user
+func (cs *CommentService) findOwnedComment(w http.ResponseWriter, r *http.Request, role users.Role) (*comments.Comment, bool) {
+	user, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return nil, false
+	}
+
+	vars := mux.Vars(r)
+	recipeId, err := strconv.Atoi(vars["recipeId"])
+
+	if err != nil {
+		problem.Write(w, r, errInvalidRecipeId)
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(vars["id"])
+
+	if err != nil {
+		problem.Write(w, r, apperror.Field("id", "must be a number"))
+		return nil, false
+	}
+
+	comment, err := cs.CommentRepository.FindCommentById(id)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return nil, false
+	}
+
+	if comment == nil || comment.RecipeID != uint(recipeId) {
+		problem.Write(w, r, comments.ErrCommentNotFound)
+		return nil, false
+	}
+
+	if comment.AuthorID != user.ID && (role == "" || !user.HasRole(role)) {
+		problem.Write(w, r, apperror.Forbidden("the comment belongs to another user"))
+		return nil, false
+	}
+
+	return comment, true
+}
@@ -2,51 +2,127 @@ package service
 
 import (
 	"database/sql"
-	"errors"
 	"github.com/krasimiraMilkova/cookit/internal/db"
 	"github.com/krasimiraMilkova/cookit/pkg/comments"
+	"github.com/krasimiraMilkova/cookit/pkg/validation"
+	"time"
 )
 
 type CommentRepository struct {
 	*sql.DB
+	Dialect db.Dialect
 }
 
 func GetCommentRepository() comments.CommentRepository {
-	return &CommentRepository{db.Get()}
+	return &CommentRepository{DB: db.Get(), Dialect: db.GetDialect()}
 }
 
-func (commentRepository *CommentRepository) AddComment(recipeId int, comment string) error {
-	if comment == "" {
-		return errors.New("comment cannot be empty")
+// commentQuery selects the comments c with the names of their authors
+const commentQuery = "select c.id, c.recipe_id, c.author_id, coalesce(u.name, ''), c.comment, c.created_at, c.edited_at " +
+	"from comments as c left join users as u on u.id = c.author_id "
+
+func (commentRepository *CommentRepository) AddComment(comment *comments.Comment) error {
+	if err := validation.Validate("comment", comment); err != nil {
+		return err
+	}
+
+	createdAt := time.Now().UTC()
+	result, err := commentRepository.Exec("insert into comments(recipe_id, author_id, comment, created_at)values(?,?,?,?);",
+		comment.RecipeID, comment.AuthorID, comment.Comment, createdAt)
+
+	if commentRepository.Dialect.IsForeignKeyViolation(err) {
+		return comments.ErrRecipeNotFound
 	}
 
-	_, err := commentRepository.Exec("insert into comments(recipe_id, comment)values(?,?);", recipeId, comment)
+	if err != nil {
+		return err
+	}
 
+	id, err := result.LastInsertId()
 	if err != nil {
 		return err
 	}
 
+	comment.ID = uint(id)
+	comment.CreatedAt = createdAt
+	comment.EditedAt = nil
 	return nil
 }
 
-func (commentRepository *CommentRepository) GetComments(recipeId int) ([]string, error) {
-	resultRows, err := commentRepository.Query("select comment from comments where recipe_id = ?", recipeId)
+func (commentRepository *CommentRepository) GetComments(recipeId int) ([]comments.Comment, error) {
+	resultRows, err := commentRepository.Query(commentQuery+"where c.recipe_id = ? order by c.created_at, c.id;", recipeId)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var foundComments []string
+	var foundComments []comments.Comment
 
 	defer resultRows.Close()
 	for resultRows.Next() {
-		var comment string
-		err = resultRows.Scan(&comment)
+		comment, err := scanComment(resultRows)
 
-		if err == nil {
-			foundComments = append(foundComments, comment)
+		if err != nil {
+			return nil, err
 		}
+
+		foundComments = append(foundComments, *comment)
+	}
+
+	return foundComments, resultRows.Err()
+}
+
+func (commentRepository *CommentRepository) FindCommentById(id int) (*comments.Comment, error) {
+	comment, err := scanComment(commentRepository.QueryRow(commentQuery+"where c.id = ?;", id))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return comment, err
+}
+
+func (commentRepository *CommentRepository) UpdateComment(comment *comments.Comment) error {
+	if err := validation.Validate("comment", comment); err != nil {
+		return err
+	}
+
+	editedAt := time.Now().UTC()
+	_, err := commentRepository.Exec("update comments set comment = ?, edited_at = ? where id = ?;",
+		comment.Comment, editedAt, comment.ID)
+
+	if err != nil {
+		return err
+	}
+
+	comment.EditedAt = &editedAt
+	return nil
+}
+
+func (commentRepository *CommentRepository) DeleteComment(id int) error {
+	_, err := commentRepository.Exec("delete from comments where id = ?;", id)
+	return err
+}
+
+// scanner is implemented by both sql.Row and sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanComment reads a comment selected by the commentQuery
+func scanComment(row scanner) (*comments.Comment, error) {
+	comment := &comments.Comment{}
+	var editedAt sql.NullTime
+
+	err := row.Scan(&comment.ID, &comment.RecipeID, &comment.AuthorID, &comment.Author, &comment.Comment,
+		&comment.CreatedAt, &editedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if editedAt.Valid {
+		comment.EditedAt = &editedAt.Time
 	}
 
-	return foundComments, nil
+	return comment, nil
 }
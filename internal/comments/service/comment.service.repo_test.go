@@ -0,0 +1,96 @@
+package service
+
+import (
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/comments"
+	"testing"
+)
+
+func TestCommentRepository_AddAndGetComments(t *testing.T) {
+	database, dialect, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	defer database.Close()
+
+	repository := &CommentRepository{DB: database, Dialect: dialect}
+
+	if err := repository.AddComment(&comments.Comment{RecipeID: 1, Comment: "Tasty"}); err != comments.ErrRecipeNotFound {
+		t.Errorf("expected ErrRecipeNotFound for a missing recipe got %v", err)
+	}
+
+	for _, statement := range []string{
+		"insert into recipes(title)values('Soup');",
+		"insert into users(id, name, email, password)values(3, 'Chef', 'chef@test.com', 'x');",
+	} {
+		if _, err = database.Exec(statement); err != nil {
+			t.Fatal("cannot insert test data", err)
+		}
+	}
+
+	added := []*comments.Comment{
+		{RecipeID: 1, AuthorID: 3, Comment: "Tasty"},
+		{RecipeID: 1, AuthorID: 3, Comment: "Too salty"},
+	}
+	for _, comment := range added {
+		if err := repository.AddComment(comment); err != nil {
+			t.Fatal("unexpected error on add", err)
+		}
+
+		if comment.ID == 0 || comment.CreatedAt.IsZero() {
+			t.Errorf("expected the id and creation time to be set got %v", comment)
+		}
+	}
+
+	found, err := repository.GetComments(1)
+	if err != nil {
+		t.Fatal("unexpected error on get", err)
+	}
+
+	if len(found) != 2 || found[0].Comment != "Tasty" || found[1].Comment != "Too salty" ||
+		found[0].Author != "Chef" || found[0].AuthorID != 3 || found[0].EditedAt != nil {
+		t.Errorf("Got comments = %v", found)
+	}
+}
+
+func TestCommentRepository_UpdateAndDeleteComment(t *testing.T) {
+	database, dialect, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	defer database.Close()
+
+	repository := &CommentRepository{DB: database, Dialect: dialect}
+
+	if _, err = database.Exec("insert into recipes(title)values('Soup');"); err != nil {
+		t.Fatal("cannot insert recipe", err)
+	}
+
+	comment := &comments.Comment{RecipeID: 1, AuthorID: 3, Comment: "Tasty"}
+	if err := repository.AddComment(comment); err != nil {
+		t.Fatal("unexpected error on add", err)
+	}
+
+	comment.Comment = "Very tasty"
+	if err := repository.UpdateComment(comment); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	found, err := repository.FindCommentById(int(comment.ID))
+	if err != nil || found == nil {
+		t.Fatal("unexpected error on find", err)
+	}
+
+	if found.Comment != "Very tasty" || found.EditedAt == nil || found.RecipeID != 1 {
+		t.Errorf("Got comment = %v after update", found)
+	}
+
+	if err := repository.DeleteComment(int(comment.ID)); err != nil {
+		t.Fatal("unexpected error on delete", err)
+	}
+
+	found, err = repository.FindCommentById(int(comment.ID))
+	if err != nil || found != nil {
+		t.Errorf("expected the comment to be deleted got %v, %v", found, err)
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"github.com/krasimiraMilkova/cookit/mocks"
+	"github.com/krasimiraMilkova/cookit/pkg/comments"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCommentService_AddComment(t *testing.T) {
@@ -24,58 +27,82 @@ func TestCommentService_AddComment(t *testing.T) {
 
 	tests := []struct {
 		name               string
-		comment            string
+		payload            string
 		recipeId           string
-		repositoryError    string
+		withoutUser        bool
+		repositoryError    error
 		expectedStatusCode int
 	}{
 		{
 			name:               "Successful",
-			comment:            "Some comment",
+			payload:            `{"comment": "Some comment"}`,
 			recipeId:           "1",
-			repositoryError:    "",
+			repositoryError:    nil,
 			expectedStatusCode: http.StatusCreated,
 		},
+		{
+			name:               "Missing user",
+			payload:            `{"comment": "Some comment"}`,
+			recipeId:           "1",
+			withoutUser:        true,
+			expectedStatusCode: http.StatusForbidden,
+		},
 		{
 			name:               "Cannot parse id",
-			comment:            "Smth",
+			payload:            `{"comment": "Smth"}`,
 			recipeId:           "a",
-			repositoryError:    "",
+			repositoryError:    nil,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Cannot decode payload",
+			payload:            `Some comment`,
+			recipeId:           "1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Empty comment",
+			payload:            `{"comment": ""}`,
+			recipeId:           "1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Too long comment",
+			payload:            `{"comment": "` + strings.Repeat("a", 5001) + `"}`,
+			recipeId:           "1",
 			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:               "Recipe not found",
-			comment:            "Some comment",
+			payload:            `{"comment": "Some comment"}`,
 			recipeId:           "2",
-			repositoryError:    "Cannot add or update",
+			repositoryError:    comments.ErrRecipeNotFound,
 			expectedStatusCode: http.StatusNotFound,
 		},
 		{
 			name:               "Repository error",
-			comment:            "Some comment",
+			payload:            `{"comment": "Some comment"}`,
 			recipeId:           "2",
-			repositoryError:    "some other error",
+			repositoryError:    errors.New("some other error"),
 			expectedStatusCode: http.StatusInternalServerError,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			jsonComment, _ := json.Marshal(test.comment)
-			req, _ := http.NewRequest("POST", "/recipe/"+test.recipeId+"/comment", strings.NewReader(string(jsonComment)))
+			req, _ := http.NewRequest("POST", "/recipe/"+test.recipeId+"/comment", strings.NewReader(test.payload))
+			if !test.withoutUser {
+				req = withUser(req, 3)
+			}
 			req = mux.SetURLVars(req, map[string]string{
 				"recipeId": test.recipeId,
 			})
 			rr := httptest.NewRecorder()
 
-			var err error
-			if test.repositoryError != "" {
-				err = errors.New(test.repositoryError)
-			}
-
-			if test.expectedStatusCode != http.StatusBadRequest {
+			if test.expectedStatusCode != http.StatusBadRequest && test.expectedStatusCode != http.StatusForbidden {
 				id, _ := strconv.Atoi(test.recipeId)
-				mockRepository.EXPECT().AddComment(id, string(jsonComment)).Return(err)
+				expected := &comments.Comment{RecipeID: uint(id), AuthorID: 3, Author: "Chef", Comment: "Some comment"}
+				mockRepository.EXPECT().AddComment(expected).Return(test.repositoryError)
 			}
 			http.HandlerFunc(service.AddComment).ServeHTTP(rr, req)
 
@@ -98,35 +125,38 @@ func TestCommentService_GetComments(t *testing.T) {
 
 	tests := []struct {
 		name               string
-		comments           []string
+		comments           []comments.Comment
 		recipeId           string
 		repositoryError    string
 		expectedStatusCode int
 	}{
 		{
-			name:               "Successful",
-			comments:           []string{"Some comment", "Another"},
+			name: "Successful",
+			comments: []comments.Comment{
+				{ID: 1, RecipeID: 1, AuthorID: 1, Author: "Chef", Comment: "Some comment", CreatedAt: time.Unix(100, 0).UTC()},
+				{ID: 2, RecipeID: 1, AuthorID: 2, Author: "Cook", Comment: "Another", CreatedAt: time.Unix(200, 0).UTC()},
+			},
 			recipeId:           "1",
 			repositoryError:    "",
 			expectedStatusCode: http.StatusOK,
 		},
 		{
 			name:               "Cannot parse id",
-			comments:           []string{},
+			comments:           []comments.Comment{},
 			recipeId:           "a",
 			repositoryError:    "",
 			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:               "No comments found",
-			comments:           []string{},
+			comments:           []comments.Comment{},
 			recipeId:           "2",
 			repositoryError:    "",
 			expectedStatusCode: http.StatusNotFound,
 		},
 		{
 			name:               "Repository error",
-			comments:           []string{},
+			comments:           []comments.Comment{},
 			recipeId:           "2",
 			repositoryError:    "some error",
 			expectedStatusCode: http.StatusInternalServerError,
@@ -158,9 +188,9 @@ func TestCommentService_GetComments(t *testing.T) {
 				t.Fail()
 			}
 
-			var resultComments []string
+			var resultComments []comments.Comment
 			s := rr.Body.String()
-			if s != "" {
+			if rr.Code == http.StatusOK {
 				err = json.Unmarshal([]byte(s), &resultComments)
 
 				if err != nil {
@@ -176,3 +206,260 @@ func TestCommentService_GetComments(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentService_UpdateComment(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockCommentRepository(mockCtrl)
+
+	service := CommentService{CommentRepository: mockRepository}
+
+	existing := comments.Comment{ID: 5, RecipeID: 1, AuthorID: 3, Author: "Chef", Comment: "Tasty"}
+
+	tests := []struct {
+		name               string
+		recipeId           string
+		id                 string
+		userId             uint
+		role               users.Role
+		payload            string
+		found              bool
+		repositoryError    string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			recipeId:           "1",
+			id:                 "5",
+			userId:             3,
+			payload:            `{"comment": "Too salty"}`,
+			found:              true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Cannot parse id",
+			recipeId:           "1",
+			id:                 "a",
+			userId:             3,
+			payload:            `{"comment": "Too salty"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Comment not found",
+			recipeId:           "1",
+			id:                 "6",
+			userId:             3,
+			payload:            `{"comment": "Too salty"}`,
+			found:              false,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Comment of another recipe",
+			recipeId:           "2",
+			id:                 "5",
+			userId:             3,
+			payload:            `{"comment": "Too salty"}`,
+			found:              true,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Not the author",
+			recipeId:           "1",
+			id:                 "5",
+			userId:             4,
+			payload:            `{"comment": "Too salty"}`,
+			found:              true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Empty comment",
+			recipeId:           "1",
+			id:                 "5",
+			userId:             3,
+			payload:            `{"comment": ""}`,
+			found:              true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repository error",
+			recipeId:           "1",
+			id:                 "5",
+			userId:             3,
+			payload:            `{"comment": "Too salty"}`,
+			found:              true,
+			repositoryError:    "some repo error",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "Moderator cannot edit comment of another user",
+			recipeId:           "1",
+			id:                 "5",
+			userId:             4,
+			role:               users.RoleModerator,
+			payload:            `{"comment": "Too salty"}`,
+			found:              true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PUT", "/recipe/"+test.recipeId+"/comment/"+test.id, strings.NewReader(test.payload))
+			req = mux.SetURLVars(withRole(req, test.userId, test.role), map[string]string{
+				"recipeId": test.recipeId,
+				"id":       test.id,
+			})
+			rr := httptest.NewRecorder()
+
+			expectFindComment(mockRepository, test.id, test.found, existing)
+			if test.expectedStatusCode == http.StatusOK || test.expectedStatusCode == http.StatusInternalServerError {
+				var err error
+				if test.repositoryError != "" {
+					err = errors.New(test.repositoryError)
+				}
+				updated := existing
+				updated.Comment = "Too salty"
+				mockRepository.EXPECT().UpdateComment(&updated).Return(err)
+			}
+
+			http.HandlerFunc(service.UpdateComment).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				var result comments.Comment
+				if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil || result.Comment != "Too salty" {
+					t.Errorf("Got comment = %v, %v", result, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCommentService_DeleteComment(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockCommentRepository(mockCtrl)
+
+	service := CommentService{CommentRepository: mockRepository}
+
+	existing := comments.Comment{ID: 5, RecipeID: 1, AuthorID: 3, Comment: "Tasty"}
+
+	tests := []struct {
+		name               string
+		id                 string
+		userId             uint
+		role               users.Role
+		found              bool
+		repositoryError    string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			id:                 "5",
+			userId:             3,
+			found:              true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Cannot parse id",
+			id:                 "a",
+			userId:             3,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Comment not found",
+			id:                 "6",
+			userId:             3,
+			found:              false,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Not the author",
+			id:                 "5",
+			userId:             4,
+			found:              true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Moderator deletes comment of another user",
+			id:                 "5",
+			userId:             4,
+			role:               users.RoleModerator,
+			found:              true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Admin deletes comment of another user",
+			id:                 "5",
+			userId:             4,
+			role:               users.RoleAdmin,
+			found:              true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Repository error",
+			id:                 "5",
+			userId:             3,
+			found:              true,
+			repositoryError:    "some repo error",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("DELETE", "/recipe/1/comment/"+test.id, nil)
+			req = mux.SetURLVars(withRole(req, test.userId, test.role), map[string]string{
+				"recipeId": "1",
+				"id":       test.id,
+			})
+			rr := httptest.NewRecorder()
+
+			expectFindComment(mockRepository, test.id, test.found, existing)
+			if test.expectedStatusCode == http.StatusNoContent || test.repositoryError != "" {
+				var err error
+				if test.repositoryError != "" {
+					err = errors.New(test.repositoryError)
+				}
+				mockRepository.EXPECT().DeleteComment(int(existing.ID)).Return(err)
+			}
+
+			http.HandlerFunc(service.DeleteComment).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func withUser(req *http.Request, userId uint) *http.Request {
+	return withRole(req, userId, users.RoleUser)
+}
+
+func withRole(req *http.Request, userId uint, role users.Role) *http.Request {
+	user := &users.User{ID: userId, Name: "Chef", Role: role}
+	return req.WithContext(users.NewContext(req.Context(), user))
+}
+
+func expectFindComment(mockRepository *mocks.MockCommentRepository, id string, found bool, comment comments.Comment) {
+	commentId, err := strconv.Atoi(id)
+	if err != nil {
+		return
+	}
+
+	if found {
+		mockRepository.EXPECT().FindCommentById(commentId).Return(&comment, nil)
+	} else {
+		mockRepository.EXPECT().FindCommentById(commentId).Return(nil, nil)
+	}
+}
@@ -3,13 +3,21 @@ package db
 import (
 	"database/sql"
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/krasimiraMilkova/cookit/internal/appconfig"
 	"log"
-	"time"
 )
 
-var db *sql.DB
+// Storage drivers which can be selected with the STORAGE_DRIVER setting
+const (
+	MySQL  = "mysql"
+	SQLite = "sqlite"
+	Memory = "memory"
+)
+
+var (
+	db      *sql.DB
+	dialect Dialect
+)
 
 // Get is used to provide connection to the db following the singleton pattern
 func Get() *sql.DB {
@@ -19,131 +27,48 @@ func Get() *sql.DB {
 	return db
 }
 
-func initDB() {
-	db = connect()
-
-	db.SetMaxIdleConns(4)
-	db.SetMaxOpenConns(4)
-	db.SetConnMaxLifetime(time.Second * 15)
-}
-
-func connect() *sql.DB {
-	config := appconfig.Get()
-	username, password, databaseName, databaseHost := config.GetDBConfig()
-
-	dbURI := fmt.Sprintf("%s:%s@(%s)/", username, password, databaseHost)
-	db, err := createAndOpen(databaseName, dbURI)
-	if err != nil {
-		log.Fatalf(err.Error())
+// GetDialect is used to provide the dialect of the configured storage driver
+func GetDialect() Dialect {
+	if dialect == nil {
+		initDB()
 	}
-
-	return db
+	return dialect
 }
 
-func createAndOpen(name string, dbURI string) (*sql.DB, error) {
-	db, err := sql.Open("mysql", dbURI)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = db.Exec("CREATE DATABASE IF NOT EXISTS " + name)
-	if err != nil {
-		return nil, err
-	}
-
-	db, err = sql.Open("mysql", dbURI+name)
-	if err != nil {
-		return nil, err
-	}
-
-	err = createUsersTable(db)
-	if err != nil {
-		return nil, err
-	}
-
-	err = createRecipesTable(db)
-	if err != nil {
-		return nil, err
-	}
-
-	err = createIngredientsTable(db)
+// initDB connects to the configured storage and refuses to continue
+// if the schema is not at the version expected by the binary
+func initDB() {
+	var err error
+	db, dialect, err = Connect()
 	if err != nil {
-		return nil, err
+		log.Fatalf(err.Error())
 	}
 
-	err = createIngredientsForRecipeTable(db)
-	if err != nil {
-		return nil, err
+	migrator, err := NewMigrator(db, dialect)
+	if err == nil {
+		err = migrator.Check()
 	}
 
-	err = createCommentsTable(db)
 	if err != nil {
-		return nil, err
+		log.Fatalf(err.Error())
 	}
-
-	return db, nil
-}
-
-func createUsersTable(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS users (
-						id int NOT NULL AUTO_INCREMENT,
-						name varchar(100) NOT NULL,
-						email varchar(100) NOT NULL,
-						password varchar(100) NOT NULL,
-						PRIMARY KEY (id)
-					);`)
-	return err
 }
 
-func createRecipesTable(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS recipes (
-						id int NOT NULL AUTO_INCREMENT,
-						title varchar(100) NOT NULL,
-						directions text NOT NULL,
-						PRIMARY KEY (id)
-					);`)
-	return err
-}
-
-func createIngredientsTable(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ingredients (
-						id int NOT NULL AUTO_INCREMENT,
-						name varchar(100) NOT NULL,
-						PRIMARY KEY (id),
-						UNIQUE (name)
-					);`)
-	return err
-}
-
-func createIngredientsForRecipeTable(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS recipe_ingredients (
-						recipe_id int NOT NULL,
-						ingredient_id int NOT NULL,
-						quantity int NOT NULL,
-						measurement varchar(10),
-						PRIMARY KEY (recipe_id, ingredient_id),
-						FOREIGN KEY (recipe_id) 
-						    REFERENCES recipes(id)
-                        	ON DELETE CASCADE
-                            ON UPDATE CASCADE,
-						FOREIGN KEY (ingredient_id) 
-							REFERENCES ingredients(id)
-							ON DELETE CASCADE
-							ON UPDATE CASCADE
-					);`)
-	return err
-}
-
-func createCommentsTable(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS comments (
-						id int NOT NULL AUTO_INCREMENT,
-						recipe_id int NOT NULL,
-						comment text NOT NULL,
-						PRIMARY KEY (id),
-						FOREIGN KEY (recipe_id) 
-							REFERENCES recipes(id)
-							ON DELETE CASCADE
-							ON UPDATE CASCADE       
-					);`)
-	return err
+// Connect function opens a new connection to the storage selected in the app config
+// without checking the schema version
+// Returns an error if the storage driver is unknown or such occurs during connecting
+func Connect() (*sql.DB, Dialect, error) {
+	config := appconfig.Get()
+	driver, sqlitePath := config.GetStorageConfig()
+
+	switch driver {
+	case MySQL:
+		return OpenMySQL(config.GetDBConfig())
+	case SQLite:
+		return OpenSQLite(sqlitePath)
+	case Memory:
+		return OpenMemory()
+	default:
+		return nil, nil, fmt.Errorf("unsupported storage driver %q", driver)
+	}
 }
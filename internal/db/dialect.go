@@ -0,0 +1,33 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Dialect interface hides the differences between the sql engines used as storage backends
+type Dialect interface {
+	// Name function returns the storage driver the dialect belongs to
+	Name() string
+
+	// Engine function returns the sql engine behind the storage driver, which selects the migrations to run
+	Engine() string
+
+	// ResolveID function inserts the values into the columns of the table, which are unique together,
+	// if they do not exist yet
+	// Returns the id of the row holding the values or an error if such occurs during the db query execution
+	ResolveID(tx *sql.Tx, table string, columns []string, values ...interface{}) (int64, error)
+
+	// Upsert function returns an insert statement for the key and value columns of the table
+	// which updates the value columns of the row with the same key if it exists
+	Upsert(table string, keys []string, values []string) string
+
+	// IsForeignKeyViolation function checks if the error is caused by a reference to a row that does not exist
+	IsForeignKeyViolation(err error) bool
+}
+
+// insertStatement returns an insert statement with placeholders for the columns, without the closing semicolon
+func insertStatement(table string, columns []string) string {
+	return "insert into " + table + "(" + strings.Join(columns, ", ") + ")values(?" +
+		strings.Repeat(",?", len(columns)-1) + ")"
+}
@@ -0,0 +1,257 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration describes a numbered schema change with the sql for applying and reverting it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes a migration and whether it is applied to the database
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Migrator applies and reverts the migrations of a dialect and records them in the schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator function creates a migrator with the migrations embedded for the engine of the dialect
+// Returns an error if the embedded migrations cannot be loaded
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(dialect.Engine())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest function returns the version of the newest migration known to the binary
+func (migrator *Migrator) Latest() int {
+	if len(migrator.migrations) == 0 {
+		return 0
+	}
+	return migrator.migrations[len(migrator.migrations)-1].Version
+}
+
+// Current function returns the version of the newest migration applied to the database
+// Returns an error if such occurs during the db query execution
+func (migrator *Migrator) Current() (int, error) {
+	if err := migrator.createMigrationsTable(); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err := migrator.db.QueryRow("select max(version) from schema_migrations;").Scan(&version)
+	return int(version.Int64), err
+}
+
+// Status function returns every known migration and whether it is applied
+// Returns an error if such occurs during the db query execution
+func (migrator *Migrator) Status() ([]MigrationStatus, error) {
+	current, err := migrator.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrator.migrations))
+	for i, migration := range migrator.migrations {
+		statuses[i] = MigrationStatus{Migration: migration, Applied: migration.Version <= current}
+	}
+
+	return statuses, nil
+}
+
+// Up function applies all migrations which are not applied yet
+// Returns an error if such occurs during a migration, the migrations before it stay applied
+func (migrator *Migrator) Up() error {
+	return migrator.To(migrator.Latest())
+}
+
+// Down function reverts the newest applied migration
+// Returns an error if such occurs during the migration
+func (migrator *Migrator) Down() error {
+	current, err := migrator.Current()
+	if err != nil {
+		return err
+	}
+
+	if current == 0 {
+		return nil
+	}
+
+	return migrator.To(migrator.previousVersion(current))
+}
+
+// To function applies or reverts migrations until the database is at the given version
+// Returns an error if the version is unknown or such occurs during a migration
+func (migrator *Migrator) To(version int) error {
+	if version != 0 && migrator.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	current, err := migrator.Current()
+	if err != nil {
+		return err
+	}
+
+	if current > migrator.Latest() {
+		return fmt.Errorf("database schema version %d is newer than the latest known migration %d", current, migrator.Latest())
+	}
+
+	for _, migration := range migrator.migrations {
+		if migration.Version > current && migration.Version <= version {
+			if err := migrator.apply(migration, migration.Up, "insert into schema_migrations(version)values(?);"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := len(migrator.migrations) - 1; i >= 0; i-- {
+		migration := migrator.migrations[i]
+		if migration.Version <= current && migration.Version > version {
+			if err := migrator.apply(migration, migration.Down, "delete from schema_migrations where version = ?;"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check function verifies that the database schema is at the latest version known to the binary
+// Returns an error describing how to fix the schema if it is behind or ahead of the binary
+func (migrator *Migrator) Check() error {
+	current, err := migrator.Current()
+	if err != nil {
+		return err
+	}
+
+	latest := migrator.Latest()
+	if current < latest {
+		return fmt.Errorf("database schema version %d is behind the expected version %d, run `cookit migrate up`", current, latest)
+	}
+
+	if current > latest {
+		return fmt.Errorf("database schema version %d is ahead of the expected version %d, upgrade the server", current, latest)
+	}
+
+	return nil
+}
+
+// apply executes the migration sql and records the version change in the same transaction
+// MySQL commits schema changes implicitly, so there a failed migration may leave partial changes behind
+func (migrator *Migrator) apply(migration Migration, statements string, record string) error {
+	err := WithTransaction(migrator.db, func(tx *sql.Tx) error {
+		if strings.TrimSpace(statements) != "" {
+			if _, err := tx.Exec(statements); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(record, migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+func (migrator *Migrator) createMigrationsTable() error {
+	_, err := migrator.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+						version int NOT NULL,
+						applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+						PRIMARY KEY (version)
+					);`)
+	return err
+}
+
+func (migrator *Migrator) find(version int) *Migration {
+	for i := range migrator.migrations {
+		if migrator.migrations[i].Version == version {
+			return &migrator.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (migrator *Migrator) previousVersion(version int) int {
+	previous := 0
+	for _, migration := range migrator.migrations {
+		if migration.Version < version {
+			previous = migration.Version
+		}
+	}
+	return previous
+}
+
+// loadMigrations reads the migrations/<engine>/<version>_<name>.<up|down>.sql files sorted by version
+func loadMigrations(engine string) ([]Migration, error) {
+	dir := path.Join("migrations", engine)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		base := strings.TrimSuffix(fileName, ".sql")
+		direction := path.Ext(base)
+		parts := strings.SplitN(strings.TrimSuffix(base, direction), "_", 2)
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("invalid migration file name %s", fileName)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = migration
+		}
+
+		if direction == ".up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"database/sql"
+	"github.com/krasimiraMilkova/cookit/pkg/units"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	for _, engine := range []string{MySQL, SQLite} {
+		migrations, err := loadMigrations(engine)
+		if err != nil {
+			t.Fatalf("cannot load %v migrations: %v", engine, err)
+		}
+
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Errorf("%v migration %v has version %v, versions should be consecutive", engine, migration.Name, migration.Version)
+			}
+
+			if migration.Up == "" || migration.Down == "" {
+				t.Errorf("%v migration %v should have both up and down sql", engine, migration.Name)
+			}
+		}
+	}
+
+	mysqlMigrations, _ := loadMigrations(MySQL)
+	sqliteMigrations, _ := loadMigrations(SQLite)
+	if len(mysqlMigrations) != len(sqliteMigrations) {
+		t.Errorf("expected the same migrations for every engine got %v mysql and %v sqlite",
+			len(mysqlMigrations), len(sqliteMigrations))
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	database, dialect, err := openSQLite("file::memory:?_foreign_keys=on", Memory)
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	defer database.Close()
+
+	migrator, err := NewMigrator(database, dialect)
+	if err != nil {
+		t.Fatal("cannot create migrator", err)
+	}
+
+	if err := migrator.Check(); err == nil {
+		t.Error("expected check to fail for an empty database")
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatal("unexpected error on up", err)
+	}
+
+	if err := migrator.Check(); err != nil {
+		t.Error("expected check to pass after up", err)
+	}
+
+	if err := migrator.Down(); err != nil {
+		t.Fatal("unexpected error on down", err)
+	}
+
+	if current, _ := migrator.Current(); current != migrator.Latest()-1 {
+		t.Errorf("expected version %v after down got %v", migrator.Latest()-1, current)
+	}
+
+	if err := migrator.To(0); err != nil {
+		t.Fatal("unexpected error on to 0", err)
+	}
+
+	var tables int
+	database.QueryRow("select count(*) from sqlite_master where type = 'table' and name not like 'sqlite_%' and name != 'schema_migrations';").Scan(&tables)
+	if tables != 0 {
+		t.Errorf("expected no tables after reverting all migrations got %v", tables)
+	}
+
+	if err := migrator.To(migrator.Latest() + 1); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatal("unexpected error on second up", err)
+	}
+
+	database.Exec("insert into schema_migrations(version)values(?);", migrator.Latest()+1)
+	if err := migrator.Check(); err == nil {
+		t.Error("expected check to fail for a database ahead of the binary")
+	}
+}
+
+func TestMigrator_SplitsDirectionsIntoSteps(t *testing.T) {
+	database, dialect, err := openSQLite("file::memory:?_foreign_keys=on", Memory)
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	defer database.Close()
+
+	testSplitsDirectionsIntoSteps(t, database, dialect)
+}
+
+// TestMigrator_SplitsDirectionsIntoStepsOnMySQL runs the migration splitting the directions on a MySQL server,
+// whose sql differs from the sqlite one, when TEST_MYSQL_HOST names one, like localhost:3306
+// The cookit_migrate_test database is created there and reverted to version 0 by the test
+func TestMigrator_SplitsDirectionsIntoStepsOnMySQL(t *testing.T) {
+	host := os.Getenv("TEST_MYSQL_HOST")
+	if host == "" {
+		t.Skip("TEST_MYSQL_HOST is not set")
+	}
+
+	database, dialect, err := OpenMySQL(os.Getenv("TEST_MYSQL_USER"), os.Getenv("TEST_MYSQL_PASSWORD"), "cookit_migrate_test", host)
+	if err != nil {
+		t.Fatal("cannot open mysql", err)
+	}
+	defer database.Close()
+
+	testSplitsDirectionsIntoSteps(t, database, dialect)
+}
+
+// testSplitsDirectionsIntoSteps migrates a recipe with free text directions from version 13 to 14 and back,
+// starting from an empty database and leaving it empty
+func testSplitsDirectionsIntoSteps(t *testing.T, database *sql.DB, dialect Dialect) {
+	migrator, err := NewMigrator(database, dialect)
+	if err != nil {
+		t.Fatal("cannot create migrator", err)
+	}
+	defer migrator.To(0)
+
+	if err := migrator.To(0); err != nil {
+		t.Fatal("unexpected error on to 0", err)
+	}
+
+	if err := migrator.To(13); err != nil {
+		t.Fatal("unexpected error on to 13", err)
+	}
+
+	_, err = database.Exec("insert into recipes(title, directions, created_at)values(?,?,CURRENT_TIMESTAMP);",
+		"Omelette", "Whisk the eggs\r\n\n  Fry them  \nServe")
+	if err != nil {
+		t.Fatal("cannot insert recipe", err)
+	}
+
+	if err := migrator.To(14); err != nil {
+		t.Fatal("unexpected error on to 14", err)
+	}
+
+	rows, err := database.Query("select position, instruction from recipe_steps order by position;")
+	if err != nil {
+		t.Fatal("cannot query steps", err)
+	}
+
+	var steps []string
+	for rows.Next() {
+		var position int
+		var instruction string
+		rows.Scan(&position, &instruction)
+		if position != len(steps)+1 {
+			t.Errorf("Got position %v for step %q", position, instruction)
+		}
+		steps = append(steps, instruction)
+	}
+	rows.Close()
+
+	if !reflect.DeepEqual(steps, []string{"Whisk the eggs", "Fry them", "Serve"}) {
+		t.Errorf("Got steps = %q", steps)
+	}
+
+	if err := migrator.To(13); err != nil {
+		t.Fatal("unexpected error on back to 13", err)
+	}
+
+	var directions string
+	database.QueryRow("select directions from recipes;").Scan(&directions)
+	if directions != "Whisk the eggs\nFry them\nServe" {
+		t.Errorf("Got directions = %q after down", directions)
+	}
+}
+
+func TestMigrator_NormalizesLegacyMeasurements(t *testing.T) {
+	database, dialect, err := openSQLite("file::memory:?_foreign_keys=on", Memory)
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	defer database.Close()
+
+	migrator, err := NewMigrator(database, dialect)
+	if err != nil {
+		t.Fatal("cannot create migrator", err)
+	}
+
+	if err := migrator.To(11); err != nil {
+		t.Fatal("unexpected error on to 11", err)
+	}
+
+	legacy := []sql.NullString{
+		{String: "Tablespoons", Valid: true},
+		{String: " cups ", Valid: true},
+		{String: "gr", Valid: true},
+		{String: "Fluid Ounce", Valid: true},
+		{String: "to taste", Valid: true},
+		{String: "large", Valid: true},
+		{String: "", Valid: true},
+		{},
+	}
+
+	_, err = database.Exec("insert into recipes(title, directions, created_at)values('Stew', 'Cook', CURRENT_TIMESTAMP);")
+	if err != nil {
+		t.Fatal("cannot insert recipe", err)
+	}
+
+	for i, measurement := range legacy {
+		if _, err = database.Exec("insert into ingredients(id, name)values(?,?);", i+1, "ingredient "+strconv.Itoa(i)); err != nil {
+			t.Fatal("cannot insert ingredient", err)
+		}
+
+		_, err = database.Exec("insert into recipe_ingredients(recipe_id, ingredient_id, quantity, measurement)values(1,?,2,?);",
+			i+1, measurement)
+		if err != nil {
+			t.Fatal("cannot insert recipe ingredient", err)
+		}
+	}
+
+	if err := migrator.To(12); err != nil {
+		t.Fatal("unexpected error on to 12", err)
+	}
+
+	rows, err := database.Query("select measurement from recipe_ingredients order by ingredient_id;")
+	if err != nil {
+		t.Fatal("cannot query measurements", err)
+	}
+
+	var measurements []string
+	for rows.Next() {
+		var measurement string
+		rows.Scan(&measurement)
+		measurements = append(measurements, measurement)
+
+		if unit, ok := units.Lookup(measurement); !ok || unit.Symbol != measurement {
+			t.Errorf("Got measurement %q which is not a unit symbol", measurement)
+		}
+	}
+	rows.Close()
+
+	expected := []string{"tbsp", "cup", "g", "fl oz", "pcs", "pcs", "pcs", "pcs"}
+	if !reflect.DeepEqual(measurements, expected) {
+		t.Errorf("Got measurements = %q but wanted %q", measurements, expected)
+	}
+}
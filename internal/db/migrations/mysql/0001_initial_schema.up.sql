@@ -0,0 +1,49 @@
+-- Tables are created only if missing so databases created before migrations existed are adopted as version 1
+CREATE TABLE IF NOT EXISTS users (
+    id int NOT NULL AUTO_INCREMENT,
+    name varchar(100) NOT NULL,
+    email varchar(100) NOT NULL,
+    password varchar(100) NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS recipes (
+    id int NOT NULL AUTO_INCREMENT,
+    title varchar(100) NOT NULL,
+    directions text NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS ingredients (
+    id int NOT NULL AUTO_INCREMENT,
+    name varchar(100) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+    recipe_id int NOT NULL,
+    ingredient_id int NOT NULL,
+    quantity int NOT NULL,
+    measurement varchar(10),
+    PRIMARY KEY (recipe_id, ingredient_id),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    FOREIGN KEY (ingredient_id)
+        REFERENCES ingredients(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id int NOT NULL AUTO_INCREMENT,
+    recipe_id int NOT NULL,
+    comment text NOT NULL,
+    PRIMARY KEY (id),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
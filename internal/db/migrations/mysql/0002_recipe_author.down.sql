@@ -0,0 +1,3 @@
+ALTER TABLE recipes
+    DROP INDEX recipes_author_id,
+    DROP COLUMN author_id;
@@ -0,0 +1,3 @@
+ALTER TABLE recipes
+    ADD COLUMN author_id int NOT NULL DEFAULT 0 AFTER id,
+    ADD INDEX recipes_author_id (author_id);
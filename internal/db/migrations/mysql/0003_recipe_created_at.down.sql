@@ -0,0 +1,3 @@
+ALTER TABLE recipes
+    DROP INDEX recipes_created_at,
+    DROP COLUMN created_at;
@@ -0,0 +1,3 @@
+ALTER TABLE recipes
+    ADD COLUMN created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD INDEX recipes_created_at (created_at);
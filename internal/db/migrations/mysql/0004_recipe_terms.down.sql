@@ -0,0 +1 @@
+DROP TABLE recipe_terms;
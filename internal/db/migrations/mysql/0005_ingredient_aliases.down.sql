@@ -0,0 +1 @@
+DROP TABLE ingredient_aliases;
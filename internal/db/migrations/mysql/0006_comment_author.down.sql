@@ -0,0 +1,5 @@
+ALTER TABLE comments
+    DROP INDEX comments_author_id,
+    DROP COLUMN edited_at,
+    DROP COLUMN created_at,
+    DROP COLUMN author_id;
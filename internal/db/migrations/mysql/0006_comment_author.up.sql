@@ -0,0 +1,5 @@
+ALTER TABLE comments
+    ADD COLUMN author_id int NOT NULL DEFAULT 0 AFTER recipe_id,
+    ADD COLUMN created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN edited_at timestamp NULL DEFAULT NULL,
+    ADD INDEX comments_author_id (author_id);
@@ -0,0 +1,18 @@
+-- Sessions started by login, a revoked session is on the revocation list checked for every access token
+CREATE TABLE sessions (
+    id varchar(64) NOT NULL,
+    user_id int NOT NULL,
+    refresh_token_hash char(64) NOT NULL,
+    previous_token_hash char(64) NULL,
+    created_at datetime NOT NULL,
+    expires_at datetime NOT NULL,
+    revoked_at datetime NULL,
+    PRIMARY KEY (id),
+    UNIQUE (refresh_token_hash),
+    INDEX sessions_previous_token_hash (previous_token_hash),
+    INDEX sessions_user_id (user_id),
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
@@ -0,0 +1,5 @@
+-- fractional quantities are rounded, to at least 1 so none of them becomes 0
+UPDATE recipe_ingredients SET quantity = GREATEST(ROUND(quantity), 1);
+ALTER TABLE recipe_ingredients
+    MODIFY quantity int NOT NULL,
+    MODIFY measurement varchar(10);
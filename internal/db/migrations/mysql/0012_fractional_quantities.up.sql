@@ -0,0 +1,24 @@
+-- Quantities can be fractional, like 0.25 tsp, and measurements hold unit symbols like "fl oz"
+ALTER TABLE recipe_ingredients
+    MODIFY quantity decimal(12,4) NOT NULL,
+    MODIFY measurement varchar(16);
+-- Legacy free-text measurements are mapped to the symbols of the units they name, the way units.Lookup reads them,
+-- empty and unknown ones like "to taste" or "large" become pcs so every ingredient keeps a valid unit
+UPDATE recipe_ingredients SET measurement = CASE lower(trim(measurement))
+    WHEN 'ml' THEN 'ml' WHEN 'milliliter' THEN 'ml' WHEN 'milliliters' THEN 'ml' WHEN 'millilitre' THEN 'ml' WHEN 'millilitres' THEN 'ml'
+    WHEN 'l' THEN 'l' WHEN 'liter' THEN 'l' WHEN 'liters' THEN 'l' WHEN 'litre' THEN 'l' WHEN 'litres' THEN 'l'
+    WHEN 'tsp' THEN 'tsp' WHEN 'teaspoon' THEN 'tsp' WHEN 'teaspoons' THEN 'tsp'
+    WHEN 'tbsp' THEN 'tbsp' WHEN 'tbs' THEN 'tbsp' WHEN 'tablespoon' THEN 'tbsp' WHEN 'tablespoons' THEN 'tbsp'
+    WHEN 'fl oz' THEN 'fl oz' WHEN 'floz' THEN 'fl oz' WHEN 'fluid ounce' THEN 'fl oz' WHEN 'fluid ounces' THEN 'fl oz'
+    WHEN 'cup' THEN 'cup' WHEN 'cups' THEN 'cup'
+    WHEN 'pt' THEN 'pt' WHEN 'pint' THEN 'pt' WHEN 'pints' THEN 'pt'
+    WHEN 'qt' THEN 'qt' WHEN 'quart' THEN 'qt' WHEN 'quarts' THEN 'qt'
+    WHEN 'gal' THEN 'gal' WHEN 'gallon' THEN 'gal' WHEN 'gallons' THEN 'gal'
+    WHEN 'mg' THEN 'mg' WHEN 'milligram' THEN 'mg' WHEN 'milligrams' THEN 'mg'
+    WHEN 'g' THEN 'g' WHEN 'gr' THEN 'g' WHEN 'gram' THEN 'g' WHEN 'grams' THEN 'g'
+    WHEN 'kg' THEN 'kg' WHEN 'kilo' THEN 'kg' WHEN 'kilos' THEN 'kg' WHEN 'kilogram' THEN 'kg' WHEN 'kilograms' THEN 'kg'
+    WHEN 'oz' THEN 'oz' WHEN 'ounce' THEN 'oz' WHEN 'ounces' THEN 'oz'
+    WHEN 'lb' THEN 'lb' WHEN 'lbs' THEN 'lb' WHEN 'pound' THEN 'lb' WHEN 'pounds' THEN 'lb'
+    WHEN 'pinch' THEN 'pinch' WHEN 'pinches' THEN 'pinch'
+    ELSE 'pcs'
+END;
@@ -0,0 +1,8 @@
+ALTER TABLE recipes ADD COLUMN directions text NULL;
+SET SESSION group_concat_max_len = 1048576;
+UPDATE recipes SET directions = (SELECT GROUP_CONCAT(instruction ORDER BY position SEPARATOR '\n')
+    FROM recipe_steps WHERE recipe_steps.recipe_id = recipes.id);
+UPDATE recipes SET directions = '' WHERE directions IS NULL;
+ALTER TABLE recipes MODIFY directions text NOT NULL;
+DROP TABLE recipe_step_ingredients;
+DROP TABLE recipe_steps;
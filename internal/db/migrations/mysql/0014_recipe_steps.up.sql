@@ -0,0 +1,47 @@
+-- Directions are ordered steps with an optional duration, temperature and the ingredients they use
+CREATE TABLE recipe_steps (
+    id int NOT NULL AUTO_INCREMENT,
+    recipe_id int NOT NULL,
+    position int NOT NULL,
+    instruction text NOT NULL,
+    kind varchar(8) NOT NULL,
+    duration_minutes int NOT NULL DEFAULT 0,
+    temperature decimal(12,4) NULL,
+    temperature_unit varchar(16) NULL,
+    PRIMARY KEY (id),
+    UNIQUE (recipe_id, position),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE TABLE recipe_step_ingredients (
+    step_id int NOT NULL,
+    ingredient_id int NOT NULL,
+    PRIMARY KEY (step_id, ingredient_id),
+    FOREIGN KEY (step_id)
+        REFERENCES recipe_steps(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    FOREIGN KEY (ingredient_id)
+        REFERENCES ingredients(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+-- the free text directions are split into a step for every line which is not blank,
+-- every line is a level of the recursion
+SET SESSION cte_max_recursion_depth = 100000;
+INSERT INTO recipe_steps (recipe_id, position, instruction, kind)
+WITH RECURSIVE direction_lines (recipe_id, line_number, line, rest) AS (
+    SELECT id, 0, CAST('' AS CHAR(20000)), CONCAT(REPLACE(directions, CHAR(13), ''), CHAR(10)) FROM recipes
+    UNION ALL
+    SELECT recipe_id, line_number + 1, TRIM(SUBSTRING_INDEX(rest, CHAR(10), 1)),
+        SUBSTRING(rest, LOCATE(CHAR(10), rest) + 1)
+    FROM direction_lines WHERE rest <> ''
+)
+SELECT recipe_id, ROW_NUMBER() OVER (PARTITION BY recipe_id ORDER BY line_number), line, 'prep'
+FROM direction_lines WHERE line <> '';
+
+ALTER TABLE recipes DROP COLUMN directions;
@@ -0,0 +1,23 @@
+-- Free tags have the facet "tag", the curated facets are cuisine, course, meal_type and difficulty
+CREATE TABLE tags (
+    id int NOT NULL AUTO_INCREMENT,
+    facet varchar(16) NOT NULL,
+    name varchar(30) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (facet, name)
+);
+
+CREATE TABLE recipe_tags (
+    recipe_id int NOT NULL,
+    tag_id int NOT NULL,
+    PRIMARY KEY (recipe_id, tag_id),
+    INDEX recipe_tags_tag_id (tag_id),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    FOREIGN KEY (tag_id)
+        REFERENCES tags(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
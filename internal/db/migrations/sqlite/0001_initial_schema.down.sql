@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS recipe_ingredients;
+DROP TABLE IF EXISTS ingredients;
+DROP TABLE IF EXISTS recipes;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,2 @@
+DROP INDEX recipes_author_id;
+ALTER TABLE recipes DROP COLUMN author_id;
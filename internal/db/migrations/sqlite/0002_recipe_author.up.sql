@@ -0,0 +1,2 @@
+ALTER TABLE recipes ADD COLUMN author_id int NOT NULL DEFAULT 0;
+CREATE INDEX recipes_author_id ON recipes(author_id);
@@ -0,0 +1,2 @@
+DROP INDEX recipes_created_at;
+ALTER TABLE recipes DROP COLUMN created_at;
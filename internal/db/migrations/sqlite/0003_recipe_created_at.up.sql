@@ -0,0 +1,4 @@
+-- sqlite cannot add a column with a non-constant default, existing recipes are stamped with the migration time
+ALTER TABLE recipes ADD COLUMN created_at timestamp NOT NULL DEFAULT '1970-01-01 00:00:00';
+UPDATE recipes SET created_at = CURRENT_TIMESTAMP;
+CREATE INDEX recipes_created_at ON recipes(created_at);
@@ -0,0 +1,13 @@
+-- Inverted index for the full text recipe search, existing recipes are indexed with cookit reindex
+CREATE TABLE recipe_terms (
+    recipe_id int NOT NULL,
+    field varchar(16) NOT NULL,
+    position int NOT NULL,
+    term varchar(100) NOT NULL,
+    PRIMARY KEY (recipe_id, field, position),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX recipe_terms_term ON recipe_terms(term);
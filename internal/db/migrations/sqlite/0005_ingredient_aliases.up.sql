@@ -0,0 +1,20 @@
+-- Alternative ingredient names mapped to the canonical names ingredients are stored by,
+-- both are normalized: lower case, single spaces and a singular last word
+CREATE TABLE ingredient_aliases (
+    alias varchar(100) NOT NULL PRIMARY KEY,
+    name varchar(100) NOT NULL
+);
+
+INSERT INTO ingredient_aliases (alias, name) VALUES
+    ('cilantro', 'coriander leaf'),
+    ('fresh coriander', 'coriander leaf'),
+    ('scallion', 'green onion'),
+    ('spring onion', 'green onion'),
+    ('aubergine', 'eggplant'),
+    ('courgette', 'zucchini'),
+    ('garbanzo bean', 'chickpea'),
+    ('capsicum', 'bell pepper'),
+    ('icing sugar', 'powdered sugar'),
+    ('confectioners sugar', 'powdered sugar'),
+    ('rocket', 'arugula'),
+    ('prawn', 'shrimp');
@@ -0,0 +1,4 @@
+DROP INDEX comments_author_id;
+ALTER TABLE comments DROP COLUMN edited_at;
+ALTER TABLE comments DROP COLUMN created_at;
+ALTER TABLE comments DROP COLUMN author_id;
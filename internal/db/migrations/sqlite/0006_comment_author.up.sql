@@ -0,0 +1,6 @@
+ALTER TABLE comments ADD COLUMN author_id int NOT NULL DEFAULT 0;
+-- sqlite cannot add a column with a non-constant default, existing comments are stamped with the migration time
+ALTER TABLE comments ADD COLUMN created_at timestamp NOT NULL DEFAULT '1970-01-01 00:00:00';
+UPDATE comments SET created_at = CURRENT_TIMESTAMP;
+ALTER TABLE comments ADD COLUMN edited_at timestamp NULL;
+CREATE INDEX comments_author_id ON comments(author_id);
@@ -0,0 +1,12 @@
+CREATE TABLE recipe_ratings (
+    recipe_id int NOT NULL,
+    user_id int NOT NULL,
+    rating int NOT NULL,
+    rated_at timestamp NOT NULL,
+    PRIMARY KEY (recipe_id, user_id),
+    CHECK (rating BETWEEN 1 AND 5),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
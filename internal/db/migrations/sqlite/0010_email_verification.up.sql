@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN email_verified_at datetime NULL;
+-- accounts created before emails were verified are trusted
+UPDATE users SET email_verified_at = CURRENT_TIMESTAMP;
+
+-- Single use tokens sent by email for verifying the address or resetting the password
+CREATE TABLE user_tokens (
+    id varchar(64) NOT NULL,
+    user_id int NOT NULL,
+    purpose varchar(32) NOT NULL,
+    expires_at datetime NOT NULL,
+    used_at datetime NULL,
+    PRIMARY KEY (id),
+    FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX user_tokens_user_id_purpose ON user_tokens(user_id, purpose);
@@ -0,0 +1,8 @@
+-- Failed logins counted by account (email:<address>) or by client address (ip:<address>)
+CREATE TABLE login_attempts (
+    attempt_key varchar(320) NOT NULL,
+    failures int NOT NULL,
+    last_failure_at datetime NOT NULL,
+    locked_until datetime NOT NULL,
+    PRIMARY KEY (attempt_key)
+);
@@ -0,0 +1,20 @@
+-- fractional quantities are rounded, to at least 1 so none of them becomes 0
+CREATE TABLE recipe_ingredients_old (
+    recipe_id int NOT NULL,
+    ingredient_id int NOT NULL,
+    quantity int NOT NULL,
+    measurement varchar(10),
+    PRIMARY KEY (recipe_id, ingredient_id),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    FOREIGN KEY (ingredient_id)
+        REFERENCES ingredients(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+INSERT INTO recipe_ingredients_old (recipe_id, ingredient_id, quantity, measurement)
+    SELECT recipe_id, ingredient_id, max(CAST(round(quantity) AS int), 1), measurement FROM recipe_ingredients;
+DROP TABLE recipe_ingredients;
+ALTER TABLE recipe_ingredients_old RENAME TO recipe_ingredients;
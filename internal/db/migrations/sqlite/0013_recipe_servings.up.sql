@@ -0,0 +1,2 @@
+-- The number of servings the quantities of the ingredients make, 0 when it is not known
+ALTER TABLE recipes ADD COLUMN servings int NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE recipes ADD COLUMN directions text NOT NULL DEFAULT '';
+UPDATE recipes SET directions = coalesce((SELECT group_concat(instruction, char(10)) FROM
+    (SELECT instruction FROM recipe_steps WHERE recipe_steps.recipe_id = recipes.id ORDER BY position)), '');
+DROP TABLE recipe_step_ingredients;
+DROP TABLE recipe_steps;
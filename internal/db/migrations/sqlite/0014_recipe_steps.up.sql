@@ -0,0 +1,44 @@
+-- Directions are ordered steps with an optional duration, temperature and the ingredients they use
+CREATE TABLE recipe_steps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recipe_id int NOT NULL,
+    position int NOT NULL,
+    instruction text NOT NULL,
+    kind varchar(8) NOT NULL,
+    duration_minutes int NOT NULL DEFAULT 0,
+    temperature decimal(12,4) NULL,
+    temperature_unit varchar(16) NULL,
+    UNIQUE (recipe_id, position),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE TABLE recipe_step_ingredients (
+    step_id int NOT NULL,
+    ingredient_id int NOT NULL,
+    PRIMARY KEY (step_id, ingredient_id),
+    FOREIGN KEY (step_id)
+        REFERENCES recipe_steps(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    FOREIGN KEY (ingredient_id)
+        REFERENCES ingredients(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+-- the free text directions are split into a step for every line which is not blank
+INSERT INTO recipe_steps (recipe_id, position, instruction, kind)
+WITH RECURSIVE direction_lines (recipe_id, line_number, line, rest) AS (
+    SELECT id, 0, '', replace(directions, char(13), '') || char(10) FROM recipes
+    UNION ALL
+    SELECT recipe_id, line_number + 1, trim(substr(rest, 1, instr(rest, char(10)) - 1)),
+        substr(rest, instr(rest, char(10)) + 1)
+    FROM direction_lines WHERE rest <> ''
+)
+SELECT recipe_id, row_number() OVER (PARTITION BY recipe_id ORDER BY line_number), line, 'prep'
+FROM direction_lines WHERE line <> '';
+
+ALTER TABLE recipes DROP COLUMN directions;
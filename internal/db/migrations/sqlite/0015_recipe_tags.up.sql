@@ -0,0 +1,22 @@
+-- Free tags have the facet "tag", the curated facets are cuisine, course, meal_type and difficulty
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    facet varchar(16) NOT NULL,
+    name varchar(30) NOT NULL,
+    UNIQUE (facet, name)
+);
+
+CREATE TABLE recipe_tags (
+    recipe_id int NOT NULL,
+    tag_id int NOT NULL,
+    PRIMARY KEY (recipe_id, tag_id),
+    FOREIGN KEY (recipe_id)
+        REFERENCES recipes(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    FOREIGN KEY (tag_id)
+        REFERENCES tags(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX recipe_tags_tag_id ON recipe_tags(tag_id);
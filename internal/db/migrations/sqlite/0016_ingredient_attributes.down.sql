@@ -0,0 +1,2 @@
+DROP TABLE ingredient_allergens;
+DROP TABLE ingredient_attributes;
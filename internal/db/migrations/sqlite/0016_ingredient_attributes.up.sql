@@ -0,0 +1,192 @@
+-- Diets ingredients are suitable for and allergens they contain, by the canonical name of the ingredient,
+-- so ingredients can be classified before any recipe uses them
+CREATE TABLE ingredient_attributes (
+    name varchar(100) NOT NULL PRIMARY KEY,
+    vegan boolean NOT NULL DEFAULT 0,
+    vegetarian boolean NOT NULL DEFAULT 0,
+    gluten_free boolean NOT NULL DEFAULT 0
+);
+
+CREATE TABLE ingredient_allergens (
+    name varchar(100) NOT NULL,
+    allergen varchar(16) NOT NULL,
+    PRIMARY KEY (name, allergen),
+    FOREIGN KEY (name)
+        REFERENCES ingredient_attributes(name)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX ingredient_allergens_allergen ON ingredient_allergens(allergen);
+
+-- The bundled classification of common ingredients, admins classify further ingredients and correct these
+INSERT INTO ingredient_attributes (name, vegan, vegetarian, gluten_free) VALUES
+    ('water', 1, 1, 1),
+    ('salt', 1, 1, 1),
+    ('black pepper', 1, 1, 1),
+    ('sugar', 1, 1, 1),
+    ('brown sugar', 1, 1, 1),
+    ('powdered sugar', 1, 1, 1),
+    ('honey', 0, 1, 1),
+    ('maple syrup', 1, 1, 1),
+    ('flour', 1, 1, 0),
+    ('wheat flour', 1, 1, 0),
+    ('corn flour', 1, 1, 1),
+    ('cornstarch', 1, 1, 1),
+    ('bread', 1, 1, 0),
+    ('breadcrumb', 1, 1, 0),
+    ('pasta', 1, 1, 0),
+    ('spaghetti', 1, 1, 0),
+    ('noodle', 1, 1, 0),
+    ('couscous', 1, 1, 0),
+    ('bulgur', 1, 1, 0),
+    ('tortilla', 1, 1, 0),
+    ('oat', 1, 1, 0),
+    ('rice', 1, 1, 1),
+    ('rice noodle', 1, 1, 1),
+    ('quinoa', 1, 1, 1),
+    ('baking powder', 1, 1, 1),
+    ('baking soda', 1, 1, 1),
+    ('yeast', 1, 1, 1),
+    ('butter', 0, 1, 1),
+    ('milk', 0, 1, 1),
+    ('cream', 0, 1, 1),
+    ('heavy cream', 0, 1, 1),
+    ('sour cream', 0, 1, 1),
+    ('yogurt', 0, 1, 1),
+    ('cheese', 0, 1, 1),
+    ('mozzarella', 0, 1, 1),
+    ('feta', 0, 1, 1),
+    ('parmesan', 0, 0, 1),
+    ('chocolate', 0, 1, 1),
+    ('egg', 0, 1, 1),
+    ('mayonnaise', 0, 1, 1),
+    ('olive oil', 1, 1, 1),
+    ('vegetable oil', 1, 1, 1),
+    ('sunflower oil', 1, 1, 1),
+    ('coconut milk', 1, 1, 1),
+    ('vinegar', 1, 1, 1),
+    ('soy sauce', 1, 1, 0),
+    ('mustard', 1, 1, 1),
+    ('ketchup', 1, 1, 1),
+    ('fish sauce', 0, 0, 1),
+    ('worcestershire sauce', 0, 0, 1),
+    ('tomato', 1, 1, 1),
+    ('onion', 1, 1, 1),
+    ('green onion', 1, 1, 1),
+    ('garlic', 1, 1, 1),
+    ('potato', 1, 1, 1),
+    ('carrot', 1, 1, 1),
+    ('celery', 1, 1, 1),
+    ('vegetable stock', 1, 1, 1),
+    ('bell pepper', 1, 1, 1),
+    ('zucchini', 1, 1, 1),
+    ('eggplant', 1, 1, 1),
+    ('mushroom', 1, 1, 1),
+    ('spinach', 1, 1, 1),
+    ('lettuce', 1, 1, 1),
+    ('arugula', 1, 1, 1),
+    ('cucumber', 1, 1, 1),
+    ('avocado', 1, 1, 1),
+    ('corn', 1, 1, 1),
+    ('pea', 1, 1, 1),
+    ('olive', 1, 1, 1),
+    ('caper', 1, 1, 1),
+    ('lemon', 1, 1, 1),
+    ('lemon juice', 1, 1, 1),
+    ('lime', 1, 1, 1),
+    ('apple', 1, 1, 1),
+    ('banana', 1, 1, 1),
+    ('strawberry', 1, 1, 1),
+    ('raisin', 1, 1, 1),
+    ('chickpea', 1, 1, 1),
+    ('lentil', 1, 1, 1),
+    ('bean', 1, 1, 1),
+    ('tofu', 1, 1, 1),
+    ('peanut', 1, 1, 1),
+    ('peanut butter', 1, 1, 1),
+    ('almond', 1, 1, 1),
+    ('walnut', 1, 1, 1),
+    ('hazelnut', 1, 1, 1),
+    ('cashew', 1, 1, 1),
+    ('pistachio', 1, 1, 1),
+    ('pine nut', 1, 1, 1),
+    ('sesame seed', 1, 1, 1),
+    ('tahini', 1, 1, 1),
+    ('dark chocolate', 1, 1, 1),
+    ('cocoa powder', 1, 1, 1),
+    ('vanilla', 1, 1, 1),
+    ('cinnamon', 1, 1, 1),
+    ('paprika', 1, 1, 1),
+    ('cumin', 1, 1, 1),
+    ('oregano', 1, 1, 1),
+    ('thyme', 1, 1, 1),
+    ('rosemary', 1, 1, 1),
+    ('bay leaf', 1, 1, 1),
+    ('basil', 1, 1, 1),
+    ('parsley', 1, 1, 1),
+    ('coriander leaf', 1, 1, 1),
+    ('chili', 1, 1, 1),
+    ('ginger', 1, 1, 1),
+    ('chicken', 0, 0, 1),
+    ('chicken stock', 0, 0, 1),
+    ('beef', 0, 0, 1),
+    ('pork', 0, 0, 1),
+    ('bacon', 0, 0, 1),
+    ('ham', 0, 0, 1),
+    ('lamb', 0, 0, 1),
+    ('minced meat', 0, 0, 1),
+    ('gelatin', 0, 0, 1),
+    ('salmon', 0, 0, 1),
+    ('tuna', 0, 0, 1),
+    ('shrimp', 0, 0, 1),
+    ('mussel', 0, 0, 1),
+    ('squid', 0, 0, 1);
+
+INSERT INTO ingredient_allergens (name, allergen) VALUES
+    ('flour', 'gluten'),
+    ('wheat flour', 'gluten'),
+    ('bread', 'gluten'),
+    ('breadcrumb', 'gluten'),
+    ('pasta', 'gluten'),
+    ('spaghetti', 'gluten'),
+    ('noodle', 'gluten'),
+    ('couscous', 'gluten'),
+    ('bulgur', 'gluten'),
+    ('tortilla', 'gluten'),
+    ('oat', 'gluten'),
+    ('butter', 'dairy'),
+    ('milk', 'dairy'),
+    ('cream', 'dairy'),
+    ('heavy cream', 'dairy'),
+    ('sour cream', 'dairy'),
+    ('yogurt', 'dairy'),
+    ('cheese', 'dairy'),
+    ('mozzarella', 'dairy'),
+    ('feta', 'dairy'),
+    ('parmesan', 'dairy'),
+    ('chocolate', 'dairy'),
+    ('egg', 'egg'),
+    ('mayonnaise', 'egg'),
+    ('soy sauce', 'gluten'),
+    ('soy sauce', 'soy'),
+    ('mustard', 'mustard'),
+    ('fish sauce', 'fish'),
+    ('worcestershire sauce', 'fish'),
+    ('celery', 'celery'),
+    ('vegetable stock', 'celery'),
+    ('tofu', 'soy'),
+    ('peanut', 'peanut'),
+    ('peanut butter', 'peanut'),
+    ('almond', 'tree-nut'),
+    ('walnut', 'tree-nut'),
+    ('hazelnut', 'tree-nut'),
+    ('cashew', 'tree-nut'),
+    ('pistachio', 'tree-nut'),
+    ('pine nut', 'tree-nut'),
+    ('sesame seed', 'sesame'),
+    ('tahini', 'sesame'),
+    ('salmon', 'fish'),
+    ('tuna', 'fish'),
+    ('shrimp', 'crustacean'),
+    ('mussel', 'mollusc'),
+    ('squid', 'mollusc');
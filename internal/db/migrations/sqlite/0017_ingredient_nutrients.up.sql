@@ -0,0 +1,62 @@
+-- Nutrients in 100 grams of an ingredient by its canonical name and the grams a milliliter and a piece of it weigh,
+-- loaded from a csv like a USDA export with the nutrients command
+CREATE TABLE ingredient_nutrients (
+    name varchar(100) NOT NULL PRIMARY KEY,
+    calories decimal(12,4) NOT NULL,
+    protein decimal(12,4) NOT NULL,
+    fat decimal(12,4) NOT NULL,
+    carbs decimal(12,4) NOT NULL,
+    fibre decimal(12,4) NOT NULL,
+    grams_per_ml decimal(12,4) NULL,
+    grams_per_piece decimal(12,4) NULL
+);
+
+-- The bundled nutrients of common ingredients, a loaded csv adds further ingredients and replaces these
+INSERT INTO ingredient_nutrients (name, calories, protein, fat, carbs, fibre, grams_per_ml, grams_per_piece) VALUES
+    ('water', 0, 0, 0, 0, 0, 1, NULL),
+    ('salt', 0, 0, 0, 0, 0, 1.2, NULL),
+    ('sugar', 387, 0, 0, 100, 0, 0.85, NULL),
+    ('brown sugar', 380, 0.1, 0, 98.1, 0, 0.93, NULL),
+    ('honey', 304, 0.3, 0, 82.4, 0.2, 1.42, NULL),
+    ('flour', 364, 10.3, 1, 76.3, 2.7, 0.53, NULL),
+    ('rice', 365, 7.1, 0.7, 80, 1.3, 0.85, NULL),
+    ('pasta', 371, 13, 1.5, 75, 3.2, NULL, NULL),
+    ('oat', 389, 16.9, 6.9, 66.3, 10.6, 0.41, NULL),
+    ('bread', 265, 9, 3.2, 49, 2.7, NULL, 30),
+    ('butter', 717, 0.9, 81.1, 0.1, 0, 0.96, NULL),
+    ('milk', 61, 3.2, 3.3, 4.8, 0, 1.03, NULL),
+    ('cream', 340, 2.8, 36, 2.7, 0, 1, NULL),
+    ('yogurt', 61, 3.5, 3.3, 4.7, 0, 1.03, NULL),
+    ('cheese', 403, 24.9, 33.1, 1.3, 0, NULL, NULL),
+    ('parmesan', 431, 38, 29, 4.1, 0, NULL, NULL),
+    ('egg', 143, 12.6, 9.5, 0.7, 0, NULL, 50),
+    ('olive oil', 884, 0, 100, 0, 0, 0.91, NULL),
+    ('vegetable oil', 884, 0, 100, 0, 0, 0.92, NULL),
+    ('coconut milk', 230, 2.3, 23.8, 5.5, 2.2, 0.97, NULL),
+    ('tomato', 18, 0.9, 0.2, 3.9, 1.2, NULL, 123),
+    ('onion', 40, 1.1, 0.1, 9.3, 1.7, NULL, 110),
+    ('garlic', 149, 6.4, 0.5, 33.1, 2.1, NULL, 3),
+    ('potato', 77, 2, 0.1, 17.5, 2.2, NULL, 213),
+    ('carrot', 41, 0.9, 0.2, 9.6, 2.8, NULL, 61),
+    ('bell pepper', 31, 1, 0.3, 6, 2.1, NULL, 119),
+    ('zucchini', 17, 1.2, 0.3, 3.1, 1, NULL, 196),
+    ('mushroom', 22, 3.1, 0.3, 3.3, 1, NULL, 18),
+    ('spinach', 23, 2.9, 0.4, 3.6, 2.2, NULL, NULL),
+    ('lemon', 29, 1.1, 0.3, 9.3, 2.8, NULL, 58),
+    ('lemon juice', 22, 0.4, 0.2, 6.9, 0.3, 1.03, NULL),
+    ('apple', 52, 0.3, 0.2, 13.8, 2.4, NULL, 182),
+    ('banana', 89, 1.1, 0.3, 22.8, 2.6, NULL, 118),
+    ('chickpea', 164, 8.9, 2.6, 27.4, 7.6, NULL, NULL),
+    ('lentil', 116, 9, 0.4, 20.1, 7.9, NULL, NULL),
+    ('tofu', 76, 8.1, 4.8, 1.9, 0.3, NULL, NULL),
+    ('peanut', 567, 25.8, 49.2, 16.1, 8.5, NULL, NULL),
+    ('almond', 579, 21.2, 49.9, 21.6, 12.5, NULL, NULL),
+    ('walnut', 654, 15.2, 65.2, 13.7, 6.7, NULL, NULL),
+    ('chicken', 120, 22.5, 2.6, 0, 0, NULL, NULL),
+    ('beef', 250, 26, 15, 0, 0, NULL, NULL),
+    ('pork', 242, 27.3, 13.9, 0, 0, NULL, NULL),
+    ('salmon', 208, 20.4, 13.4, 0, 0, NULL, NULL),
+    ('shrimp', 85, 20.1, 0.5, 0, 0, NULL, NULL),
+    ('basil', 23, 3.2, 0.6, 2.7, 1.6, NULL, NULL),
+    ('dark chocolate', 598, 7.8, 42.6, 45.9, 10.9, NULL, NULL),
+    ('cocoa powder', 228, 19.6, 13.7, 57.9, 37, 0.42, NULL);
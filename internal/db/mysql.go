@@ -0,0 +1,82 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"strings"
+	"time"
+)
+
+// mysqlForeignKeyViolation is the MySQL error number for a child row referencing a missing parent row
+const mysqlForeignKeyViolation = 1452
+
+type mysqlDialect struct{}
+
+// OpenMySQL function connects to the MySQL server and creates the database if it does not exist
+// Returns an error if such occurs during connecting
+func OpenMySQL(username string, password string, databaseName string, databaseHost string) (*sql.DB, Dialect, error) {
+	dbURI := fmt.Sprintf("%s:%s@(%s)/", username, password, databaseHost)
+	db, err := createAndOpen(databaseName, dbURI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db.SetMaxIdleConns(4)
+	db.SetMaxOpenConns(4)
+	db.SetConnMaxLifetime(time.Second * 15)
+
+	return db, mysqlDialect{}, nil
+}
+
+func (mysqlDialect) Name() string {
+	return MySQL
+}
+
+func (mysqlDialect) Engine() string {
+	return MySQL
+}
+
+// ResolveID relies on LAST_INSERT_ID(id) making the driver report the id of the existing row
+// on a duplicate value, so concurrent inserts of the same new value resolve to the same id
+func (mysqlDialect) ResolveID(tx *sql.Tx, table string, columns []string, values ...interface{}) (int64, error) {
+	result, err := tx.Exec(insertStatement(table, columns)+" on duplicate key update id = LAST_INSERT_ID(id);", values...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (mysqlDialect) Upsert(table string, keys []string, values []string) string {
+	updates := make([]string, len(values))
+	for i, column := range values {
+		updates[i] = column + " = values(" + column + ")"
+	}
+
+	return insertStatement(table, append(append([]string{}, keys...), values...)) +
+		" on duplicate key update " + strings.Join(updates, ", ") + ";"
+}
+
+func (mysqlDialect) IsForeignKeyViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlForeignKeyViolation
+}
+
+func createAndOpen(name string, dbURI string) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dbURI)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec("CREATE DATABASE IF NOT EXISTS " + name)
+	db.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// migrations are executed as a single multi statement script, timestamp columns are scanned into time.Time
+	// and updates report the matched rows like sqlite does, also when the values did not change
+	return sql.Open("mysql", dbURI+name+"?multiStatements=true&parseTime=true&clientFoundRows=true")
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/mattn/go-sqlite3"
+	"strings"
+)
+
+type sqliteDialect struct {
+	name string
+}
+
+// OpenSQLite function opens the sqlite database stored in the given file
+// Returns an error if such occurs during opening
+func OpenSQLite(path string) (*sql.DB, Dialect, error) {
+	return openSQLite("file:"+path+"?_foreign_keys=on", SQLite)
+}
+
+// OpenMemory function opens a new in-memory sqlite database and applies all migrations to it,
+// since no other process can reach the database to migrate it
+// The data lives as long as the returned db is open
+// Returns an error if such occurs during opening or migration
+func OpenMemory() (*sql.DB, Dialect, error) {
+	db, dialect, err := openSQLite("file::memory:?_foreign_keys=on", Memory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrator, err := NewMigrator(db, dialect)
+	if err == nil {
+		err = migrator.Up()
+	}
+
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return db, dialect, nil
+}
+
+func openSQLite(dsn string, name string) (*sql.DB, Dialect, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// sqlite allows a single writer and every connection to :memory: is a separate database,
+	// so all queries go through one long-lived connection
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+
+	return db, sqliteDialect{name: name}, nil
+}
+
+func (dialect sqliteDialect) Name() string {
+	return dialect.name
+}
+
+func (sqliteDialect) Engine() string {
+	return SQLite
+}
+
+// ResolveID can select the id after the insert because sqlite serializes writing transactions
+func (sqliteDialect) ResolveID(tx *sql.Tx, table string, columns []string, values ...interface{}) (int64, error) {
+	_, err := tx.Exec(strings.Replace(insertStatement(table, columns), "insert", "insert or ignore", 1)+";", values...)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = tx.QueryRow("select id from "+table+" where "+strings.Join(columns, " = ? and ")+" = ?;", values...).Scan(&id)
+	return id, err
+}
+
+func (sqliteDialect) Upsert(table string, keys []string, values []string) string {
+	updates := make([]string, len(values))
+	for i, column := range values {
+		updates[i] = column + " = excluded." + column
+	}
+
+	return insertStatement(table, append(append([]string{}, keys...), values...)) +
+		" on conflict(" + strings.Join(keys, ", ") + ") do update set " + strings.Join(updates, ", ") + ";"
+}
+
+func (sqliteDialect) IsForeignKeyViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
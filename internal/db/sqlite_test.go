@@ -0,0 +1,28 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSQLiteDialect_IsForeignKeyViolation(t *testing.T) {
+	database, dialect, err := OpenMemory()
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	defer database.Close()
+
+	_, err = database.Exec("insert into comments(recipe_id, comment)values(?,?);", 42, "Tasty")
+
+	if !dialect.IsForeignKeyViolation(err) {
+		t.Errorf("expected a foreign key violation got %v", err)
+	}
+
+	if !dialect.IsForeignKeyViolation(fmt.Errorf("cannot add comment: %w", err)) {
+		t.Errorf("expected a wrapped foreign key violation to be detected")
+	}
+
+	if _, err = database.Exec("insert into recipes(title)values(null);"); dialect.IsForeignKeyViolation(err) || dialect.IsForeignKeyViolation(nil) {
+		t.Errorf("expected other errors not to be foreign key violations got %v", err)
+	}
+}
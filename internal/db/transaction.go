@@ -0,0 +1,26 @@
+package db
+
+import "database/sql"
+
+// WithTransaction runs the given unit of work inside a single transaction
+// The transaction is committed if the work succeeds and rolled back if it returns an error or panics
+func WithTransaction(db *sql.DB, work func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := work(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/krasimiraMilkova/cookit/pkg/mail"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer is used for local development and tests instead of delivering the emails,
+// it writes every email to a separate .eml file in Dir or to the log if Dir is empty
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (mailer *FileMailer) Send(message mail.Message) error {
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return errors.New("email headers cannot contain line breaks")
+	}
+
+	content := format(mailer.From, message)
+
+	if mailer.Dir == "" {
+		log.Printf("Email to %s\n%s", message.To, content)
+		return nil
+	}
+
+	recipient := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '.' {
+			return '_'
+		}
+		return r
+	}, message.To)
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), recipient)
+	return ioutil.WriteFile(filepath.Join(mailer.Dir, name), content, 0600)
+}
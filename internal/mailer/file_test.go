@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/mail"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+	if err != nil {
+		t.Fatal("cannot create directory", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mailer := &FileMailer{Dir: dir, From: "cookit@test.com"}
+
+	err = mailer.Send(mail.Message{To: "chef@test.com", Subject: "Welcome", Body: "first line\nsecond line"})
+	if err != nil {
+		t.Fatal("unexpected error on send", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*-chef@test_com.eml"))
+	if len(files) != 1 {
+		t.Fatalf("Got %v email files but wanted 1", len(files))
+	}
+
+	content, _ := ioutil.ReadFile(files[0])
+	for _, expected := range []string{"From: cookit@test.com\r\n", "To: chef@test.com\r\n", "Subject: Welcome\r\n", "\r\n\r\nfirst line\r\nsecond line"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Got email %q without %q", content, expected)
+		}
+	}
+
+	if err = mailer.Send(mail.Message{To: "chef@test.com\r\nBcc: other@test.com", Subject: "Welcome"}); err == nil {
+		t.Error("expected a header with line breaks to be rejected")
+	}
+}
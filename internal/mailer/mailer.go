@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"github.com/krasimiraMilkova/cookit/internal/appconfig"
+	"github.com/krasimiraMilkova/cookit/pkg/mail"
+	"log"
+	"strings"
+	"time"
+)
+
+// Mailer drivers selected with the MAILER setting
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+var mailer mail.Mailer
+
+// Get function returns the mailer of the configured driver
+func Get() mail.Mailer {
+	if mailer == nil {
+		config := appconfig.Get()
+		driver, dir, from := config.GetMailConfig()
+
+		switch driver {
+		case DriverSMTP:
+			host, port, username, password := config.GetSMTPConfig()
+			mailer = &SMTPMailer{Host: host, Port: port, Username: username, Password: password, From: from}
+		case DriverFile:
+			mailer = &FileMailer{Dir: dir, From: from}
+		default:
+			log.Fatalf("Unsupported mailer %q", driver)
+		}
+	}
+
+	return mailer
+}
+
+// format returns the message with the headers of a plain text email
+func format(from string, message mail.Message) []byte {
+	var builder strings.Builder
+
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + message.To + "\r\n")
+	builder.WriteString("Subject: " + message.Subject + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return []byte(builder.String())
+}
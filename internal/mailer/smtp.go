@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"errors"
+	"github.com/krasimiraMilkova/cookit/pkg/mail"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// SMTPMailer delivers the emails through an SMTP server, authenticating when a username is set
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (mailer *SMTPMailer) Send(message mail.Message) error {
+	// a line break in a header would let the value add headers or recipients of its own
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return errors.New("email headers cannot contain line breaks")
+	}
+
+	var auth smtp.Auth
+	if mailer.Username != "" {
+		auth = smtp.PlainAuth("", mailer.Username, mailer.Password, mailer.Host)
+	}
+
+	address := net.JoinHostPort(mailer.Host, strconv.Itoa(mailer.Port))
+	return smtp.SendMail(address, auth, mailer.From, []string{message.To}, format(mailer.From, message))
+}
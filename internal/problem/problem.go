@@ -0,0 +1,57 @@
+// Package problem writes the errors of the handlers as problem details (RFC 7807)
+package problem
+
+import (
+	"encoding/json"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"log"
+	"net/http"
+)
+
+// statuses are the response statuses of the kinds of typed errors
+var statuses = map[apperror.Kind]int{
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindValidation:   http.StatusBadRequest,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+}
+
+// Write function writes the error as problem details with the status of its kind and logs it
+// Errors which are not typed are written as Status InternalServerError without their message,
+// so the details of the storage are not sent to the client
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	typed, ok := apperror.As(err)
+	if !ok {
+		log.Printf("%s %s failed: %v", r.Method, r.URL.Path, err)
+		write(w, r, apperror.Problem{Status: http.StatusInternalServerError, Detail: "an unexpected error occurred"})
+		return
+	}
+
+	status, ok := statuses[typed.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	log.Printf("%s %s rejected: %v", r.Method, r.URL.Path, err)
+	write(w, r, apperror.Problem{Status: status, Detail: typed.Message, Errors: typed.Fields})
+}
+
+// Status function writes problem details with the given status and detail
+// for errors which do not come from a typed error
+func Status(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	log.Printf("%s %s rejected: %s", r.Method, r.URL.Path, detail)
+	write(w, r, apperror.Problem{Status: status, Detail: detail})
+}
+
+func write(w http.ResponseWriter, r *http.Request, problem apperror.Problem) {
+	// the errors are not documented by separate pages, so the status tells what the problem is
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	problem.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", apperror.ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,101 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	invalidRecipe := apperror.Validation("invalid recipe",
+		apperror.FieldError{Field: "title", Message: "must not be empty"},
+		apperror.FieldError{Field: "directions", Message: "must not be empty"})
+
+	tests := []struct {
+		name     string
+		err      error
+		expected apperror.Problem
+	}{
+		{
+			name: "Validation error with fields",
+			err:  invalidRecipe,
+			expected: apperror.Problem{Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest,
+				Detail: "invalid recipe", Instance: "/api/v1/recipe", Errors: invalidRecipe.Fields},
+		},
+		{
+			name: "Wrapped not found error",
+			err:  fmt.Errorf("fetching the recipe: %w", apperror.NotFound("recipe not found")),
+			expected: apperror.Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound,
+				Detail: "recipe not found", Instance: "/api/v1/recipe"},
+		},
+		{
+			name: "Conflict",
+			err:  apperror.Conflict("the email is used by another user"),
+			expected: apperror.Problem{Type: "about:blank", Title: "Conflict", Status: http.StatusConflict,
+				Detail: "the email is used by another user", Instance: "/api/v1/recipe"},
+		},
+		{
+			name: "Unauthorized",
+			err:  apperror.Unauthorized("invalid email or password"),
+			expected: apperror.Problem{Type: "about:blank", Title: "Unauthorized", Status: http.StatusUnauthorized,
+				Detail: "invalid email or password", Instance: "/api/v1/recipe"},
+		},
+		{
+			name: "Forbidden",
+			err:  apperror.Forbidden("the recipe belongs to another user"),
+			expected: apperror.Problem{Type: "about:blank", Title: "Forbidden", Status: http.StatusForbidden,
+				Detail: "the recipe belongs to another user", Instance: "/api/v1/recipe"},
+		},
+		{
+			name: "Error which is not typed",
+			err:  errors.New("Error 1045: Access denied for user 'root'"),
+			expected: apperror.Problem{Type: "about:blank", Title: "Internal Server Error",
+				Status: http.StatusInternalServerError, Detail: "an unexpected error occurred", Instance: "/api/v1/recipe"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			// the common middleware sets the json content type before the handlers run
+			rr.Header().Add("Content-Type", "application/json")
+
+			Write(rr, httptest.NewRequest("POST", "/api/v1/recipe", nil), test.err)
+
+			expectProblem(t, rr, test.expected)
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	Status(rr, httptest.NewRequest("POST", "/login", nil), http.StatusTooManyRequests, "too many failed logins")
+
+	expectProblem(t, rr, apperror.Problem{Type: "about:blank", Title: "Too Many Requests",
+		Status: http.StatusTooManyRequests, Detail: "too many failed logins", Instance: "/login"})
+}
+
+func expectProblem(t *testing.T, rr *httptest.ResponseRecorder, expected apperror.Problem) {
+	if rr.Code != expected.Status {
+		t.Errorf("Got status %v but wanted %v", rr.Code, expected.Status)
+	}
+
+	if contentType := rr.Header().Values("Content-Type"); !reflect.DeepEqual(contentType, []string{apperror.ProblemContentType}) {
+		t.Errorf("Got content type %v but wanted %v", contentType, apperror.ProblemContentType)
+	}
+
+	var problem apperror.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatal("error from unmarshal", err)
+	}
+
+	if !reflect.DeepEqual(problem, expected) {
+		t.Errorf("Got problem = %+v but wanted %+v", problem, expected)
+	}
+}
@@ -2,18 +2,34 @@ package service
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/krasimiraMilkova/cookit/internal/problem"
+	"github.com/krasimiraMilkova/cookit/internal/request"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
 	"github.com/krasimiraMilkova/cookit/pkg/recipes"
-	"log"
+	"github.com/krasimiraMilkova/cookit/pkg/units"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 type RecipeService struct {
 	RecipeRepository recipes.RecipeRepository
 }
 
+// recipePatch holds the recipe fields sent in a partial update, nil fields are left unchanged
+type recipePatch struct {
+	Title       *string               `json:"title"`
+	Servings    *uint                 `json:"servings"`
+	Ingredients *[]recipes.Ingredient `json:"ingredients"`
+	Steps       *[]recipes.Step       `json:"steps"`
+	Directions  *string               `json:"directions"`
+}
+
+// errInvalidRecipeId is written when the recipe id path variable is not a number
+var errInvalidRecipeId = apperror.Field("id", "must be a number")
+
 var recipesService *RecipeService
 
 func Get() *RecipeService {
@@ -25,75 +41,157 @@ func Get() *RecipeService {
 }
 
 func (rs *RecipeService) CreateRecipe(w http.ResponseWriter, r *http.Request) {
+	user, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
 	recipe := &recipes.Recipe{}
-	err := json.NewDecoder(r.Body).Decode(recipe)
 
-	if err != nil {
-		log.Print("Error occurred when decoding recipe payload ", err.Error())
-		w.WriteHeader(http.StatusBadRequest)
+	if !request.DecodeValid(w, r, "recipe", recipe) {
 		return
 	}
 
+	recipe.ID = 0
+	recipe.AuthorID = user.ID
+
 	if err := rs.RecipeRepository.CreateRecipe(recipe); err != nil {
-		log.Print("Error occurred when creating a recipe", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
-func (rs *RecipeService) FindRecipesByTitle(w http.ResponseWriter, r *http.Request) {
-	title := r.FormValue("title")
+func (rs *RecipeService) FindRecipes(w http.ResponseWriter, r *http.Request) {
+	filter, err := searchFilter(r)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	options, err := searchOptions(r)
 
-	if title == "" {
-		log.Print("Expected title query parameter not present")
-		w.WriteHeader(http.StatusBadRequest)
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
-	results, err := rs.RecipeRepository.FindRecipesByTitle(title)
+	results, total, err := rs.RecipeRepository.FindRecipes(filter, options)
 
 	if err != nil {
-		log.Print("Error occurred when searching for recipes", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
-	if results == nil || len(results) == 0 {
-		w.WriteHeader(http.StatusNotFound)
+	facets, err := rs.RecipeRepository.CountFacets(filter, options)
+
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(results)
+	writeSearchPage(w, results, total, facets, options)
 }
 
-func (rs *RecipeService) FindRecipesByIngredients(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	ingredientsAsString := query.Get("ingredients")
+func (rs *RecipeService) FindRecipesByPantry(w http.ResponseWriter, r *http.Request) {
+	query := recipes.ParsePantry(r.URL.Query().Get("pantry"))
 
-	if ingredientsAsString == "" {
-		log.Print("Expected ingredients query parameter not present")
-		w.WriteHeader(http.StatusBadRequest)
+	if len(query.Available) == 0 {
+		problem.Write(w, r, apperror.Field("pantry", "must list available ingredients"))
 		return
 	}
 
-	ingredients := strings.Split(ingredientsAsString, ",")
+	maxMissing, err := maxMissingIngredients(r)
 
-	results, err := rs.RecipeRepository.FindRecipesByIngredients(ingredients)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	query.MaxMissing = maxMissing
+	options, err := searchOptions(r)
 
 	if err != nil {
-		log.Print("Error occurred when searching for recipes ", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
-	if results == nil || len(results) == 0 {
-		w.WriteHeader(http.StatusNotFound)
+	candidates, err := rs.RecipeRepository.FindRecipesForPantry(&query)
+
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(results)
+	writePantryPage(w, recipes.MatchPantry(candidates, query), options)
+}
+
+func (rs *RecipeService) FindRecipesByText(w http.ResponseWriter, r *http.Request) {
+	query := recipes.ParseTextQuery(r.URL.Query().Get("q"))
+
+	if query.Empty() {
+		problem.Write(w, r, apperror.Field("q", "must contain search terms"))
+		return
+	}
+
+	options, err := searchOptions(r)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	candidates, documents, err := rs.RecipeRepository.FindRecipesForText(query)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeTextPage(w, recipes.MatchText(candidates, query, documents), options)
+}
+
+func (rs *RecipeService) RateRecipe(w http.ResponseWriter, r *http.Request) {
+	user, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err != nil {
+		problem.Write(w, r, errInvalidRecipeId)
+		return
+	}
+
+	rating := recipes.Rating{}
+
+	if !request.Decode(w, r, "rating", &rating) {
+		return
+	}
+
+	if !rating.Valid() {
+		problem.Write(w, r, apperror.Field("rating",
+			"must be between "+strconv.Itoa(recipes.MinRating)+" and "+strconv.Itoa(recipes.MaxRating)))
+		return
+	}
+
+	rating.RecipeID = uint(id)
+	rating.UserID = user.ID
+	summary, err := rs.RecipeRepository.RateRecipe(rating)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(recipes.RatingResult{Rating: rating, RatingSummary: summary})
 }
 
 func (rs *RecipeService) FindRecipeById(w http.ResponseWriter, r *http.Request) {
@@ -101,17 +199,266 @@ func (rs *RecipeService) FindRecipeById(w http.ResponseWriter, r *http.Request)
 	id, err := strconv.Atoi(vars["id"])
 
 	if err != nil {
-		log.Print("Cannot parse recipe id")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, errInvalidRecipeId)
+		return
+	}
+
+	system, err := unitSystem(r)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	servings, err := scaledServings(r)
+
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
 	recipe, err := rs.RecipeRepository.FindRecipeById(id)
 
-	if recipe == nil {
-		w.WriteHeader(http.StatusNotFound)
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
+	if system != "" {
+		recipe.InSystem(system)
+	}
+
+	if servings != 0 {
+		if err := recipe.Scale(servings); err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+	}
+
 	json.NewEncoder(w).Encode(recipe)
 }
+
+func (rs *RecipeService) UpdateRecipe(w http.ResponseWriter, r *http.Request) {
+	existing, ok := rs.findOwnedRecipe(w, r)
+
+	if !ok {
+		return
+	}
+
+	recipe := &recipes.Recipe{}
+
+	if !request.Decode(w, r, "recipe", recipe) {
+		return
+	}
+
+	recipe.ID = existing.ID
+	recipe.AuthorID = existing.AuthorID
+	rs.updateRecipe(w, r, recipe)
+}
+
+func (rs *RecipeService) PatchRecipe(w http.ResponseWriter, r *http.Request) {
+	recipe, ok := rs.findOwnedRecipe(w, r)
+
+	if !ok {
+		return
+	}
+
+	patch := &recipePatch{}
+
+	if !request.Decode(w, r, "recipe", patch) {
+		return
+	}
+
+	if patch.Title != nil {
+		recipe.Title = *patch.Title
+	}
+
+	if patch.Servings != nil {
+		recipe.Servings = *patch.Servings
+	}
+
+	if patch.Ingredients != nil {
+		recipe.Ingredients = *patch.Ingredients
+	}
+
+	// free text directions replace the steps like in a full update, unless steps are sent too
+	if patch.Steps != nil {
+		recipe.Steps = *patch.Steps
+	} else if patch.Directions != nil {
+		recipe.Steps, recipe.Directions = nil, *patch.Directions
+	}
+
+	recipe.Normalize()
+
+	rs.updateRecipe(w, r, recipe)
+}
+
+// updateRecipe validates the replaced or patched recipe before storing it
+func (rs *RecipeService) updateRecipe(w http.ResponseWriter, r *http.Request, recipe *recipes.Recipe) {
+	if err := recipe.Validate(); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err := rs.RecipeRepository.UpdateRecipe(recipe); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(recipe)
+}
+
+func (rs *RecipeService) DeleteRecipe(w http.ResponseWriter, r *http.Request) {
+	recipe, ok := rs.findOwnedRecipe(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := rs.RecipeRepository.DeleteRecipe(int(recipe.ID)); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unitSystem reads the units query parameter the quantities of a recipe are converted to
+// Returns an empty system if the parameter is not sent or a validation error if it is not a known system
+func unitSystem(r *http.Request) (units.System, error) {
+	name := r.URL.Query().Get("units")
+
+	if name == "" {
+		return "", nil
+	}
+
+	system, ok := units.ParseSystem(name)
+
+	if !ok {
+		return "", apperror.Field("units", "must be metric or imperial")
+	}
+
+	return system, nil
+}
+
+// scaledServings reads the servings query parameter the quantities of a recipe are scaled to
+// Returns 0 if the parameter is not sent or a validation error if it is not a number of servings
+func scaledServings(r *http.Request) (uint, error) {
+	value := r.URL.Query().Get("servings")
+
+	if value == "" {
+		return 0, nil
+	}
+
+	servings, err := strconv.Atoi(value)
+
+	if err != nil || servings < 1 || servings > recipes.MaxServings {
+		return 0, apperror.Field("servings", fmt.Sprintf("must be a number from 1 to %d", recipes.MaxServings))
+	}
+
+	return uint(servings), nil
+}
+
+// findOwnedRecipe fetches the recipe with id from the path variables and checks that
+// the user from the request context is its author or an admin
+// Writes the error status and returns false if the recipe cannot be modified by the user
+func (rs *RecipeService) findOwnedRecipe(w http.ResponseWriter, r *http.Request) (*recipes.Recipe, bool) {
+	user, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err != nil {
+		problem.Write(w, r, errInvalidRecipeId)
+		return nil, false
+	}
+
+	recipe, err := rs.RecipeRepository.FindRecipeById(id)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return nil, false
+	}
+
+	if recipe.AuthorID != user.ID && !user.HasRole(users.RoleAdmin) {
+		problem.Write(w, r, apperror.Forbidden("the recipe belongs to another user"))
+		return nil, false
+	}
+
+	return recipe, true
+}
+
+func (rs *RecipeService) ListIngredientAttributes(w http.ResponseWriter, r *http.Request) {
+	attributes, err := rs.RecipeRepository.FindIngredientAttributes()
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if attributes == nil {
+		attributes = []recipes.IngredientAttributes{}
+	}
+
+	json.NewEncoder(w).Encode(attributes)
+}
+
+func (rs *RecipeService) UpdateIngredientAttributes(w http.ResponseWriter, r *http.Request) {
+	attributes := &recipes.IngredientAttributes{}
+
+	if !request.Decode(w, r, "ingredient attributes", attributes) {
+		return
+	}
+
+	attributes.Name = mux.Vars(r)["name"]
+
+	if err := rs.RecipeRepository.SaveIngredientAttributes(attributes); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(attributes)
+}
+
+func (rs *RecipeService) DeleteIngredientAttributes(w http.ResponseWriter, r *http.Request) {
+	if err := rs.RecipeRepository.DeleteIngredientAttributes(mux.Vars(r)["name"]); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rs *RecipeService) FindRecipeNutrition(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err != nil {
+		problem.Write(w, r, errInvalidRecipeId)
+		return
+	}
+
+	recipe, err := rs.RecipeRepository.FindRecipeById(id)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	names := make([]string, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		names[i] = ingredient.Name
+	}
+
+	nutrients, err := rs.RecipeRepository.FindIngredientNutrients(names)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(recipes.CalculateNutrition(recipe, nutrients))
+}
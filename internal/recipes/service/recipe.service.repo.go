@@ -2,125 +2,847 @@ package service
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
 	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
 	"github.com/krasimiraMilkova/cookit/pkg/recipes"
+	"github.com/krasimiraMilkova/cookit/pkg/units"
 	"strings"
+	"time"
 )
 
 type RecipeRepository struct {
 	*sql.DB
+	Dialect db.Dialect
 }
 
 func GetRecipeRepository() recipes.RecipeRepository {
-	return &RecipeRepository{db.Get()}
+	return &RecipeRepository{DB: db.Get(), Dialect: db.GetDialect()}
 }
 
 func (recipeRepository *RecipeRepository) CreateRecipe(recipe *recipes.Recipe) error {
-	if recipe.Title == "" || recipe.Directions == "" || len(recipe.Ingredients) == 0 {
-		return errors.New("recipe cannot have empty fields")
+	recipe.Normalize()
+	if err := recipe.Validate(); err != nil {
+		return err
 	}
 
-	result, err := recipeRepository.Exec("insert into recipes(title, directions)values(?,?);",
-		recipe.Title, recipe.Directions)
+	var recipeId int64
+	err := db.WithTransaction(recipeRepository.DB, func(tx *sql.Tx) error {
+		result, err := tx.Exec("insert into recipes(author_id, title, servings, created_at)values(?,?,?,?);",
+			recipe.AuthorID, recipe.Title, recipe.Servings, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		recipeId, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		ingredientIds, err := recipeRepository.insertIngredients(tx, recipeId, recipe.Ingredients)
+		if err != nil {
+			return err
+		}
+
+		if err = insertSteps(tx, recipeId, recipe.Steps, ingredientIds); err != nil {
+			return err
+		}
+
+		if err = recipeRepository.insertTags(tx, recipeId, recipe); err != nil {
+			return err
+		}
+
+		return indexRecipe(tx, recipeId, recipe)
+	})
 	if err != nil {
 		return err
 	}
 
-	id, _ := result.LastInsertId()
-	recipeId := int(id)
+	recipe.ID = uint(recipeId)
+	return nil
+}
+
+func (recipeRepository *RecipeRepository) UpdateRecipe(recipe *recipes.Recipe) error {
+	recipe.Normalize()
+	if err := recipe.Validate(); err != nil {
+		return err
+	}
+
+	return db.WithTransaction(recipeRepository.DB, func(tx *sql.Tx) error {
+		_, err := tx.Exec("update recipes set title = ?, servings = ? where id = ?;",
+			recipe.Title, recipe.Servings, recipe.ID)
+		if err != nil {
+			return err
+		}
 
-	for _, ingredient := range recipe.Ingredients {
+		_, err = tx.Exec("delete from recipe_ingredients where recipe_id = ?;", recipe.ID)
+		if err != nil {
+			return err
+		}
 
-		ingredientId, _ := recipeRepository.findIngredientIdByName(ingredient.Name)
+		_, err = tx.Exec("delete from recipe_steps where recipe_id = ?;", recipe.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("delete from recipe_tags where recipe_id = ?;", recipe.ID)
+		if err != nil {
+			return err
+		}
+
+		ingredientIds, err := recipeRepository.insertIngredients(tx, int64(recipe.ID), recipe.Ingredients)
+		if err != nil {
+			return err
+		}
+
+		if err = insertSteps(tx, int64(recipe.ID), recipe.Steps, ingredientIds); err != nil {
+			return err
+		}
+
+		if err = recipeRepository.insertTags(tx, int64(recipe.ID), recipe); err != nil {
+			return err
+		}
+
+		return indexRecipe(tx, int64(recipe.ID), recipe)
+	})
+}
+
+func (recipeRepository *RecipeRepository) DeleteRecipe(id int) error {
+	_, err := recipeRepository.Exec("delete from recipes where id = ?;", id)
+	return err
+}
+
+// insertIngredients stores the ingredients of the recipe by their canonical names
+// Returns the stored ingredients by the names they were sent with, in lower case, and by their canonical names
+func (recipeRepository *RecipeRepository) insertIngredients(tx *sql.Tx, recipeId int64, ingredients []recipes.Ingredient) (map[string]storedIngredient, error) {
+	canonicalizer, err := loadCanonicalizer(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// every name is checked before anything is written, names canonicalizing alike would resolve to one ingredient
+	sentNames := make([]string, len(ingredients))
+	listed := map[string]bool{}
+	for i, ingredient := range ingredients {
+		sentNames[i] = ingredientKey(ingredient.Name)
+		ingredients[i].Name = canonicalizer.Canonical(ingredient.Name)
+		if ingredients[i].Name == "" {
+			return nil, apperror.Validation("invalid recipe",
+				apperror.FieldError{Field: fmt.Sprintf("ingredients[%d].name", i), Message: "must not be empty"})
+		}
+
+		if listed[ingredients[i].Name] {
+			return nil, apperror.Validation("invalid recipe",
+				apperror.FieldError{Field: fmt.Sprintf("ingredients[%d].name", i), Message: "is listed twice"})
+		}
+		listed[ingredients[i].Name] = true
+
+		// units are stored by their symbols, so "tablespoons" and "tbsp" convert alike
+		if unit, ok := units.Lookup(ingredient.Measurement); ok {
+			ingredients[i].Measurement = unit.Symbol
+		}
+	}
 
-		if ingredientId == 0 {
-			result, _ := recipeRepository.Exec("insert ignore into ingredients(name)values(?);", ingredient.Name)
-			lastInsertedId, _ := result.LastInsertId()
-			ingredientId = int(lastInsertedId)
+	stored := map[string]storedIngredient{}
+	for i, ingredient := range ingredients {
+		ingredientId, err := recipeRepository.Dialect.ResolveID(tx, "ingredients", []string{"name"}, ingredient.Name)
+		if err != nil {
+			return nil, err
 		}
 
-		_, err = recipeRepository.Exec("insert into recipe_ingredients(recipe_id, ingredient_id, quantity, measurement)values(?,?,?,?);",
+		_, err = tx.Exec("insert into recipe_ingredients(recipe_id, ingredient_id, quantity, measurement)values(?,?,?,?);",
 			recipeId, ingredientId, ingredient.Quantity, ingredient.Measurement)
+		if err != nil {
+			return nil, err
+		}
+
+		stored[sentNames[i]] = storedIngredient{id: ingredientId, name: ingredient.Name}
+		stored[ingredientKey(ingredient.Name)] = stored[sentNames[i]]
+	}
+
+	return stored, nil
+}
+
+// storedIngredient is an ingredient of a recipe the steps can refer to
+type storedIngredient struct {
+	id   int64
+	name string
+}
+
+// ingredientKey is the name the steps refer to an ingredient by, letter case and surrounding spaces do not matter
+func ingredientKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// insertSteps stores the steps of the recipe in their order with the ingredients they use,
+// which must be ingredients of the recipe and are referred to by their canonical names afterwards
+func insertSteps(tx *sql.Tx, recipeId int64, steps []recipes.Step, ingredients map[string]storedIngredient) error {
+	for i, step := range steps {
+		var degrees, unit interface{}
+		if step.Temperature != nil {
+			// units are stored by their symbols like the measurements of the ingredients
+			if temperatureUnit, ok := units.Lookup(step.Temperature.Unit); ok {
+				step.Temperature.Unit = temperatureUnit.Symbol
+			}
+			degrees, unit = step.Temperature.Degrees, step.Temperature.Unit
+		}
+
+		result, err := tx.Exec("insert into recipe_steps(recipe_id, position, instruction, kind, duration_minutes, "+
+			"temperature, temperature_unit)values(?,?,?,?,?,?,?);",
+			recipeId, i+1, step.Text, step.Kind, step.DurationMinutes, degrees, unit)
+		if err != nil {
+			return err
+		}
 
+		stepId, err := result.LastInsertId()
 		if err != nil {
-			recipeRepository.deleteRecipeById(recipeId)
 			return err
 		}
+
+		used := map[int64]bool{}
+		for j, name := range step.Ingredients {
+			ingredient, ok := ingredients[ingredientKey(name)]
+			if !ok {
+				return apperror.Validation("invalid recipe", apperror.FieldError{
+					Field: fmt.Sprintf("steps[%d].ingredients[%d]", i, j), Message: "must be an ingredient of the recipe"})
+			}
+			step.Ingredients[j] = ingredient.name
+
+			if used[ingredient.id] {
+				continue
+			}
+			used[ingredient.id] = true
+
+			_, err = tx.Exec("insert into recipe_step_ingredients(step_id, ingredient_id)values(?,?);", stepId, ingredient.id)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
-func (recipeRepository *RecipeRepository) deleteRecipeById(id int) {
-	recipeRepository.Exec("delete from recipes where id = ?;", id)
+// queryer is implemented by both sql.DB and sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
 }
 
-func (recipeRepository *RecipeRepository) findIngredientIdByName(name string) (int, error) {
-	var id int
-	row := recipeRepository.QueryRow("select id from ingredients where name = ?;", name)
-	err := row.Scan(&id)
+// insertTags stores the free tags and the values of the facets of the recipe
+func (recipeRepository *RecipeRepository) insertTags(tx *sql.Tx, recipeId int64, recipe *recipes.Recipe) error {
+	tags := make([][2]string, 0, len(recipe.Tags)+len(recipes.Facets))
+	for _, tag := range recipe.Tags {
+		tags = append(tags, [2]string{recipes.FacetTag, tag})
+	}
+	values := recipe.FacetValues()
+	for _, facet := range recipes.Facets {
+		if value := values[facet]; value != "" {
+			tags = append(tags, [2]string{facet, value})
+		}
+	}
 
-	if err == sql.ErrNoRows {
+	for _, tag := range tags {
+		tagId, err := recipeRepository.Dialect.ResolveID(tx, "tags", []string{"facet", "name"}, tag[0], tag[1])
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("insert into recipe_tags(recipe_id, tag_id)values(?,?);", recipeId, tagId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadCanonicalizer reads the ingredient aliases into a canonicalizer
+func loadCanonicalizer(q queryer) (recipes.IngredientCanonicalizer, error) {
+	canonicalizer := recipes.IngredientCanonicalizer{Aliases: map[string]string{}}
+
+	rows, err := q.Query("select alias, name from ingredient_aliases;")
+	if err != nil {
+		return canonicalizer, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var alias, name string
+		if err = rows.Scan(&alias, &name); err != nil {
+			return canonicalizer, err
+		}
+
+		canonicalizer.Aliases[alias] = name
+	}
+
+	return canonicalizer, rows.Err()
+}
+
+// knownIngredientNames returns the names of all stored ingredients
+func (recipeRepository *RecipeRepository) knownIngredientNames() ([]string, error) {
+	rows, err := recipeRepository.Query("select name from ingredients;")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// indexRecipe replaces the full text search terms of the recipe
+func indexRecipe(tx *sql.Tx, recipeId int64, recipe *recipes.Recipe) error {
+	_, err := tx.Exec("delete from recipe_terms where recipe_id = ?;", recipeId)
+	if err != nil {
+		return err
+	}
+
+	for _, term := range recipes.IndexRecipe(recipe) {
+		_, err = tx.Exec("insert into recipe_terms(recipe_id, field, position, term)values(?,?,?,?);",
+			recipeId, term.Field, term.Position, term.Term)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReindexRecipes rebuilds the full text search terms of all recipes,
+// which is needed for recipes created before the search index existed
+// Returns the number of indexed recipes
+func (recipeRepository *RecipeRepository) ReindexRecipes() (int, error) {
+	rows, err := recipeRepository.Query("select id from recipes order by id;")
+	if err != nil {
 		return 0, err
 	}
 
-	return id, nil
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		recipe, err := recipeRepository.FindRecipeById(id)
+		if err != nil {
+			return 0, err
+		}
+
+		err = db.WithTransaction(recipeRepository.DB, func(tx *sql.Tx) error {
+			return indexRecipe(tx, int64(id), recipe)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+// CanonicalizeIngredients renames the stored ingredients to their canonical names and merges ingredients
+// sharing a canonical name, which is needed for ingredients created before names were canonicalized
+// Returns the number of renamed or merged ingredients
+func (recipeRepository *RecipeRepository) CanonicalizeIngredients() (int, error) {
+	changed := 0
+	err := db.WithTransaction(recipeRepository.DB, func(tx *sql.Tx) error {
+		canonicalizer, err := loadCanonicalizer(tx)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query("select id, name from ingredients order by id;")
+		if err != nil {
+			return err
+		}
+
+		var stored []recipes.Ingredient
+		byName := map[string]uint{}
+		for rows.Next() {
+			ingredient := recipes.Ingredient{}
+			if err = rows.Scan(&ingredient.ID, &ingredient.Name); err != nil {
+				rows.Close()
+				return err
+			}
+
+			stored = append(stored, ingredient)
+			byName[ingredient.Name] = ingredient.ID
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		for _, ingredient := range stored {
+			canonical := canonicalizer.Canonical(ingredient.Name)
+			if canonical == ingredient.Name {
+				continue
+			}
+			changed++
+
+			target, ok := byName[canonical]
+			if !ok {
+				if _, err = tx.Exec("update ingredients set name = ? where id = ?;", canonical, ingredient.ID); err != nil {
+					return err
+				}
+				byName[canonical] = ingredient.ID
+				continue
+			}
+
+			// recipes containing both ingredients keep the quantity of the canonical one
+			_, err = tx.Exec("update recipe_ingredients set ingredient_id = ? where ingredient_id = ? and recipe_id not in "+
+				"(select recipe_id from (select recipe_id from recipe_ingredients where ingredient_id = ?) as merged);",
+				target, ingredient.ID, target)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec("update recipe_step_ingredients set ingredient_id = ? where ingredient_id = ? and step_id not in "+
+				"(select step_id from (select step_id from recipe_step_ingredients where ingredient_id = ?) as merged);",
+				target, ingredient.ID, target)
+			if err != nil {
+				return err
+			}
+
+			if _, err = tx.Exec("delete from ingredients where id = ?;", ingredient.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return changed, err
+}
+
+// ratingAverage and ratingCount select the rating summary of the recipe r
+const (
+	ratingAverage = "coalesce((select avg(rr.rating) from recipe_ratings as rr where rr.recipe_id = r.id), 0)"
+	ratingCount   = "(select count(*) from recipe_ratings as rr where rr.recipe_id = r.id)"
+)
+
+// recipeSummaryQuery selects the recipes r with their author name, number of ingredients and rating summary
+const recipeSummaryQuery = "select r.id, r.title, r.author_id, coalesce(u.name, ''), r.created_at, " +
+	"(select count(*) from recipe_ingredients as ri where ri.recipe_id = r.id), " + ratingAverage + ", " + ratingCount +
+	" from recipes as r left join users as u on u.id = r.author_id "
+
+// searchOrders maps the search sort fields to order by clauses, the id keeps the order stable between pages
+var searchOrders = map[string]string{
+	recipes.SortByTitle:     "r.title %[1]s, r.id %[1]s",
+	recipes.SortByCreatedAt: "r.created_at %[1]s, r.id %[1]s",
+	recipes.SortByRating:    ratingAverage + " %[1]s, " + ratingCount + " %[1]s, r.id %[1]s",
+}
+
+// scanner is implemented by both sql.Row and sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRecipeSummary reads a recipe selected by the recipeSummaryQuery
+func scanRecipeSummary(row scanner, result *recipes.RecipeSearchResult) error {
+	return row.Scan(&result.ID, &result.Title, &result.AuthorID, &result.Author, &result.CreatedAt,
+		&result.IngredientCount, &result.AverageRating, &result.RatingCount)
+}
+
+func (recipeRepository *RecipeRepository) FindRecipes(filter recipes.SearchFilter, options recipes.SearchOptions) ([]recipes.RecipeSearchResult, int, error) {
+	condition, args, err := recipeRepository.filterCondition(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return recipeRepository.findRecipes(condition, options, args...)
 }
 
-func (recipeRepository *RecipeRepository) FindRecipesByTitle(title string) ([]recipes.RecipeSearchResult, error) {
-	if title == "" {
-		return nil, errors.New("title cannot be empty")
+// maxTagCounts is the number of the most used free tags whose counts are returned with the facet counts
+const maxTagCounts = 20
+
+func (recipeRepository *RecipeRepository) CountFacets(filter recipes.SearchFilter, options recipes.SearchOptions) (map[string][]recipes.FacetCount, error) {
+	condition, args, err := recipeRepository.filterCondition(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, args = ratedCondition(condition, options, args)
+	rows, err := recipeRepository.Query("select t.facet, t.name, count(*) from recipe_tags as rt "+
+		"join tags as t on t.id = rt.tag_id "+
+		"where rt.recipe_id in (select r.id from recipes as r where "+condition+") "+
+		"group by t.facet, t.name order by count(*) desc, t.name;", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := map[string][]recipes.FacetCount{}
+	defer rows.Close()
+	for rows.Next() {
+		var facet string
+		count := recipes.FacetCount{}
+		if err = rows.Scan(&facet, &count.Value, &count.Count); err != nil {
+			return nil, err
+		}
+
+		if facet != recipes.FacetTag || len(facets[facet]) < maxTagCounts {
+			facets[facet] = append(facets[facet], count)
+		}
 	}
 
-	titleSearch := "%" + title + "%"
-	return recipeRepository.findRecipes("select id, title from recipes where LOWER(title) like LOWER(?);", titleSearch)
+	return facets, rows.Err()
 }
 
-func (recipeRepository *RecipeRepository) FindRecipesByIngredients(ingredients []string) ([]recipes.RecipeSearchResult, error) {
-	if len(ingredients) == 0 {
-		return nil, errors.New("ingredients list cannot be empty")
+// filterCondition builds a condition selecting the recipes r found by the filter
+// Returns ErrEmptySearchFilter if the filter would find every recipe
+func (recipeRepository *RecipeRepository) filterCondition(filter recipes.SearchFilter) (string, []interface{}, error) {
+	if filter.IsEmpty() {
+		return "", nil, recipes.ErrEmptySearchFilter
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if filter.Title != "" {
+		conditions = append(conditions, "LOWER(r.title) like LOWER(?)")
+		args = append(args, "%"+filter.Title+"%")
+	}
+
+	if len(filter.Ingredients) > 0 {
+		names, err := recipeRepository.closestIngredientNames(filter.Ingredients)
+		if err != nil {
+			return "", nil, err
+		}
+
+		condition, ingredientArgs := ingredientNamesCondition("in", names)
+		conditions = append(conditions, condition)
+		args = append(args, ingredientArgs...)
+	}
+
+	tags := make([][2]string, 0, len(filter.Tags)+len(filter.Facets))
+	for _, tag := range filter.Tags {
+		tags = append(tags, [2]string{recipes.FacetTag, tag})
+	}
+	for _, facet := range recipes.Facets {
+		if value, ok := filter.Facets[facet]; ok {
+			tags = append(tags, [2]string{facet, value})
+		}
 	}
 
-	args := make([]interface{}, len(ingredients))
+	for _, tag := range tags {
+		conditions = append(conditions, "r.id in (select rt.recipe_id from recipe_tags as rt "+
+			"join tags as t on t.id = rt.tag_id where t.facet = ? and t.name = ?)")
+		args = append(args, tag[0], tag[1])
+	}
+
+	for _, diet := range filter.Diets {
+		column, ok := dietColumns[diet]
+		if !ok {
+			return "", nil, apperror.Field("diet", "must be one of "+strings.Join(recipes.Diets, ", "))
+		}
+
+		conditions = append(conditions, "r.id not in (select ri.recipe_id from recipe_ingredients as ri "+
+			"join ingredients as ing on ri.ingredient_id = ing.id "+
+			"join ingredient_attributes as a on a.name = ing.name where a."+column+" = 0)")
+	}
+
+	if len(filter.ExcludedAllergens) > 0 {
+		argsWildCards := strings.Repeat(",?", len(filter.ExcludedAllergens)-1)
+		conditions = append(conditions, "r.id not in (select ri.recipe_id from recipe_ingredients as ri "+
+			"join ingredients as ing on ri.ingredient_id = ing.id where ing.name in "+
+			"(select ia.name from ingredient_allergens as ia where ia.allergen in (?"+argsWildCards+")))")
+		for _, allergen := range filter.ExcludedAllergens {
+			args = append(args, allergen)
+		}
+	}
+
+	return strings.Join(conditions, " and "), args, nil
+}
+
+func (recipeRepository *RecipeRepository) FindRecipesByAuthor(authorId uint, options recipes.SearchOptions) ([]recipes.RecipeSearchResult, int, error) {
+	return recipeRepository.findRecipes("r.author_id = ?", options, authorId)
+}
+
+// closestIngredientNames returns the canonical names of the ingredients,
+// tolerating typos in names which are not known ingredients
+func (recipeRepository *RecipeRepository) closestIngredientNames(ingredients []string) ([]string, error) {
+	canonicalizer, err := loadCanonicalizer(recipeRepository.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := recipeRepository.knownIngredientNames()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(ingredients))
 	for i, ingredient := range ingredients {
-		args[i] = ingredient
+		names[i] = canonicalizer.Closest(ingredient, known)
 	}
-	argsWildCards := strings.Repeat(",?", len(args)-1)
-	query := "select id, title from recipes where id in (select distinct recipe_id from recipe_ingredients where " +
-		"ingredient_id in (select id from ingredients where name in (?" + argsWildCards + ")));"
-	return recipeRepository.findRecipes(query, args...)
+
+	return names, nil
 }
 
-func (recipeRepository *RecipeRepository) findRecipes(query string, args ...interface{}) ([]recipes.RecipeSearchResult, error) {
-	var results []recipes.RecipeSearchResult
+func (recipeRepository *RecipeRepository) FindRecipesForPantry(query *recipes.PantryQuery) ([]recipes.RecipeIngredients, error) {
+	if len(query.Available) == 0 {
+		return nil, apperror.Field("pantry", "must list available ingredients")
+	}
+
+	canonicalizer, err := loadCanonicalizer(recipeRepository.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, name := range query.Available {
+		query.Available[i] = canonicalizer.Canonical(name)
+	}
+	for i, name := range query.Excluded {
+		query.Excluded[i] = canonicalizer.Canonical(name)
+	}
+
+	condition, args := ingredientNamesCondition("in", query.Available)
+	if len(query.Excluded) > 0 {
+		excludedCondition, excludedArgs := ingredientNamesCondition("not in", query.Excluded)
+		condition += " and " + excludedCondition
+		args = append(args, excludedArgs...)
+	}
+
+	candidates := []recipes.RecipeIngredients{}
+	byId := map[uint]int{}
 
-	rows, err := recipeRepository.Query(query, args...)
+	rows, err := recipeRepository.Query(recipeSummaryQuery+"where "+condition+";", args...)
 	if err != nil {
 		return nil, err
 	}
 
 	defer rows.Close()
 	for rows.Next() {
-		result := recipes.RecipeSearchResult{}
-		err = rows.Scan(&result.ID, &result.Title)
+		candidate := recipes.RecipeIngredients{}
+		err = scanRecipeSummary(rows, &candidate.RecipeSearchResult)
 
 		if err != nil {
 			return nil, err
 		}
 
+		byId[candidate.ID] = len(candidates)
+		candidates = append(candidates, candidate)
+	}
+
+	if err = rows.Err(); err != nil || len(candidates) == 0 {
+		return candidates, err
+	}
+
+	nameRows, err := recipeRepository.Query("select ri.recipe_id, ing.name from recipe_ingredients as ri "+
+		"join ingredients as ing on ri.ingredient_id = ing.id "+
+		"where ri.recipe_id in (select r.id from recipes as r where "+condition+");", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer nameRows.Close()
+	for nameRows.Next() {
+		var recipeId uint
+		var name string
+
+		if err = nameRows.Scan(&recipeId, &name); err != nil {
+			return nil, err
+		}
+
+		if i, ok := byId[recipeId]; ok {
+			candidates[i].Ingredients = append(candidates[i].Ingredients, name)
+		}
+	}
+
+	return candidates, nameRows.Err()
+}
+
+func (recipeRepository *RecipeRepository) FindRecipesForText(query recipes.TextQuery) ([]recipes.RecipeTerms, int, error) {
+	terms := query.AllTerms()
+	if len(terms) == 0 {
+		return nil, 0, apperror.Field("q", "must not be empty")
+	}
+
+	var documents int
+	err := recipeRepository.QueryRow("select count(*) from recipes;").Scan(&documents)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args := make([]interface{}, len(terms))
+	for i, term := range terms {
+		args[i] = term
+	}
+	termsCondition := "term in (?" + strings.Repeat(",?", len(args)-1) + ")"
+
+	candidates := []recipes.RecipeTerms{}
+	byId := map[uint]int{}
+
+	rows, err := recipeRepository.Query(recipeSummaryQuery+
+		"where r.id in (select recipe_id from recipe_terms where "+termsCondition+");", args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		candidate := recipes.RecipeTerms{}
+		err = scanRecipeSummary(rows, &candidate.RecipeSearchResult)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		byId[candidate.ID] = len(candidates)
+		candidates = append(candidates, candidate)
+	}
+
+	if err = rows.Err(); err != nil || len(candidates) == 0 {
+		return candidates, documents, err
+	}
+
+	termRows, err := recipeRepository.Query("select recipe_id, field, position, term from recipe_terms where "+
+		termsCondition+";", args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer termRows.Close()
+	for termRows.Next() {
+		var recipeId uint
+		term := recipes.SearchTerm{}
+
+		if err = termRows.Scan(&recipeId, &term.Field, &term.Position, &term.Term); err != nil {
+			return nil, 0, err
+		}
+
+		if i, ok := byId[recipeId]; ok {
+			candidates[i].Terms = append(candidates[i].Terms, term)
+		}
+	}
+
+	return candidates, documents, termRows.Err()
+}
+
+// ingredientNamesCondition builds a condition selecting the recipes r
+// which do or do not contain any of the ingredients with the given canonical names
+func ingredientNamesCondition(operator string, names []string) (string, []interface{}) {
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+
+	argsWildCards := strings.Repeat(",?", len(args)-1)
+	condition := "r.id " + operator + " (select ri.recipe_id from recipe_ingredients as ri " +
+		"join ingredients as ing on ri.ingredient_id = ing.id where ing.name in (?" + argsWildCards + "))"
+	return condition, args
+}
+
+// ratedCondition narrows the condition down to the recipes rated at least with the minimum rating of the options
+func ratedCondition(condition string, options recipes.SearchOptions, args []interface{}) (string, []interface{}) {
+	if options.MinRating > 0 {
+		condition = "(" + condition + ") and " + ratingAverage + " >= ?"
+		args = append(args, options.MinRating)
+	}
+
+	return condition, args
+}
+
+// findRecipes returns the page selected by the options of the recipes matching the condition
+// and rated at least with the minimum rating of the options, together with the total number of such recipes
+func (recipeRepository *RecipeRepository) findRecipes(condition string, options recipes.SearchOptions, args ...interface{}) ([]recipes.RecipeSearchResult, int, error) {
+	condition, args = ratedCondition(condition, options, args)
+
+	var total int
+	err := recipeRepository.QueryRow("select count(*) from recipes as r where "+condition+";", args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := searchOrders[options.SortBy]
+	if !ok {
+		order = searchOrders[recipes.SortByTitle]
+	}
+
+	direction := "asc"
+	if options.Descending {
+		direction = "desc"
+	}
+
+	query := recipeSummaryQuery + "where " + condition + " order by " + fmt.Sprintf(order, direction) + " limit ? offset ?;"
+	rows, err := recipeRepository.Query(query, append(args, options.Limit, options.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := []recipes.RecipeSearchResult{}
+	defer rows.Close()
+	for rows.Next() {
+		result := recipes.RecipeSearchResult{}
+		err = scanRecipeSummary(rows, &result)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
 		results = append(results, result)
 	}
 
-	return results, nil
+	return results, total, rows.Err()
+}
+
+func (recipeRepository *RecipeRepository) RateRecipe(rating recipes.Rating) (recipes.RatingSummary, error) {
+	summary := recipes.RatingSummary{}
+	if !rating.Valid() {
+		return summary, apperror.Field("rating",
+			fmt.Sprintf("must be between %d and %d", recipes.MinRating, recipes.MaxRating))
+	}
+
+	err := db.WithTransaction(recipeRepository.DB, func(tx *sql.Tx) error {
+		upsert := recipeRepository.Dialect.Upsert("recipe_ratings",
+			[]string{"recipe_id", "user_id"}, []string{"rating", "rated_at"})
+
+		_, err := tx.Exec(upsert, rating.RecipeID, rating.UserID, rating.Rating, time.Now().UTC())
+		if recipeRepository.Dialect.IsForeignKeyViolation(err) {
+			return recipes.ErrRecipeNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRow("select "+ratingAverage+", "+ratingCount+" from recipes as r where r.id = ?;",
+			rating.RecipeID).Scan(&summary.AverageRating, &summary.RatingCount)
+	})
+
+	return summary, err
 }
 
 func (recipeRepository *RecipeRepository) FindRecipeById(id int) (*recipes.Recipe, error) {
 	recipe := &recipes.Recipe{}
-	recipeRow := recipeRepository.QueryRow("select * from recipes where id = ?;", id)
-	err := recipeRow.Scan(&recipe.ID, &recipe.Title, &recipe.Directions)
+	recipeRow := recipeRepository.QueryRow("select r.id, r.author_id, r.title, r.servings, "+
+		ratingAverage+", "+ratingCount+" from recipes as r where r.id = ?;", id)
+	err := recipeRow.Scan(&recipe.ID, &recipe.AuthorID, &recipe.Title, &recipe.Servings,
+		&recipe.AverageRating, &recipe.RatingCount)
 
 	if err == sql.ErrNoRows {
+		return nil, recipes.ErrRecipeNotFound
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
@@ -144,5 +866,308 @@ func (recipeRepository *RecipeRepository) FindRecipeById(id int) (*recipes.Recip
 		recipe.Ingredients = append(recipe.Ingredients, ingredient)
 	}
 
+	if recipe.Steps, err = recipeRepository.findSteps(id); err != nil {
+		return nil, err
+	}
+
+	if err = recipeRepository.findTags(recipe); err != nil {
+		return nil, err
+	}
+
+	if recipe.Dietary, err = recipeRepository.dietaryProfile(recipe); err != nil {
+		return nil, err
+	}
+
+	recipe.Normalize()
 	return recipe, nil
 }
+
+// findSteps returns the steps of the recipe in their order with the names of the ingredients they use
+func (recipeRepository *RecipeRepository) findSteps(recipeId int) ([]recipes.Step, error) {
+	rows, err := recipeRepository.Query("select id, instruction, kind, duration_minutes, temperature, temperature_unit "+
+		"from recipe_steps where recipe_id = ? order by position;", recipeId)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []recipes.Step
+	positions := map[int64]int{}
+	for rows.Next() {
+		var stepId int64
+		var degrees sql.NullFloat64
+		var unit sql.NullString
+		step := recipes.Step{}
+		if err = rows.Scan(&stepId, &step.Text, &step.Kind, &step.DurationMinutes, &degrees, &unit); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if degrees.Valid && unit.Valid {
+			step.Temperature = &recipes.Temperature{Degrees: units.Quantity(degrees.Float64), Unit: unit.String}
+		}
+
+		positions[stepId] = len(steps)
+		steps = append(steps, step)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	nameRows, err := recipeRepository.Query("select rs.id, ing.name from recipe_step_ingredients as rsi "+
+		"join recipe_steps as rs on rsi.step_id = rs.id "+
+		"join ingredients as ing on rsi.ingredient_id = ing.id "+
+		"where rs.recipe_id = ? order by ing.name;", recipeId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer nameRows.Close()
+	for nameRows.Next() {
+		var stepId int64
+		var name string
+		if err = nameRows.Scan(&stepId, &name); err != nil {
+			return nil, err
+		}
+
+		steps[positions[stepId]].Ingredients = append(steps[positions[stepId]].Ingredients, name)
+	}
+
+	return steps, nameRows.Err()
+}
+
+// findTags loads the free tags of the recipe in alphabetical order and the values of its facets
+func (recipeRepository *RecipeRepository) findTags(recipe *recipes.Recipe) error {
+	rows, err := recipeRepository.Query("select t.facet, t.name from recipe_tags as rt "+
+		"join tags as t on t.id = rt.tag_id where rt.recipe_id = ? order by t.name;", recipe.ID)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var facet, name string
+		if err = rows.Scan(&facet, &name); err != nil {
+			return err
+		}
+
+		if facet == recipes.FacetTag {
+			recipe.Tags = append(recipe.Tags, name)
+		} else {
+			recipe.SetFacetValue(facet, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// dietColumns maps the diets to the columns of ingredient_attributes flagging the ingredients suitable for them
+var dietColumns = map[string]string{
+	recipes.DietVegan:      "vegan",
+	recipes.DietVegetarian: "vegetarian",
+	recipes.DietGlutenFree: "gluten_free",
+}
+
+// dietaryProfile derives the dietary profile of the recipe from the attributes of its ingredients
+func (recipeRepository *RecipeRepository) dietaryProfile(recipe *recipes.Recipe) (*recipes.DietaryProfile, error) {
+	attributes, err := recipeRepository.findIngredientAttributes("name in (select ing.name from recipe_ingredients as ri "+
+		"join ingredients as ing on ri.ingredient_id = ing.id where ri.recipe_id = ?)", recipe.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]recipes.IngredientAttributes{}
+	for _, ingredient := range attributes {
+		byName[ingredient.Name] = ingredient
+	}
+
+	names := make([]string, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		names[i] = ingredient.Name
+	}
+
+	profile := recipes.DeriveDietaryProfile(names, byName)
+	return &profile, nil
+}
+
+func (recipeRepository *RecipeRepository) FindIngredientAttributes() ([]recipes.IngredientAttributes, error) {
+	return recipeRepository.findIngredientAttributes("1 = 1")
+}
+
+// findIngredientAttributes returns the attributes of the ingredients whose name matches the condition, ordered by name
+func (recipeRepository *RecipeRepository) findIngredientAttributes(condition string, args ...interface{}) ([]recipes.IngredientAttributes, error) {
+	rows, err := recipeRepository.Query("select name, vegan, vegetarian, gluten_free from ingredient_attributes "+
+		"where "+condition+" order by name;", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []recipes.IngredientAttributes
+	positions := map[string]int{}
+	for rows.Next() {
+		ingredient := recipes.IngredientAttributes{Allergens: []string{}}
+		if err = rows.Scan(&ingredient.Name, &ingredient.Vegan, &ingredient.Vegetarian, &ingredient.GlutenFree); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		positions[ingredient.Name] = len(attributes)
+		attributes = append(attributes, ingredient)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	allergenRows, err := recipeRepository.Query("select name, allergen from ingredient_allergens "+
+		"where "+condition+" order by allergen;", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer allergenRows.Close()
+	for allergenRows.Next() {
+		var name, allergen string
+		if err = allergenRows.Scan(&name, &allergen); err != nil {
+			return nil, err
+		}
+
+		attributes[positions[name]].Allergens = append(attributes[positions[name]].Allergens, allergen)
+	}
+
+	return attributes, allergenRows.Err()
+}
+
+func (recipeRepository *RecipeRepository) SaveIngredientAttributes(attributes *recipes.IngredientAttributes) error {
+	canonicalizer, err := loadCanonicalizer(recipeRepository.DB)
+	if err != nil {
+		return err
+	}
+
+	attributes.Name = canonicalizer.Canonical(attributes.Name)
+	attributes.Normalize()
+	if err = attributes.Validate(); err != nil {
+		return err
+	}
+
+	return db.WithTransaction(recipeRepository.DB, func(tx *sql.Tx) error {
+		upsert := recipeRepository.Dialect.Upsert("ingredient_attributes",
+			[]string{"name"}, []string{"vegan", "vegetarian", "gluten_free"})
+
+		_, err := tx.Exec(upsert, attributes.Name, attributes.Vegan, attributes.Vegetarian, attributes.GlutenFree)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("delete from ingredient_allergens where name = ?;", attributes.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, allergen := range attributes.Allergens {
+			_, err = tx.Exec("insert into ingredient_allergens(name, allergen)values(?,?);", attributes.Name, allergen)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (recipeRepository *RecipeRepository) DeleteIngredientAttributes(name string) error {
+	canonicalizer, err := loadCanonicalizer(recipeRepository.DB)
+	if err != nil {
+		return err
+	}
+
+	result, err := recipeRepository.Exec("delete from ingredient_attributes where name = ?;", canonicalizer.Canonical(name))
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return recipes.ErrIngredientNotClassified
+	}
+
+	return nil
+}
+
+func (recipeRepository *RecipeRepository) FindIngredientNutrients(names []string) (map[string]recipes.IngredientNutrients, error) {
+	nutrients := map[string]recipes.IngredientNutrients{}
+	if len(names) == 0 {
+		return nutrients, nil
+	}
+
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+
+	argsWildCards := strings.Repeat(",?", len(args)-1)
+	rows, err := recipeRepository.Query("select name, calories, protein, fat, carbs, fibre, grams_per_ml, grams_per_piece "+
+		"from ingredient_nutrients where name in (?"+argsWildCards+");", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		ingredient := recipes.IngredientNutrients{}
+		var gramsPerMl, gramsPerPiece sql.NullFloat64
+		err = rows.Scan(&ingredient.Name, &ingredient.Per100g.Calories, &ingredient.Per100g.Protein,
+			&ingredient.Per100g.Fat, &ingredient.Per100g.Carbs, &ingredient.Per100g.Fibre, &gramsPerMl, &gramsPerPiece)
+		if err != nil {
+			return nil, err
+		}
+
+		ingredient.GramsPerMl, ingredient.GramsPerPiece = gramsPerMl.Float64, gramsPerPiece.Float64
+		nutrients[ingredient.Name] = ingredient
+	}
+
+	return nutrients, rows.Err()
+}
+
+func (recipeRepository *RecipeRepository) SaveIngredientNutrients(nutrients []recipes.IngredientNutrients) (int, error) {
+	canonicalizer, err := loadCanonicalizer(recipeRepository.DB)
+	if err != nil {
+		return 0, err
+	}
+
+	saved := map[string]bool{}
+	err = db.WithTransaction(recipeRepository.DB, func(tx *sql.Tx) error {
+		upsert := recipeRepository.Dialect.Upsert("ingredient_nutrients", []string{"name"},
+			[]string{"calories", "protein", "fat", "carbs", "fibre", "grams_per_ml", "grams_per_piece"})
+
+		for _, ingredient := range nutrients {
+			name := canonicalizer.Canonical(ingredient.Name)
+			if name == "" || saved[name] {
+				continue
+			}
+
+			_, err := tx.Exec(upsert, name, ingredient.Per100g.Calories, ingredient.Per100g.Protein,
+				ingredient.Per100g.Fat, ingredient.Per100g.Carbs, ingredient.Per100g.Fibre,
+				sql.NullFloat64{Float64: ingredient.GramsPerMl, Valid: ingredient.GramsPerMl > 0},
+				sql.NullFloat64{Float64: ingredient.GramsPerPiece, Valid: ingredient.GramsPerPiece > 0})
+			if err != nil {
+				return err
+			}
+
+			saved[name] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(saved), nil
+}
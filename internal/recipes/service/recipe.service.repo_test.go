@@ -0,0 +1,876 @@
+package service
+
+import (
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/recipes"
+	"github.com/krasimiraMilkova/cookit/pkg/units"
+	"reflect"
+	"testing"
+)
+
+var searchAll = recipes.SearchOptions{Limit: maxSearchLimit, SortBy: recipes.SortByTitle}
+
+func newMemoryRecipeRepository(t *testing.T) *RecipeRepository {
+	database, dialect, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return &RecipeRepository{DB: database, Dialect: dialect}
+}
+
+func TestRecipeRepository_CreateAndFindRecipe(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	recipe := &recipes.Recipe{
+		AuthorID: 3,
+		Title:    "Tomato soup",
+		Ingredients: []recipes.Ingredient{
+			{Name: "tomato", Quantity: 4, Measurement: "pcs"},
+			{Name: "salt", Quantity: 1, Measurement: "tsp"},
+		},
+		Directions: "Boil",
+	}
+
+	if err := repository.CreateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	if recipe.ID == 0 {
+		t.Error("expected the created recipe id to be set")
+	}
+
+	found, err := repository.FindRecipeById(int(recipe.ID))
+	if err != nil {
+		t.Fatal("unexpected error on find", err)
+	}
+
+	for i := range found.Ingredients {
+		found.Ingredients[i].ID = 0
+	}
+
+	recipe.Dietary = &recipes.DietaryProfile{
+		Diets:     []string{recipes.DietVegan, recipes.DietVegetarian, recipes.DietGlutenFree},
+		Allergens: []string{},
+	}
+	if !reflect.DeepEqual(found, recipe) {
+		t.Errorf("Got recipe = %v but wanted %v", found, recipe)
+	}
+
+	results, total, err := repository.FindRecipes(recipes.SearchFilter{Ingredients: []string{"salt"}}, searchAll)
+	if err != nil || total != 1 || len(results) != 1 || results[0].ID != recipe.ID {
+		t.Errorf("Got search results = %v, %v but wanted the created recipe", results, err)
+	}
+}
+
+func TestRecipeRepository_CreateRecipeReusesIngredients(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	for _, title := range []string{"First", "Second"} {
+		err := repository.CreateRecipe(&recipes.Recipe{
+			Title:       title,
+			Ingredients: []recipes.Ingredient{{Name: "flour", Quantity: 1, Measurement: "kg"}},
+			Directions:  "Bake",
+		})
+		if err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	var count int
+	repository.QueryRow("select count(*) from ingredients;").Scan(&count)
+	if count != 1 {
+		t.Errorf("expected 1 ingredient got %v", count)
+	}
+
+	results, _, _ := repository.FindRecipes(recipes.SearchFilter{Ingredients: []string{"flour"}}, searchAll)
+	if len(results) != 2 {
+		t.Errorf("expected 2 recipes with the ingredient got %v", len(results))
+	}
+}
+
+func TestRecipeRepository_IngredientListedTwice(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	recipe := &recipes.Recipe{
+		Title: "Salsa",
+		Ingredients: []recipes.Ingredient{
+			{Name: "Tomato", Quantity: 2, Measurement: "pcs"},
+			{Name: "onion", Quantity: 1, Measurement: "pcs"},
+			{Name: "tomatoes", Quantity: 100, Measurement: "g"},
+		},
+		Directions: "Chop",
+	}
+
+	err := repository.CreateRecipe(recipe)
+	if typed, ok := apperror.As(err); !ok || len(typed.Fields) != 1 || typed.Fields[0].Field != "ingredients[2].name" {
+		t.Fatalf("Got error = %v but wanted ingredients[2].name listed twice", err)
+	}
+
+	var count int
+	repository.QueryRow("select count(*) from recipes;").Scan(&count)
+	if count != 0 {
+		t.Errorf("expected no recipe after the failed create got %v", count)
+	}
+
+	recipe.Ingredients = recipe.Ingredients[:2]
+	if err = repository.CreateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	recipe.Ingredients = append(recipe.Ingredients, recipes.Ingredient{Name: "Onions", Quantity: 1, Measurement: "pcs"})
+	if err = repository.UpdateRecipe(recipe); apperror.KindOf(err) != apperror.KindValidation {
+		t.Errorf("Got error = %v for an update listing onions twice", err)
+	}
+
+	found, _ := repository.FindRecipeById(int(recipe.ID))
+	if len(found.Ingredients) != 2 {
+		t.Errorf("Got ingredients = %v after the failed update", found.Ingredients)
+	}
+}
+
+func TestRecipeRepository_FractionalQuantitiesAndUnits(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	third, _ := units.ParseQuantity("1/3")
+	recipe := &recipes.Recipe{
+		Title: "Custard",
+		Ingredients: []recipes.Ingredient{
+			{Name: "sugar", Quantity: third, Measurement: "Tablespoons"},
+			{Name: "milk", Quantity: 1.5, Measurement: "cups"},
+		},
+		Directions: "Whisk",
+	}
+
+	if err := repository.CreateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	found, err := repository.FindRecipeById(int(recipe.ID))
+	if err != nil {
+		t.Fatal("unexpected error on find", err)
+	}
+
+	expected := map[string]recipes.Ingredient{
+		"sugar": {Name: "sugar", Quantity: 0.3333, Measurement: "tbsp"},
+		"milk":  {Name: "milk", Quantity: 1.5, Measurement: "cup"},
+	}
+
+	for _, ingredient := range found.Ingredients {
+		ingredient.ID = 0
+		if ingredient != expected[ingredient.Name] {
+			t.Errorf("Got ingredient = %v but wanted %v", ingredient, expected[ingredient.Name])
+		}
+	}
+}
+
+func TestRecipeRepository_Servings(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	recipe := &recipes.Recipe{
+		Title:       "Risotto",
+		Servings:    4,
+		Ingredients: []recipes.Ingredient{{Name: "rice", Quantity: 320, Measurement: "g"}},
+		Directions:  "Stir",
+	}
+
+	if err := repository.CreateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	if found, err := repository.FindRecipeById(int(recipe.ID)); err != nil || found.Servings != 4 {
+		t.Fatalf("Got recipe = %v, error = %v but wanted 4 servings", found, err)
+	}
+
+	recipe.Servings = 2
+	if err := repository.UpdateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	if found, err := repository.FindRecipeById(int(recipe.ID)); err != nil || found.Servings != 2 {
+		t.Errorf("Got recipe = %v, error = %v but wanted 2 servings", found, err)
+	}
+}
+
+func TestRecipeRepository_Steps(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	recipe := &recipes.Recipe{
+		Title: "Roast potatoes",
+		Ingredients: []recipes.Ingredient{
+			{Name: "Potatoes", Quantity: 1, Measurement: "kg"},
+			{Name: "oil", Quantity: 2, Measurement: "tbsp"},
+		},
+		Steps: []recipes.Step{
+			{Text: "Peel and cut the potatoes", DurationMinutes: 15, Ingredients: []string{"potatoes"}},
+			{Text: "Roast them", DurationMinutes: 45, Ingredients: []string{"Oil", "POTATOES"},
+				Temperature: &recipes.Temperature{Degrees: 200, Unit: "celsius"}},
+		},
+	}
+
+	if err := repository.CreateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	found, err := repository.FindRecipeById(int(recipe.ID))
+	if err != nil {
+		t.Fatal("unexpected error on find", err)
+	}
+
+	expected := []recipes.Step{
+		{Text: "Peel and cut the potatoes", Kind: recipes.StepPrep, DurationMinutes: 15, Ingredients: []string{"potato"}},
+		{Text: "Roast them", Kind: recipes.StepCook, DurationMinutes: 45, Ingredients: []string{"oil", "potato"},
+			Temperature: &recipes.Temperature{Degrees: 200, Unit: "°C"}},
+	}
+	if !reflect.DeepEqual(found.Steps, expected) {
+		t.Errorf("Got steps = %v but wanted %v", found.Steps, expected)
+	}
+
+	if found.PrepMinutes != 15 || found.CookMinutes != 45 || found.Directions != "Peel and cut the potatoes\nRoast them" {
+		t.Errorf("Got prep = %v, cook = %v minutes and directions %q", found.PrepMinutes, found.CookMinutes, found.Directions)
+	}
+
+	recipe.Steps = []recipes.Step{{Text: "Fry the onions", Ingredients: []string{"onion"}}}
+	err = repository.UpdateRecipe(recipe)
+	if typed, ok := apperror.As(err); !ok || len(typed.Fields) != 1 || typed.Fields[0].Field != "steps[0].ingredients[0]" {
+		t.Errorf("expected a validation error for an ingredient which is not in the recipe got %v", err)
+	}
+
+	if found, _ = repository.FindRecipeById(int(recipe.ID)); len(found.Steps) != 2 {
+		t.Errorf("expected the failed update to keep the steps got %v", found.Steps)
+	}
+}
+
+func TestRecipeRepository_TagsAndFacets(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	pasta := &recipes.Recipe{
+		Title:       "Pasta with basil",
+		Ingredients: []recipes.Ingredient{{Name: "basil", Quantity: 20, Measurement: "g"}},
+		Directions:  "Boil",
+		Tags:        []string{"Vegan", "quick", "vegan"},
+		Cuisine:     "Italian",
+		Course:      "main",
+		Difficulty:  "easy",
+	}
+	risotto := &recipes.Recipe{
+		Title:       "Risotto",
+		Ingredients: []recipes.Ingredient{{Name: "rice", Quantity: 320, Measurement: "g"}},
+		Directions:  "Stir",
+		Tags:        []string{"vegan"},
+		Cuisine:     "italian",
+		Difficulty:  "medium",
+	}
+	tacos := &recipes.Recipe{
+		Title:       "Tacos",
+		Ingredients: []recipes.Ingredient{{Name: "tortillas", Quantity: 8, Measurement: "pcs"}},
+		Directions:  "Fill",
+		Tags:        []string{"quick"},
+		Cuisine:     "mexican",
+	}
+
+	for _, recipe := range []*recipes.Recipe{pasta, risotto, tacos} {
+		if err := repository.CreateRecipe(recipe); err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	found, err := repository.FindRecipeById(int(pasta.ID))
+	if err != nil {
+		t.Fatal("unexpected error on find", err)
+	}
+
+	if !reflect.DeepEqual(found.Tags, []string{"quick", "vegan"}) || found.FacetValues()[recipes.FacetCuisine] != "italian" ||
+		found.Course != "main" || found.Difficulty != "easy" || found.MealType != "" {
+		t.Errorf("Got tags = %v and facets = %v", found.Tags, found.FacetValues())
+	}
+
+	titles := func(filter recipes.SearchFilter) []string {
+		results, _, err := repository.FindRecipes(filter, searchAll)
+		if err != nil {
+			t.Fatal("unexpected error on search", err)
+		}
+
+		var titles []string
+		for _, result := range results {
+			titles = append(titles, result.Title)
+		}
+		return titles
+	}
+
+	if got := titles(recipes.SearchFilter{Tags: []string{"vegan"}, Facets: map[string]string{recipes.FacetCuisine: "italian"}}); !reflect.DeepEqual(got, []string{"Pasta with basil", "Risotto"}) {
+		t.Errorf("Got %v for vegan italian recipes", got)
+	}
+
+	if got := titles(recipes.SearchFilter{Tags: []string{"vegan", "quick"}}); !reflect.DeepEqual(got, []string{"Pasta with basil"}) {
+		t.Errorf("Got %v for quick vegan recipes", got)
+	}
+
+	if got := titles(recipes.SearchFilter{Title: "risotto", Tags: []string{"quick"}}); got != nil {
+		t.Errorf("Got %v for quick risotto recipes", got)
+	}
+
+	if got := titles(recipes.SearchFilter{Ingredients: []string{"rice"}, Facets: map[string]string{recipes.FacetDifficulty: "medium"}}); !reflect.DeepEqual(got, []string{"Risotto"}) {
+		t.Errorf("Got %v for medium recipes with rice", got)
+	}
+
+	facets, err := repository.CountFacets(recipes.SearchFilter{Tags: []string{"quick"}}, searchAll)
+	if err != nil {
+		t.Fatal("unexpected error on counting facets", err)
+	}
+
+	expected := map[string][]recipes.FacetCount{
+		recipes.FacetCuisine:    {{Value: "italian", Count: 1}, {Value: "mexican", Count: 1}},
+		recipes.FacetCourse:     {{Value: "main", Count: 1}},
+		recipes.FacetDifficulty: {{Value: "easy", Count: 1}},
+		recipes.FacetTag:        {{Value: "quick", Count: 2}, {Value: "vegan", Count: 1}},
+	}
+	if !reflect.DeepEqual(facets, expected) {
+		t.Errorf("Got facets = %v but wanted %v", facets, expected)
+	}
+
+	pasta.Tags, pasta.Cuisine, pasta.Course = []string{"summer"}, "", ""
+	if err = repository.UpdateRecipe(pasta); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	if found, _ = repository.FindRecipeById(int(pasta.ID)); !reflect.DeepEqual(found.Tags, []string{"summer"}) ||
+		found.Cuisine != "" || found.Course != "" || found.Difficulty != "easy" {
+		t.Errorf("Got tags = %v and facets = %v after update", found.Tags, found.FacetValues())
+	}
+
+	if _, _, err = repository.FindRecipes(recipes.SearchFilter{}, searchAll); err != recipes.ErrEmptySearchFilter {
+		t.Errorf("Got error = %v for an empty filter", err)
+	}
+
+	pasta.Cuisine = "martian"
+	if err = repository.UpdateRecipe(pasta); apperror.KindOf(err) != apperror.KindValidation {
+		t.Errorf("Got error = %v for an unknown cuisine", err)
+	}
+}
+
+func TestRecipeRepository_DietaryProfile(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	salad := &recipes.Recipe{
+		Title: "Tomato salad",
+		Ingredients: []recipes.Ingredient{
+			{Name: "Tomatoes", Quantity: 4, Measurement: "pcs"},
+			{Name: "olive oil", Quantity: 2, Measurement: "tbsp"},
+		},
+		Directions: "Slice",
+	}
+	pasta := &recipes.Recipe{
+		Title: "Pasta with parmesan",
+		Ingredients: []recipes.Ingredient{
+			{Name: "pasta", Quantity: 200, Measurement: "g"},
+			{Name: "parmesan", Quantity: 30, Measurement: "g"},
+		},
+		Directions: "Boil",
+	}
+	pesto := &recipes.Recipe{
+		Title: "Pesto",
+		Ingredients: []recipes.Ingredient{
+			{Name: "basil", Quantity: 50, Measurement: "g"},
+			{Name: "pine nuts", Quantity: 30, Measurement: "g"},
+			{Name: "saffron", Quantity: 1, Measurement: "pcs"},
+		},
+		Directions: "Blend",
+	}
+
+	for _, recipe := range []*recipes.Recipe{salad, pasta, pesto} {
+		if err := repository.CreateRecipe(recipe); err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	found, err := repository.FindRecipeById(int(pasta.ID))
+	expected := &recipes.DietaryProfile{Diets: []string{}, Allergens: []string{"dairy", "gluten"}}
+	if err != nil || !reflect.DeepEqual(found.Dietary, expected) {
+		t.Errorf("Got profile = %v, error = %v but wanted %v", found.Dietary, err, expected)
+	}
+
+	found, _ = repository.FindRecipeById(int(pesto.ID))
+	expected = &recipes.DietaryProfile{Diets: []string{recipes.DietVegan, recipes.DietVegetarian, recipes.DietGlutenFree},
+		Allergens: []string{"tree-nut"}, Unknown: []string{"saffron"}}
+	if !reflect.DeepEqual(found.Dietary, expected) {
+		t.Errorf("Got profile = %v but wanted %v", found.Dietary, expected)
+	}
+
+	titles := func(filter recipes.SearchFilter) []string {
+		results, _, err := repository.FindRecipes(filter, searchAll)
+		if err != nil {
+			t.Fatal("unexpected error on search", err)
+		}
+
+		var titles []string
+		for _, result := range results {
+			titles = append(titles, result.Title)
+		}
+		return titles
+	}
+
+	if got := titles(recipes.SearchFilter{Diets: []string{recipes.DietVegan}}); !reflect.DeepEqual(got, []string{"Pesto", "Tomato salad"}) {
+		t.Errorf("Got %v for vegan recipes", got)
+	}
+
+	if got := titles(recipes.SearchFilter{ExcludedAllergens: []string{"dairy"}}); !reflect.DeepEqual(got, []string{"Pesto", "Tomato salad"}) {
+		t.Errorf("Got %v for recipes without dairy", got)
+	}
+
+	err = repository.SaveIngredientAttributes(&recipes.IngredientAttributes{Name: " Saffron", Vegetarian: true})
+	if err != nil {
+		t.Fatal("unexpected error on saving attributes", err)
+	}
+
+	if got := titles(recipes.SearchFilter{Diets: []string{recipes.DietVegan}}); !reflect.DeepEqual(got, []string{"Tomato salad"}) {
+		t.Errorf("Got %v for vegan recipes after classifying saffron as not vegan", got)
+	}
+
+	err = repository.SaveIngredientAttributes(&recipes.IngredientAttributes{Name: "saffron", Vegan: true, Vegetarian: true, GlutenFree: true})
+	if err != nil {
+		t.Fatal("unexpected error on saving attributes", err)
+	}
+
+	if got := titles(recipes.SearchFilter{Diets: []string{recipes.DietVegan, recipes.DietGlutenFree}}); !reflect.DeepEqual(got, []string{"Pesto", "Tomato salad"}) {
+		t.Errorf("Got %v for vegan gluten free recipes after classifying saffron", got)
+	}
+
+	if got := titles(recipes.SearchFilter{Title: "p", ExcludedAllergens: []string{"tree-nut", "peanut"}}); !reflect.DeepEqual(got, []string{"Pasta with parmesan"}) {
+		t.Errorf("Got %v for recipes without nuts", got)
+	}
+
+	err = repository.SaveIngredientAttributes(&recipes.IngredientAttributes{Name: "parmesan", Vegetarian: true, GlutenFree: true, Allergens: []string{"dairy"}})
+	if err != nil {
+		t.Fatal("unexpected error on saving attributes", err)
+	}
+
+	attributes, err := repository.FindIngredientAttributes()
+	if err != nil {
+		t.Fatal("unexpected error on listing attributes", err)
+	}
+
+	for _, ingredient := range attributes {
+		if ingredient.Name == "saffron" && (!ingredient.Vegan || len(ingredient.Allergens) != 0) ||
+			ingredient.Name == "parmesan" && (!ingredient.Vegetarian || !reflect.DeepEqual(ingredient.Allergens, []string{"dairy"})) {
+			t.Errorf("Got attributes = %v", ingredient)
+		}
+	}
+
+	if err = repository.DeleteIngredientAttributes("saffron"); err != nil {
+		t.Fatal("unexpected error on deleting attributes", err)
+	}
+
+	if err = repository.DeleteIngredientAttributes("saffron"); err != recipes.ErrIngredientNotClassified {
+		t.Errorf("Got error = %v for an ingredient which is not classified", err)
+	}
+
+	err = repository.SaveIngredientAttributes(&recipes.IngredientAttributes{Name: "anchovy", Vegetarian: true, Allergens: []string{"fish"}})
+	if apperror.KindOf(err) != apperror.KindValidation {
+		t.Errorf("Got error = %v for contradicting attributes", err)
+	}
+}
+
+func TestRecipeRepository_IngredientNutrients(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	found, err := repository.FindIngredientNutrients([]string{"egg", "saffron"})
+	expected := recipes.IngredientNutrients{Name: "egg", Per100g: recipes.Nutrients{Calories: 143, Protein: 12.6, Fat: 9.5, Carbs: 0.7}, GramsPerPiece: 50}
+	if err != nil || len(found) != 1 || !reflect.DeepEqual(found["egg"], expected) {
+		t.Errorf("Got nutrients = %v, error = %v but wanted the bundled nutrients of eggs", found, err)
+	}
+
+	saved, err := repository.SaveIngredientNutrients([]recipes.IngredientNutrients{
+		{Name: "Eggs", Per100g: recipes.Nutrients{Calories: 155, Protein: 13}, GramsPerPiece: 55},
+		{Name: "egg", Per100g: recipes.Nutrients{Calories: 1}},
+		{Name: "Saffron", Per100g: recipes.Nutrients{Calories: 310, Protein: 11.4, Fat: 5.9, Carbs: 65.4, Fibre: 3.9}},
+	})
+	if err != nil || saved != 2 {
+		t.Fatalf("Got saved = %v, error = %v but wanted 2 saved ingredients", saved, err)
+	}
+
+	found, err = repository.FindIngredientNutrients([]string{"egg", "saffron"})
+	if err != nil || found["egg"].Per100g.Calories != 155 || found["egg"].GramsPerPiece != 55 || found["saffron"].GramsPerMl != 0 ||
+		found["saffron"].Per100g.Fibre != 3.9 {
+		t.Errorf("Got nutrients = %v, error = %v after saving", found, err)
+	}
+}
+
+func TestRecipeRepository_CreateRecipeRollsBack(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	err := repository.CreateRecipe(&recipes.Recipe{
+		Title: "Duplicated ingredient",
+		Ingredients: []recipes.Ingredient{
+			{Name: "egg", Quantity: 1, Measurement: "pcs"},
+			{Name: "egg", Quantity: 2, Measurement: "pcs"},
+		},
+		Directions: "Fry",
+	})
+	if err == nil {
+		t.Fatal("expected an error for a duplicated ingredient")
+	}
+
+	for _, table := range []string{"recipes", "ingredients", "recipe_ingredients"} {
+		var count int
+		repository.QueryRow("select count(*) from " + table + ";").Scan(&count)
+		if count != 0 {
+			t.Errorf("expected no rows in %v after rollback got %v", table, count)
+		}
+	}
+}
+
+func TestRecipeRepository_UpdateAndDeleteRecipe(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	recipe := &recipes.Recipe{
+		Title:       "Pancakes",
+		Ingredients: []recipes.Ingredient{{Name: "milk", Quantity: 1, Measurement: "l"}},
+		Directions:  "Mix",
+	}
+	if err := repository.CreateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	recipe.Title = "Crepes"
+	recipe.Ingredients = []recipes.Ingredient{{Name: "water", Quantity: 2, Measurement: "l"}}
+	if err := repository.UpdateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	found, _ := repository.FindRecipeById(int(recipe.ID))
+	if found.Title != "Crepes" || len(found.Ingredients) != 1 || found.Ingredients[0].Name != "water" {
+		t.Errorf("Got recipe = %v after update", found)
+	}
+
+	if err := repository.DeleteRecipe(int(recipe.ID)); err != nil {
+		t.Fatal("unexpected error on delete", err)
+	}
+
+	found, err := repository.FindRecipeById(int(recipe.ID))
+	if found != nil || err != recipes.ErrRecipeNotFound {
+		t.Errorf("expected the recipe to be deleted got %v, %v", found, err)
+	}
+
+	recipe.Steps, recipe.Directions = nil, ""
+	if err = repository.UpdateRecipe(recipe); apperror.KindOf(err) != apperror.KindValidation {
+		t.Errorf("expected a validation error for empty steps got %v", err)
+	}
+
+	var count int
+	repository.QueryRow("select count(*) from recipe_ingredients;").Scan(&count)
+	if count != 0 {
+		t.Errorf("expected recipe ingredients to be deleted got %v", count)
+	}
+}
+
+func TestRecipeRepository_FindRecipesByTitlePaged(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	_, err := repository.Exec("insert into users(id, name, email, password)values(7, 'Chef', 'chef@test.com', 'x');")
+	if err != nil {
+		t.Fatal("cannot insert user", err)
+	}
+
+	for _, title := range []string{"Cake B", "Cake A", "Bread", "Cake C"} {
+		err := repository.CreateRecipe(&recipes.Recipe{
+			AuthorID: 7,
+			Title:    title,
+			Ingredients: []recipes.Ingredient{
+				{Name: "flour", Quantity: 1, Measurement: "kg"},
+				{Name: "sugar", Quantity: 1, Measurement: "kg"},
+			},
+			Directions: "Bake",
+		})
+		if err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	options := recipes.SearchOptions{Limit: 2, SortBy: recipes.SortByTitle}
+	page, total, err := repository.FindRecipes(recipes.SearchFilter{Title: "cake"}, options)
+	if err != nil {
+		t.Fatal("unexpected error on search", err)
+	}
+
+	if total != 3 || len(page) != 2 || page[0].Title != "Cake A" || page[1].Title != "Cake B" {
+		t.Errorf("Got first page = %v with total %v", page, total)
+	}
+
+	if page[0].Author != "Chef" || page[0].AuthorID != 7 || page[0].IngredientCount != 2 || page[0].CreatedAt.IsZero() {
+		t.Errorf("Got summary = %v", page[0])
+	}
+
+	options.Offset = 2
+	page, _, _ = repository.FindRecipes(recipes.SearchFilter{Title: "cake"}, options)
+	if len(page) != 1 || page[0].Title != "Cake C" {
+		t.Errorf("Got second page = %v", page)
+	}
+
+	options = recipes.SearchOptions{Limit: 1, SortBy: recipes.SortByCreatedAt, Descending: true}
+	page, _, _ = repository.FindRecipes(recipes.SearchFilter{Title: "cake"}, options)
+	if len(page) != 1 || page[0].Title != "Cake C" {
+		t.Errorf("Got newest recipe = %v", page)
+	}
+
+	page, total, err = repository.FindRecipes(recipes.SearchFilter{Title: "soup"}, options)
+	if err != nil || total != 0 || page == nil || len(page) != 0 {
+		t.Errorf("Got empty search = %v, %v, %v", page, total, err)
+	}
+}
+
+func TestRecipeRepository_FindRecipesByAuthor(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	for i, authorId := range []uint{7, 8, 7} {
+		err := repository.CreateRecipe(&recipes.Recipe{
+			AuthorID:    authorId,
+			Title:       "Cake " + string(rune('A'+i)),
+			Ingredients: []recipes.Ingredient{{Name: "flour", Quantity: 1, Measurement: "kg"}},
+			Directions:  "Bake",
+		})
+		if err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	page, total, err := repository.FindRecipesByAuthor(7, searchAll)
+	if err != nil || total != 2 || len(page) != 2 || page[0].Title != "Cake A" || page[1].Title != "Cake C" {
+		t.Errorf("Got recipes = %v, %v, %v of the author", page, total, err)
+	}
+
+	page, total, err = repository.FindRecipesByAuthor(9, searchAll)
+	if err != nil || total != 0 || len(page) != 0 {
+		t.Errorf("Got recipes = %v, %v, %v of an author without recipes", page, total, err)
+	}
+}
+
+func TestRecipeRepository_FindRecipesForPantry(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	for title, names := range map[string][]string{
+		"Salad":  {"Tomato", "onion"},
+		"Sauce":  {"tomato", "garlic"},
+		"Cookie": {"flour", "sugar"},
+	} {
+		recipe := &recipes.Recipe{Title: title, Directions: "Cook"}
+		for _, name := range names {
+			recipe.Ingredients = append(recipe.Ingredients, recipes.Ingredient{Name: name, Quantity: 1, Measurement: "pcs"})
+		}
+
+		if err := repository.CreateRecipe(recipe); err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	query := &recipes.PantryQuery{
+		Available: []string{"Tomatoes"},
+		Excluded:  []string{"garlic"},
+	}
+	candidates, err := repository.FindRecipesForPantry(query)
+	if err != nil {
+		t.Fatal("unexpected error on search", err)
+	}
+
+	if len(candidates) != 1 || candidates[0].Title != "Salad" {
+		t.Fatalf("Got candidates = %v but wanted only the salad", candidates)
+	}
+
+	if !reflect.DeepEqual(candidates[0].Ingredients, []string{"tomato", "onion"}) {
+		t.Errorf("Got candidate ingredients = %v", candidates[0].Ingredients)
+	}
+
+	if !reflect.DeepEqual(query.Available, []string{"tomato"}) {
+		t.Errorf("Got query = %v but wanted canonical names", query)
+	}
+}
+
+func TestRecipeRepository_FindRecipesForText(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	soup := &recipes.Recipe{
+		Title:       "Tomato soup",
+		Ingredients: []recipes.Ingredient{{Name: "tomato", Quantity: 4, Measurement: "pcs"}},
+		Directions:  "Boil tomatoes with salt",
+	}
+	cake := &recipes.Recipe{
+		Title:       "Cake",
+		Ingredients: []recipes.Ingredient{{Name: "flour", Quantity: 1, Measurement: "kg"}},
+		Directions:  "Bake",
+	}
+	for _, recipe := range []*recipes.Recipe{soup, cake} {
+		if err := repository.CreateRecipe(recipe); err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	search := func(q string) []uint {
+		query := recipes.ParseTextQuery(q)
+		candidates, documents, err := repository.FindRecipesForText(query)
+		if err != nil {
+			t.Fatal("unexpected error on search", err)
+		}
+
+		var ids []uint
+		for _, match := range recipes.MatchText(candidates, query, documents) {
+			ids = append(ids, match.ID)
+		}
+		return ids
+	}
+
+	if ids := search(`"boiled tomato"`); !reflect.DeepEqual(ids, []uint{soup.ID}) {
+		t.Errorf("Got matches = %v but wanted the soup", ids)
+	}
+
+	cake.Steps = []recipes.Step{{Text: "Bake with tomatoes"}}
+	if err := repository.UpdateRecipe(cake); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	if ids := search("tomato"); !reflect.DeepEqual(ids, []uint{soup.ID, cake.ID}) {
+		t.Errorf("Got matches = %v after update but wanted the soup and the cake", ids)
+	}
+
+	if err := repository.DeleteRecipe(int(soup.ID)); err != nil {
+		t.Fatal("unexpected error on delete", err)
+	}
+
+	if ids := search("tomato"); !reflect.DeepEqual(ids, []uint{cake.ID}) {
+		t.Errorf("Got matches = %v after delete but wanted the cake", ids)
+	}
+
+	repository.Exec("delete from recipe_terms;")
+	if indexed, err := repository.ReindexRecipes(); err != nil || indexed != 1 {
+		t.Fatalf("Got %v indexed recipes, %v", indexed, err)
+	}
+
+	if ids := search("baking"); !reflect.DeepEqual(ids, []uint{cake.ID}) {
+		t.Errorf("Got matches = %v after reindex but wanted the cake", ids)
+	}
+}
+
+func TestRecipeRepository_CanonicalIngredientNames(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	recipe := &recipes.Recipe{
+		Title: "Salsa",
+		Ingredients: []recipes.Ingredient{
+			{Name: " Tomatoes ", Quantity: 3, Measurement: "pcs"},
+			{Name: "Cilantro", Quantity: 1, Measurement: "tbsp"},
+		},
+		Directions: "Chop",
+	}
+	if err := repository.CreateRecipe(recipe); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	if recipe.Ingredients[0].Name != "tomato" || recipe.Ingredients[1].Name != "coriander leaf" {
+		t.Errorf("Got ingredients = %v but wanted canonical names", recipe.Ingredients)
+	}
+
+	tests := []struct {
+		name        string
+		ingredients []string
+		expected    int
+	}{
+		{name: "Plural", ingredients: []string{"tomatoes"}, expected: 1},
+		{name: "Alias", ingredients: []string{"Fresh  coriander"}, expected: 1},
+		{name: "Typo", ingredients: []string{"tomatto"}, expected: 1},
+		{name: "Too many typos", ingredients: []string{"potato"}, expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, total, err := repository.FindRecipes(recipes.SearchFilter{Ingredients: test.ingredients}, searchAll)
+			if err != nil || total != test.expected {
+				t.Errorf("Got %v recipes, %v but wanted %v", total, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestRecipeRepository_CanonicalizeIngredients(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	for _, statement := range []string{
+		"insert into recipes(id, title, created_at)values(1, 'Soup', '2020-01-01 00:00:00');",
+		"insert into recipes(id, title, created_at)values(2, 'Salad', '2020-01-01 00:00:00');",
+		"insert into ingredients(id, name)values(1, 'tomato'), (2, 'Tomatoes'), (3, 'Onions');",
+		"insert into recipe_ingredients(recipe_id, ingredient_id, quantity, measurement)values" +
+			"(1, 1, 1, 'pcs'), (1, 2, 2, 'pcs'), (2, 2, 3, 'pcs'), (2, 3, 1, 'pcs');",
+	} {
+		if _, err := repository.Exec(statement); err != nil {
+			t.Fatal("cannot insert test data", err)
+		}
+	}
+
+	changed, err := repository.CanonicalizeIngredients()
+	if err != nil || changed != 2 {
+		t.Fatalf("Got %v canonicalized ingredients, %v", changed, err)
+	}
+
+	soup, _ := repository.FindRecipeById(1)
+	salad, _ := repository.FindRecipeById(2)
+	if len(soup.Ingredients) != 1 || soup.Ingredients[0].Name != "tomato" || soup.Ingredients[0].Quantity != 1 {
+		t.Errorf("Got soup ingredients = %v", soup.Ingredients)
+	}
+	if len(salad.Ingredients) != 2 || salad.Ingredients[0].Name != "tomato" || salad.Ingredients[1].Name != "onion" {
+		t.Errorf("Got salad ingredients = %v", salad.Ingredients)
+	}
+}
+
+func TestRecipeRepository_RateRecipe(t *testing.T) {
+	repository := newMemoryRecipeRepository(t)
+
+	if _, err := repository.RateRecipe(recipes.Rating{RecipeID: 1, UserID: 1, Rating: 5}); err != recipes.ErrRecipeNotFound {
+		t.Errorf("expected ErrRecipeNotFound for a missing recipe got %v", err)
+	}
+
+	var created []*recipes.Recipe
+	for _, title := range []string{"Cake", "Pie", "Bread"} {
+		recipe := &recipes.Recipe{
+			Title:       title,
+			Ingredients: []recipes.Ingredient{{Name: "flour", Quantity: 1, Measurement: "kg"}},
+			Directions:  "Bake",
+		}
+		if err := repository.CreateRecipe(recipe); err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+		created = append(created, recipe)
+	}
+	cake, pie := created[0], created[1]
+
+	for _, rating := range []recipes.Rating{
+		{RecipeID: cake.ID, UserID: 1, Rating: 2},
+		{RecipeID: cake.ID, UserID: 2, Rating: 4},
+		{RecipeID: pie.ID, UserID: 1, Rating: 4},
+		{RecipeID: cake.ID, UserID: 1, Rating: 5},
+	} {
+		if _, err := repository.RateRecipe(rating); err != nil {
+			t.Fatal("unexpected error on rate", err)
+		}
+	}
+
+	if _, err := repository.RateRecipe(recipes.Rating{RecipeID: cake.ID, UserID: 3, Rating: 0}); apperror.KindOf(err) != apperror.KindValidation {
+		t.Errorf("expected a validation error for a rating out of bounds got %v", err)
+	}
+
+	found, _ := repository.FindRecipeById(int(cake.ID))
+	if found.AverageRating != 4.5 || found.RatingCount != 2 {
+		t.Errorf("Got rating summary = %v but wanted the changed rating to be counted once", found.RatingSummary)
+	}
+
+	options := recipes.SearchOptions{Limit: maxSearchLimit, SortBy: recipes.SortByRating, Descending: true}
+	results, total, err := repository.FindRecipes(recipes.SearchFilter{Ingredients: []string{"flour"}}, options)
+	if err != nil || total != 3 || results[0].ID != cake.ID || results[1].ID != pie.ID || results[2].RatingCount != 0 {
+		t.Errorf("Got results = %v, %v sorted by rating", results, err)
+	}
+
+	options.MinRating = 4.5
+	results, total, err = repository.FindRecipes(recipes.SearchFilter{Ingredients: []string{"flour"}}, options)
+	if err != nil || total != 1 || len(results) != 1 || results[0].ID != cake.ID {
+		t.Errorf("Got results = %v, %v with minimum rating", results, err)
+	}
+}
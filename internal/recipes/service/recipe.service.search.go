@@ -0,0 +1,234 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/recipes"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// searchOptions reads the limit, cursor or offset, sort and min_rating query parameters of a search request
+// Returns a validation error if any of the parameters is invalid
+func searchOptions(r *http.Request) (recipes.SearchOptions, error) {
+	query := r.URL.Query()
+	options := recipes.SearchOptions{Limit: defaultSearchLimit, SortBy: recipes.SortByTitle}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxSearchLimit {
+			return options, apperror.Field("limit", "must be a number between 1 and "+strconv.Itoa(maxSearchLimit))
+		}
+		options.Limit = value
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			return options, apperror.Field("cursor", "is not valid")
+		}
+		options.Offset = offset
+	} else if offset := query.Get("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return options, apperror.Field("offset", "must be a non-negative number")
+		}
+		options.Offset = value
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		options.Descending = strings.HasPrefix(sort, "-")
+		options.SortBy = strings.TrimPrefix(sort, "-")
+
+		if options.SortBy != recipes.SortByTitle && options.SortBy != recipes.SortByCreatedAt &&
+			options.SortBy != recipes.SortByRating {
+			return options, apperror.Field("sort", "must be one of title, created_at and rating with an optional - prefix")
+		}
+	}
+
+	if minRating := query.Get("min_rating"); minRating != "" {
+		value, err := strconv.ParseFloat(minRating, 64)
+		if err != nil || value < 0 || value > recipes.MaxRating {
+			return options, apperror.Field("min_rating", "must be a number between 0 and "+strconv.Itoa(recipes.MaxRating))
+		}
+		options.MinRating = value
+	}
+
+	return options, nil
+}
+
+// searchFilter reads the title, the comma separated ingredients, the free tags, given as repeated or comma separated
+// tag query parameters, the facet values (cuisine, course, meal_type and difficulty), the diets and the excluded
+// allergens, given like the tags as diet and exclude_allergens, from the query parameters
+// Returns a validation error if a facet value, diet or allergen is unknown or ErrEmptySearchFilter if none of them is given
+func searchFilter(r *http.Request) (recipes.SearchFilter, error) {
+	query := r.URL.Query()
+	filter := recipes.SearchFilter{Title: query.Get("title")}
+
+	if ingredients := query.Get("ingredients"); ingredients != "" {
+		filter.Ingredients = strings.Split(ingredients, ",")
+	}
+
+	filter.Tags = listParameter(query["tag"])
+
+	for _, facet := range recipes.Facets {
+		value := recipes.NormalizeTag(query.Get(facet))
+		if value == "" {
+			continue
+		}
+
+		if !recipes.IsFacetValue(facet, value) {
+			return filter, apperror.Field(facet, "must be one of "+strings.Join(recipes.FacetValues[facet], ", "))
+		}
+
+		if filter.Facets == nil {
+			filter.Facets = map[string]string{}
+		}
+		filter.Facets[facet] = value
+	}
+
+	for _, diet := range listParameter(query["diet"]) {
+		if !recipes.IsDiet(diet) {
+			return filter, apperror.Field("diet", "must be one of "+strings.Join(recipes.Diets, ", "))
+		}
+		filter.Diets = append(filter.Diets, diet)
+	}
+
+	for _, allergen := range listParameter(query["exclude_allergens"]) {
+		if !recipes.IsAllergen(allergen) {
+			return filter, apperror.Field("exclude_allergens", "must be some of "+strings.Join(recipes.Allergens, ", "))
+		}
+		filter.ExcludedAllergens = append(filter.ExcludedAllergens, allergen)
+	}
+
+	if filter.IsEmpty() {
+		return filter, recipes.ErrEmptySearchFilter
+	}
+
+	return filter, nil
+}
+
+// listParameter returns the normalized values of a query parameter which can be repeated or comma separated
+func listParameter(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = recipes.NormalizeTag(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+
+	return list
+}
+
+// writeSearchPage encodes the results as a page with a cursor pointing to the next page if there is such
+// together with the counts of the facets of all results
+func writeSearchPage(w http.ResponseWriter, results []recipes.RecipeSearchResult, total int,
+	facets map[string][]recipes.FacetCount, options recipes.SearchOptions) {
+	page := recipes.RecipeSearchPage{Items: results, Total: total, Facets: facets}
+
+	if page.Items == nil {
+		page.Items = []recipes.RecipeSearchResult{}
+	}
+
+	if next := options.Offset + len(page.Items); len(page.Items) > 0 && next < total {
+		page.NextCursor = encodeCursor(next)
+	}
+
+	json.NewEncoder(w).Encode(page)
+}
+
+// maxMissingIngredients reads how many recipe ingredients a pantry match may miss
+// from the match=all or max_missing query parameters
+// Returns a validation error if the parameters are invalid
+func maxMissingIngredients(r *http.Request) (int, error) {
+	query := r.URL.Query()
+
+	if match := query.Get("match"); match != "" {
+		if match != "all" {
+			return 0, apperror.Field("match", "must be all")
+		}
+		return 0, nil
+	}
+
+	if maxMissing := query.Get("max_missing"); maxMissing != "" {
+		value, err := strconv.Atoi(maxMissing)
+		if err != nil || value < 0 {
+			return 0, apperror.Field("max_missing", "must be a non-negative number")
+		}
+		return value, nil
+	}
+
+	return recipes.AnyMissing, nil
+}
+
+// writePantryPage encodes the page of the ranked matches selected and filtered by the options
+func writePantryPage(w http.ResponseWriter, matches []recipes.PantryMatch, options recipes.SearchOptions) {
+	rated := matches[:0]
+	for _, match := range matches {
+		if match.RatedAtLeast(options.MinRating) {
+			rated = append(rated, match)
+		}
+	}
+	matches = rated
+
+	start, end, nextCursor := pageWindow(len(matches), options)
+	json.NewEncoder(w).Encode(recipes.PantryMatchPage{Items: matches[start:end], NextCursor: nextCursor, Total: len(matches)})
+}
+
+// writeTextPage encodes the page of the full text matches selected and filtered by the options
+func writeTextPage(w http.ResponseWriter, matches []recipes.TextMatch, options recipes.SearchOptions) {
+	rated := matches[:0]
+	for _, match := range matches {
+		if match.RatedAtLeast(options.MinRating) {
+			rated = append(rated, match)
+		}
+	}
+	matches = rated
+
+	start, end, nextCursor := pageWindow(len(matches), options)
+	json.NewEncoder(w).Encode(recipes.TextMatchPage{Items: matches[start:end], NextCursor: nextCursor, Total: len(matches)})
+}
+
+// pageWindow returns the bounds of the page selected by the options out of total results ranked in memory
+// and the cursor pointing to the next page if there is such
+func pageWindow(total int, options recipes.SearchOptions) (int, int, string) {
+	if options.Offset >= total {
+		return total, total, ""
+	}
+
+	end := options.Offset + options.Limit
+	if end >= total {
+		return options.Offset, total, ""
+	}
+
+	return options.Offset, end, encodeCursor(end)
+}
+
+// encodeCursor hides the offset of the next page, so clients treat the cursor as opaque
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(string(decoded))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor offset")
+	}
+
+	return offset, nil
+}
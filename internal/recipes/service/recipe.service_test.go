@@ -6,7 +6,9 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"github.com/krasimiraMilkova/cookit/mocks"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
 	"github.com/krasimiraMilkova/cookit/pkg/recipes"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -26,35 +28,69 @@ func TestRecipeService_CreateRecipe(t *testing.T) {
 	tests := []struct {
 		name               string
 		recipe             recipes.Recipe
-		repositoryError    string
+		repositoryError    error
 		expectedStatusCode int
+		expectedFields     []string
 	}{
 		{
 			name: "Successful",
 			recipe: recipes.Recipe{
-				Title: "Test title",
+				AuthorID: 1,
+				Title:    "Test title",
 				Ingredients:
 				[]recipes.Ingredient{
 					{
 						Name:        "Test",
 						Quantity:    1,
-						Measurement: "m",
+						Measurement: "ml",
 					},
 					{
 						Name:        "Smth",
 						Quantity:    1,
-						Measurement: "m",
+						Measurement: "ml",
 					},
 				},
 				Directions: "Test directions",
 			},
-			repositoryError:    "",
 			expectedStatusCode: http.StatusCreated,
 		},
 		{
-			name:               "Repository error",
-			recipe:             recipes.Recipe{},
-			repositoryError:    "recipe cannot have empty fields",
+			name:               "Invalid recipe",
+			recipe:             recipes.Recipe{AuthorID: 1},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedFields:     []string{"title", "ingredients", "steps"},
+		},
+		{
+			name: "Invalid ingredients",
+			recipe: recipes.Recipe{
+				AuthorID:    1,
+				Title:       strings.Repeat("t", 101),
+				Ingredients: []recipes.Ingredient{{Name: "Test", Quantity: 0, Measurement: "ml"}, {Name: "Smth", Quantity: 1, Measurement: "m"}},
+				Directions:  "Test directions",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedFields:     []string{"title", "ingredients[0].quantity", "ingredients[1].measurement"},
+		},
+		{
+			name: "Invalid steps",
+			recipe: recipes.Recipe{
+				AuthorID:    1,
+				Title:       "Test title",
+				Ingredients: []recipes.Ingredient{{Name: "Test", Quantity: 1, Measurement: "pcs"}},
+				Steps:       []recipes.Step{{Text: "Boil", Kind: "boil"}, {Text: "Serve", DurationMinutes: 100000}},
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedFields:     []string{"steps[0].kind", "steps[1].duration_minutes"},
+		},
+		{
+			name: "Repository error",
+			recipe: recipes.Recipe{
+				AuthorID:    1,
+				Title:       "Test title",
+				Ingredients: []recipes.Ingredient{{Name: "Test", Quantity: 1, Measurement: "pcs"}},
+				Directions:  "Test directions",
+			},
+			repositoryError:    errors.New("some repo error"),
 			expectedStatusCode: http.StatusInternalServerError,
 		},
 	}
@@ -63,13 +99,16 @@ func TestRecipeService_CreateRecipe(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			jsonRecipe, _ := json.Marshal(test.recipe)
 			req, _ := http.NewRequest("POST", "/recipe", strings.NewReader(string(jsonRecipe)))
+			req = withUser(req, 1)
 			rr := httptest.NewRecorder()
 
-			var err error
-			if test.repositoryError != "" {
-				err = errors.New(test.repositoryError)
+			// invalid recipes are rejected before they reach the repository
+			if test.expectedFields == nil {
+				// free text directions are split into steps when the recipe is decoded
+				expectedRecipe := test.recipe
+				expectedRecipe.Normalize()
+				mockRepository.EXPECT().CreateRecipe(&expectedRecipe).Return(test.repositoryError)
 			}
-			mockRepository.EXPECT().CreateRecipe(&test.recipe).Return(err)
 			http.HandlerFunc(service.CreateRecipe).ServeHTTP(rr, req)
 
 			if status := rr.Code; status != test.expectedStatusCode {
@@ -77,11 +116,15 @@ func TestRecipeService_CreateRecipe(t *testing.T) {
 					status, test.expectedStatusCode)
 				t.Fail()
 			}
+
+			if test.expectedStatusCode != http.StatusCreated {
+				expectProblem(t, rr, test.expectedStatusCode, test.expectedFields)
+			}
 		})
 	}
 }
 
-func TestRecipeService_FindRecipesByTitle(t *testing.T) {
+func TestRecipeService_FindRecipes(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
@@ -89,18 +132,24 @@ func TestRecipeService_FindRecipesByTitle(t *testing.T) {
 
 	service := RecipeService{RecipeRepository: mockRepository}
 
+	defaultOptions := recipes.SearchOptions{Limit: defaultSearchLimit, SortBy: recipes.SortByTitle}
+
 	tests := []struct {
 		name               string
 		title              string
+		query              string
+		options            recipes.SearchOptions
 		searchResults      []recipes.RecipeSearchResult
+		total              int
 		repositoryError    string
+		expectedCursor     string
 		expectedStatusCode int
 	}{
 		{
-			name:  "Successful",
-			title: "test",
-			searchResults:
-			[]recipes.RecipeSearchResult{
+			name:    "Successful",
+			title:   "test",
+			options: defaultOptions,
+			searchResults: []recipes.RecipeSearchResult{
 				{
 					ID:    0,
 					Title: "Test recipe",
@@ -110,6 +159,7 @@ func TestRecipeService_FindRecipesByTitle(t *testing.T) {
 					Title: "Smth test",
 				},
 			},
+			total:              2,
 			repositoryError:    "",
 			expectedStatusCode: http.StatusOK,
 		},
@@ -123,6 +173,7 @@ func TestRecipeService_FindRecipesByTitle(t *testing.T) {
 		{
 			name:               "Repository returns error",
 			title:              "Some title",
+			options:            defaultOptions,
 			searchResults:      []recipes.RecipeSearchResult{},
 			repositoryError:    "some repo error",
 			expectedStatusCode: http.StatusInternalServerError,
@@ -130,15 +181,77 @@ func TestRecipeService_FindRecipesByTitle(t *testing.T) {
 		{
 			name:               "Repository returns no results",
 			title:              "Some title",
-			searchResults:      []recipes.RecipeSearchResult{},
+			options:            defaultOptions,
+			searchResults:      nil,
 			repositoryError:    "",
-			expectedStatusCode: http.StatusNotFound,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "First page",
+			title:              "test",
+			query:              "&limit=1&sort=-created_at",
+			options:            recipes.SearchOptions{Limit: 1, SortBy: recipes.SortByCreatedAt, Descending: true},
+			searchResults:      []recipes.RecipeSearchResult{{ID: 1, Title: "Test"}},
+			total:              3,
+			expectedCursor:     encodeCursor(1),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Page from cursor",
+			title:              "test",
+			query:              "&limit=1&cursor=" + encodeCursor(2),
+			options:            recipes.SearchOptions{Limit: 1, Offset: 2, SortBy: recipes.SortByTitle},
+			searchResults:      []recipes.RecipeSearchResult{{ID: 3, Title: "Test"}},
+			total:              3,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Page from offset",
+			title:              "test",
+			query:              "&offset=20",
+			options:            recipes.SearchOptions{Limit: defaultSearchLimit, Offset: 20, SortBy: recipes.SortByTitle},
+			searchResults:      []recipes.RecipeSearchResult{},
+			total:              3,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid limit",
+			title:              "test",
+			query:              "&limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid cursor",
+			title:              "test",
+			query:              "&cursor=abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unsupported sort",
+			title:              "test",
+			query:              "&sort=directions",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Best rated",
+			title:              "test",
+			query:              "&sort=-rating&min_rating=3.5",
+			options:            recipes.SearchOptions{Limit: defaultSearchLimit, SortBy: recipes.SortByRating, Descending: true, MinRating: 3.5},
+			searchResults:      []recipes.RecipeSearchResult{{ID: 1, Title: "Test"}},
+			total:              1,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid min rating",
+			title:              "test",
+			query:              "&min_rating=6",
+			expectedStatusCode: http.StatusBadRequest,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest("GET", "/recipe?title="+test.title, nil)
+			req, _ := http.NewRequest("GET", "/recipe?title="+test.title+test.query, nil)
 			rr := httptest.NewRecorder()
 
 			var err error
@@ -146,10 +259,14 @@ func TestRecipeService_FindRecipesByTitle(t *testing.T) {
 				err = errors.New(test.repositoryError)
 			}
 			if test.expectedStatusCode != http.StatusBadRequest {
-				mockRepository.EXPECT().FindRecipesByTitle(test.title).Return(test.searchResults, err)
+				filter := recipes.SearchFilter{Title: test.title}
+				mockRepository.EXPECT().FindRecipes(filter, test.options).Return(test.searchResults, test.total, err)
+				if err == nil {
+					mockRepository.EXPECT().CountFacets(filter, test.options).Return(nil, nil)
+				}
 			}
 
-			http.HandlerFunc(service.FindRecipesByTitle).ServeHTTP(rr, req)
+			http.HandlerFunc(service.FindRecipes).ServeHTTP(rr, req)
 
 			if status := rr.Code; status != test.expectedStatusCode {
 				t.Errorf("handler returned wrong status code: got %v want %v",
@@ -157,26 +274,14 @@ func TestRecipeService_FindRecipesByTitle(t *testing.T) {
 				t.Fail()
 			}
 
-			var searchResults []recipes.RecipeSearchResult
-			s := rr.Body.String()
-			if s != "" {
-				err = json.Unmarshal([]byte(s), &searchResults)
-
-				if err != nil {
-					t.Error("error from unmarshal", err)
-				}
-			}
-
-			if len(searchResults) > 0 && !reflect.DeepEqual(searchResults, test.searchResults) {
-				t.Errorf("Got searchResults = %v but wanted %v", searchResults, test.searchResults)
-			} else if len(test.searchResults) == 0 && len(searchResults) != 0 {
-				t.Errorf("Expected [] result but got %v", searchResults)
+			if test.expectedStatusCode == http.StatusOK {
+				expectSearchPage(t, rr.Body.String(), test.searchResults, test.total, test.expectedCursor)
 			}
 		})
 	}
 }
 
-func TestRecipeService_FindRecipesByIngredients(t *testing.T) {
+func TestRecipeService_FindRecipesByFilters(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
@@ -184,18 +289,26 @@ func TestRecipeService_FindRecipesByIngredients(t *testing.T) {
 
 	service := RecipeService{RecipeRepository: mockRepository}
 
+	defaultOptions := recipes.SearchOptions{Limit: defaultSearchLimit, SortBy: recipes.SortByTitle}
+
+	facets := map[string][]recipes.FacetCount{
+		recipes.FacetCuisine: {{Value: "italian", Count: 1}},
+		recipes.FacetTag:     {{Value: "vegan", Count: 1}},
+	}
+
 	tests := []struct {
 		name               string
-		ingredients        string
+		query              string
+		filter             recipes.SearchFilter
 		searchResults      []recipes.RecipeSearchResult
 		repositoryError    string
 		expectedStatusCode int
 	}{
 		{
-			name:        "Successful",
-			ingredients: "test,smth",
-			searchResults:
-			[]recipes.RecipeSearchResult{
+			name:   "Successful",
+			query:  "ingredients=test,smth",
+			filter: recipes.SearchFilter{Ingredients: []string{"test", "smth"}},
+			searchResults: []recipes.RecipeSearchResult{
 				{
 					ID:    0,
 					Title: "Test recipe",
@@ -206,30 +319,79 @@ func TestRecipeService_FindRecipesByIngredients(t *testing.T) {
 		},
 		{
 			name:               "Empty search parameter - ingredients",
-			ingredients:        "",
+			query:              "ingredients=",
 			searchResults:      []recipes.RecipeSearchResult{},
 			repositoryError:    "",
 			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:               "Repository returns error",
-			ingredients:        "test,smth",
+			query:              "ingredients=test,smth",
+			filter:             recipes.SearchFilter{Ingredients: []string{"test", "smth"}},
 			searchResults:      []recipes.RecipeSearchResult{},
 			repositoryError:    "some repo error",
 			expectedStatusCode: http.StatusInternalServerError,
 		},
 		{
 			name:               "Repository returns no results",
-			ingredients:        "test",
+			query:              "ingredients=test",
+			filter:             recipes.SearchFilter{Ingredients: []string{"test"}},
 			searchResults:      []recipes.RecipeSearchResult{},
 			repositoryError:    "",
-			expectedStatusCode: http.StatusNotFound,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:  "Tags and facets",
+			query: "tag=vegan&tag=Gluten%20Free,quick&cuisine=Italian&difficulty=easy",
+			filter: recipes.SearchFilter{
+				Tags:   []string{"vegan", "gluten-free", "quick"},
+				Facets: map[string]string{recipes.FacetCuisine: "italian", recipes.FacetDifficulty: "easy"},
+			},
+			searchResults:      []recipes.RecipeSearchResult{{ID: 1, Title: "Pasta"}},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "Facets combined with title and ingredients",
+			query:  "title=pasta&ingredients=basil&course=main",
+			filter: recipes.SearchFilter{Title: "pasta", Ingredients: []string{"basil"}, Facets: map[string]string{recipes.FacetCourse: "main"}},
+			searchResults:      []recipes.RecipeSearchResult{{ID: 1, Title: "Pasta"}},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:  "Diets and excluded allergens",
+			query: "diet=vegan,Gluten%20Free&exclude_allergens=peanut&exclude_allergens=tree-nut",
+			filter: recipes.SearchFilter{
+				Diets:             []string{recipes.DietVegan, recipes.DietGlutenFree},
+				ExcludedAllergens: []string{"peanut", "tree-nut"},
+			},
+			searchResults:      []recipes.RecipeSearchResult{{ID: 1, Title: "Salad"}},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Unknown diet",
+			query:              "diet=paleo",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown allergen",
+			query:              "tag=vegan&exclude_allergens=nuts",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown facet value",
+			query:              "tag=vegan&cuisine=martian",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "No filters",
+			query:              "tag=%20&limit=5",
+			expectedStatusCode: http.StatusBadRequest,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest("GET", "/recipe?ingredients="+test.ingredients, nil)
+			req, _ := http.NewRequest("GET", "/recipe?"+test.query, nil)
 			rr := httptest.NewRecorder()
 
 			var err error
@@ -237,11 +399,14 @@ func TestRecipeService_FindRecipesByIngredients(t *testing.T) {
 				err = errors.New(test.repositoryError)
 			}
 			if test.expectedStatusCode != http.StatusBadRequest {
-				ingredientsList := strings.Split(test.ingredients, ",")
-				mockRepository.EXPECT().FindRecipesByIngredients(ingredientsList).Return(test.searchResults, err)
+				mockRepository.EXPECT().FindRecipes(test.filter, defaultOptions).
+					Return(test.searchResults, len(test.searchResults), err)
+				if err == nil {
+					mockRepository.EXPECT().CountFacets(test.filter, defaultOptions).Return(facets, nil)
+				}
 			}
 
-			http.HandlerFunc(service.FindRecipesByIngredients).ServeHTTP(rr, req)
+			http.HandlerFunc(service.FindRecipes).ServeHTTP(rr, req)
 
 			if status := rr.Code; status != test.expectedStatusCode {
 				t.Errorf("handler returned wrong status code: got %v want %v",
@@ -249,26 +414,134 @@ func TestRecipeService_FindRecipesByIngredients(t *testing.T) {
 				t.Fail()
 			}
 
-			var searchResults []recipes.RecipeSearchResult
-			s := rr.Body.String()
-			if s != "" {
-				err = json.Unmarshal([]byte(s), &searchResults)
+			if test.expectedStatusCode == http.StatusOK {
+				expectSearchPage(t, rr.Body.String(), test.searchResults, len(test.searchResults), "")
 
-				if err != nil {
-					t.Error("error from unmarshal", err)
+				var page recipes.RecipeSearchPage
+				json.Unmarshal(rr.Body.Bytes(), &page)
+				if !reflect.DeepEqual(page.Facets, facets) {
+					t.Errorf("Got facets = %v but wanted %v", page.Facets, facets)
 				}
 			}
+		})
+	}
+}
+
+func TestRecipeService_FindRecipesByPantry(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	candidates := []recipes.RecipeIngredients{
+		{
+			RecipeSearchResult: recipes.RecipeSearchResult{ID: 1, Title: "Salad", RatingSummary: recipes.RatingSummary{AverageRating: 4.5, RatingCount: 2}},
+			Ingredients:        []string{"tomato", "oil"},
+		},
+		{RecipeSearchResult: recipes.RecipeSearchResult{ID: 2, Title: "Soup"}, Ingredients: []string{"tomato"}},
+	}
+
+	tests := []struct {
+		name               string
+		query              string
+		pantryQuery        recipes.PantryQuery
+		repositoryError    string
+		expectedIds        []uint
+		expectedCursor     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			query:              "pantry=tomato,-garlic",
+			pantryQuery:        recipes.PantryQuery{Available: []string{"tomato"}, Excluded: []string{"garlic"}, MaxMissing: recipes.AnyMissing},
+			expectedIds:        []uint{2, 1},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Require all ingredients",
+			query:              "pantry=tomato&match=all",
+			pantryQuery:        recipes.PantryQuery{Available: []string{"tomato"}, MaxMissing: 0},
+			expectedIds:        []uint{2},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Paged",
+			query:              "pantry=tomato&max_missing=1&limit=1",
+			pantryQuery:        recipes.PantryQuery{Available: []string{"tomato"}, MaxMissing: 1},
+			expectedIds:        []uint{2},
+			expectedCursor:     encodeCursor(1),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Minimum rating",
+			query:              "pantry=tomato&min_rating=4",
+			pantryQuery:        recipes.PantryQuery{Available: []string{"tomato"}, MaxMissing: recipes.AnyMissing},
+			expectedIds:        []uint{1},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Only excluded ingredients",
+			query:              "pantry=-garlic",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid max missing",
+			query:              "pantry=tomato&max_missing=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repository returns error",
+			query:              "pantry=tomato",
+			pantryQuery:        recipes.PantryQuery{Available: []string{"tomato"}, MaxMissing: recipes.AnyMissing},
+			repositoryError:    "some repo error",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/recipe?"+test.query, nil)
+			rr := httptest.NewRecorder()
+
+			var err error
+			if test.repositoryError != "" {
+				err = errors.New(test.repositoryError)
+			}
+			if test.expectedStatusCode != http.StatusBadRequest {
+				mockRepository.EXPECT().FindRecipesForPantry(&test.pantryQuery).Return(candidates, err)
+			}
+
+			http.HandlerFunc(service.FindRecipesByPantry).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				var page recipes.PantryMatchPage
+				if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+					t.Error("error from unmarshal", err)
+				}
 
-			if len(searchResults) > 0 && !reflect.DeepEqual(searchResults, test.searchResults) {
-				t.Errorf("Got searchResults = %v but wanted %v", searchResults, test.searchResults)
-			} else if len(test.searchResults) == 0 && len(searchResults) != 0 {
-				t.Errorf("Expected [] result but got %v", searchResults)
+				var ids []uint
+				for _, match := range page.Items {
+					ids = append(ids, match.ID)
+				}
+
+				if !reflect.DeepEqual(ids, test.expectedIds) || page.NextCursor != test.expectedCursor {
+					t.Errorf("Got matches = %v with cursor %v but wanted %v with cursor %v",
+						ids, page.NextCursor, test.expectedIds, test.expectedCursor)
+				}
 			}
 		})
 	}
 }
 
-func TestRecipeService_FindRecipeById(t *testing.T) {
+func TestRecipeService_FindRecipesByText(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
@@ -276,54 +549,59 @@ func TestRecipeService_FindRecipeById(t *testing.T) {
 
 	service := RecipeService{RecipeRepository: mockRepository}
 
+	candidates := []recipes.RecipeTerms{
+		{
+			RecipeSearchResult: recipes.RecipeSearchResult{ID: 1, Title: "Bread"},
+			Terms:              []recipes.SearchTerm{{Field: recipes.FieldDirections, Term: "tomato"}},
+		},
+		{
+			RecipeSearchResult: recipes.RecipeSearchResult{ID: 2, Title: "Tomato soup"},
+			Terms:              []recipes.SearchTerm{{Field: recipes.FieldTitle, Term: "tomato"}},
+		},
+	}
+
 	tests := []struct {
 		name               string
-		id                 string
-		recipe             recipes.Recipe
+		query              string
 		repositoryError    string
+		expectedIds        []uint
+		expectedCursor     string
 		expectedStatusCode int
 	}{
 		{
-			name: "Successful",
-			id:   "1",
-			recipe: recipes.Recipe{
-				ID:    1,
-				Title: "Test title",
-				Ingredients:
-				[]recipes.Ingredient{
-					{
-						Name:        "Test",
-						Quantity:    1,
-						Measurement: "m",
-					},
-				},
-				Directions: "Test directions",
-			},
-			repositoryError:    "",
+			name:               "Successful",
+			query:              "q=tomatoes",
+			expectedIds:        []uint{2, 1},
 			expectedStatusCode: http.StatusOK,
 		},
 		{
-			name:               "Cannot parse id to number",
-			id:                 "a",
-			recipe:             recipes.Recipe{},
-			repositoryError:    "",
+			name:               "Paged",
+			query:              "q=tomatoes&limit=1",
+			expectedIds:        []uint{2},
+			expectedCursor:     encodeCursor(1),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Only stop words",
+			query:              "q=the",
 			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
-			name:               "Repository returns no results",
-			id:                 "2",
-			recipe:             recipes.Recipe{},
-			repositoryError:    "",
-			expectedStatusCode: http.StatusNotFound,
+			name:               "Invalid limit",
+			query:              "q=tomato&limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repository returns error",
+			query:              "q=tomato",
+			repositoryError:    "some repo error",
+			expectedStatusCode: http.StatusInternalServerError,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			req, _ := http.NewRequest("GET", "/recipe", nil)
-			req = mux.SetURLVars(req, map[string]string{
-				"id": test.id,
-			})
+			req, _ := http.NewRequest("GET", "/recipe/search?"+test.query, nil)
 			rr := httptest.NewRecorder()
 
 			var err error
@@ -331,15 +609,10 @@ func TestRecipeService_FindRecipeById(t *testing.T) {
 				err = errors.New(test.repositoryError)
 			}
 			if test.expectedStatusCode != http.StatusBadRequest {
-				id, _ := strconv.Atoi(test.id)
-				if test.expectedStatusCode == http.StatusOK {
-					mockRepository.EXPECT().FindRecipeById(id).Return(&test.recipe, err)
-				} else {
-					mockRepository.EXPECT().FindRecipeById(id).Return(nil, err)
-				}
+				mockRepository.EXPECT().FindRecipesForText(recipes.TextQuery{Terms: []string{"tomato"}}).Return(candidates, 2, err)
 			}
 
-			http.HandlerFunc(service.FindRecipeById).ServeHTTP(rr, req)
+			http.HandlerFunc(service.FindRecipesByText).ServeHTTP(rr, req)
 
 			if status := rr.Code; status != test.expectedStatusCode {
 				t.Errorf("handler returned wrong status code: got %v want %v",
@@ -347,19 +620,935 @@ func TestRecipeService_FindRecipeById(t *testing.T) {
 				t.Fail()
 			}
 
-			var recipe recipes.Recipe
-			s := rr.Body.String()
-			if s != "" {
-				err = json.Unmarshal([]byte(s), &recipe)
-
-				if err != nil {
+			if test.expectedStatusCode == http.StatusOK {
+				var page recipes.TextMatchPage
+				if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
 					t.Error("error from unmarshal", err)
 				}
-			}
 
-			if !reflect.DeepEqual(recipe, test.recipe) {
-				t.Errorf("Got recipe = %v but wanted %v", recipe, test.recipe)
+				var ids []uint
+				for _, match := range page.Items {
+					ids = append(ids, match.ID)
+				}
+
+				if !reflect.DeepEqual(ids, test.expectedIds) || page.NextCursor != test.expectedCursor || page.Total != 2 {
+					t.Errorf("Got matches = %v with cursor %v but wanted %v with cursor %v",
+						ids, page.NextCursor, test.expectedIds, test.expectedCursor)
+				}
 			}
 		})
 	}
 }
+
+func TestRecipeService_RateRecipe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	summary := recipes.RatingSummary{AverageRating: 4.5, RatingCount: 2}
+
+	tests := []struct {
+		name               string
+		id                 string
+		payload            string
+		withoutUser        bool
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			id:                 "1",
+			payload:            `{"rating": 5}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Missing user",
+			id:                 "1",
+			payload:            `{"rating": 5}`,
+			withoutUser:        true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Cannot parse id",
+			id:                 "a",
+			payload:            `{"rating": 5}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Rating out of bounds",
+			id:                 "1",
+			payload:            `{"rating": 6}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Recipe not found",
+			id:                 "2",
+			payload:            `{"rating": 5}`,
+			repositoryError:    recipes.ErrRecipeNotFound,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Repository error",
+			id:                 "1",
+			payload:            `{"rating": 5}`,
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PUT", "/recipe/"+test.id+"/rating", strings.NewReader(test.payload))
+			if !test.withoutUser {
+				req = withUser(req, 3)
+			}
+			req = mux.SetURLVars(req, map[string]string{
+				"id": test.id,
+			})
+			rr := httptest.NewRecorder()
+
+			if test.expectedStatusCode != http.StatusBadRequest && test.expectedStatusCode != http.StatusForbidden {
+				id, _ := strconv.Atoi(test.id)
+				rating := recipes.Rating{RecipeID: uint(id), UserID: 3, Rating: 5}
+				mockRepository.EXPECT().RateRecipe(rating).Return(summary, test.repositoryError)
+			}
+
+			http.HandlerFunc(service.RateRecipe).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				var result recipes.RatingResult
+				json.Unmarshal(rr.Body.Bytes(), &result)
+
+				expected := recipes.RatingResult{Rating: recipes.Rating{RecipeID: 1, UserID: 3, Rating: 5}, RatingSummary: summary}
+				if result != expected {
+					t.Errorf("Got rating = %v but wanted %v", result, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRecipeService_FindRecipeById(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	tests := []struct {
+		name               string
+		id                 string
+		units              string
+		servings           string
+		recipe             recipes.Recipe
+		expectedRecipe     *recipes.Recipe
+		repositoryError    string
+		expectedStatusCode int
+	}{
+		{
+			name: "Successful",
+			id:   "1",
+			recipe: recipes.Recipe{
+				ID:    1,
+				Title: "Test title",
+				Ingredients:
+				[]recipes.Ingredient{
+					{
+						Name:        "Test",
+						Quantity:    1,
+						Measurement: "ml",
+					},
+				},
+				Directions: "Test directions",
+			},
+			repositoryError:    "",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:  "Converted to imperial units",
+			id:    "3",
+			units: "imperial",
+			recipe: recipes.Recipe{
+				ID:    3,
+				Title: "Pancakes",
+				Ingredients: []recipes.Ingredient{
+					{Name: "Milk", Quantity: 250, Measurement: "ml"},
+					{Name: "Flour", Quantity: 500, Measurement: "g"},
+					{Name: "Sugar", Quantity: 0.25, Measurement: "cup"},
+					{Name: "Egg", Quantity: 2, Measurement: "pcs"},
+				},
+				Steps: []recipes.Step{{Text: "Mix and fry", Temperature: &recipes.Temperature{Degrees: 180, Unit: "°C"}}},
+			},
+			expectedRecipe: &recipes.Recipe{
+				ID:    3,
+				Title: "Pancakes",
+				Ingredients: []recipes.Ingredient{
+					{Name: "Milk", Quantity: 1.06, Measurement: "cup"},
+					{Name: "Flour", Quantity: 1.1, Measurement: "lb"},
+					{Name: "Sugar", Quantity: 0.25, Measurement: "cup"},
+					{Name: "Egg", Quantity: 2, Measurement: "pcs"},
+				},
+				Steps: []recipes.Step{{Text: "Mix and fry", Temperature: &recipes.Temperature{Degrees: 356, Unit: "°F"}}},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:     "Scaled to more servings",
+			id:       "4",
+			servings: "16",
+			recipe: recipes.Recipe{
+				ID:       4,
+				Title:    "Cake",
+				Servings: 4,
+				Ingredients: []recipes.Ingredient{
+					{Name: "Milk", Quantity: 250, Measurement: "ml"},
+					{Name: "Flour", Quantity: 300, Measurement: "g"},
+					{Name: "Sugar", Quantity: 12, Measurement: "tsp"},
+					{Name: "Egg", Quantity: 2, Measurement: "pcs"},
+					{Name: "Oven", Quantity: 180, Measurement: "°C"},
+				},
+				Directions: "Mix and bake",
+			},
+			expectedRecipe: &recipes.Recipe{
+				ID:       4,
+				Title:    "Cake",
+				Servings: 16,
+				Ingredients: []recipes.Ingredient{
+					{Name: "Milk", Quantity: 1, Measurement: "l"},
+					{Name: "Flour", Quantity: 1.2, Measurement: "kg"},
+					{Name: "Sugar", Quantity: 1, Measurement: "cup"},
+					{Name: "Egg", Quantity: 8, Measurement: "pcs"},
+					{Name: "Oven", Quantity: 180, Measurement: "°C"},
+				},
+				Directions: "Mix and bake",
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:     "Scaled to fewer servings",
+			id:       "5",
+			servings: "1",
+			recipe: recipes.Recipe{
+				ID:          5,
+				Title:       "Omelette",
+				Servings:    3,
+				Ingredients: []recipes.Ingredient{{Name: "Butter", Quantity: 1, Measurement: "tbsp"}},
+				Directions:  "Fry",
+			},
+			expectedRecipe: &recipes.Recipe{
+				ID:          5,
+				Title:       "Omelette",
+				Servings:    1,
+				Ingredients: []recipes.Ingredient{{Name: "Butter", Quantity: 1, Measurement: "tsp"}},
+				Directions:  "Fry",
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Servings of the recipe are unknown",
+			id:                 "6",
+			servings:           "2",
+			recipe:             recipes.Recipe{ID: 6, Title: "Soup", Directions: "Boil"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:               "Invalid servings",
+			id:                 "1",
+			servings:           "0",
+			recipe:             recipes.Recipe{},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown unit system",
+			id:                 "1",
+			units:              "nautical",
+			recipe:             recipes.Recipe{},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Cannot parse id to number",
+			id:                 "a",
+			recipe:             recipes.Recipe{},
+			repositoryError:    "",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repository returns no results",
+			id:                 "2",
+			recipe:             recipes.Recipe{},
+			repositoryError:    "",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/recipe?units="+test.units+"&servings="+test.servings, nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"id": test.id,
+			})
+			rr := httptest.NewRecorder()
+
+			var err error
+			if test.repositoryError != "" {
+				err = errors.New(test.repositoryError)
+			}
+			expectedRecipe := test.recipe
+			if test.expectedRecipe != nil {
+				expectedRecipe = *test.expectedRecipe
+			}
+			expectedRecipe.Normalize()
+
+			if test.expectedStatusCode != http.StatusBadRequest {
+				id, _ := strconv.Atoi(test.id)
+				if test.expectedStatusCode == http.StatusOK || test.expectedStatusCode == http.StatusConflict {
+					found := test.recipe
+					mockRepository.EXPECT().FindRecipeById(id).Return(&found, err)
+				} else {
+					mockRepository.EXPECT().FindRecipeById(id).Return(nil, recipes.ErrRecipeNotFound)
+				}
+			}
+
+			http.HandlerFunc(service.FindRecipeById).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+
+			var recipe recipes.Recipe
+			s := rr.Body.String()
+			if rr.Code == http.StatusOK {
+				err = json.Unmarshal([]byte(s), &recipe)
+
+				if err != nil {
+					t.Error("error from unmarshal", err)
+				}
+			}
+
+			if rr.Code == http.StatusOK && !reflect.DeepEqual(recipe, expectedRecipe) {
+				t.Errorf("Got recipe = %v but wanted %v", recipe, expectedRecipe)
+			}
+		})
+	}
+}
+
+func TestRecipeService_UpdateRecipe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	existing := recipes.Recipe{
+		ID:          1,
+		AuthorID:    1,
+		Title:       "Old title",
+		Ingredients: []recipes.Ingredient{{Name: "Old", Quantity: 1, Measurement: "ml"}},
+		Directions:  "Old directions",
+	}
+
+	tests := []struct {
+		name               string
+		id                 string
+		userId             uint
+		role               users.Role
+		body               string
+		found              bool
+		expectedRecipe     recipes.Recipe
+		repositoryError    string
+		expectedStatusCode int
+	}{
+		{
+			name:   "Successful",
+			id:     "1",
+			userId: 1,
+			body:   `{"title":"New title","ingredients":[{"name":"New","quantity":2,"measurement":"g"}],"directions":"New directions"}`,
+			found:  true,
+			expectedRecipe: recipes.Recipe{
+				ID:          1,
+				AuthorID:    1,
+				Title:       "New title",
+				Ingredients: []recipes.Ingredient{{Name: "New", Quantity: 2, Measurement: "g"}},
+				Directions:  "New directions",
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Cannot parse id",
+			id:                 "a",
+			userId:             1,
+			body:               `{}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Recipe not found",
+			id:                 "2",
+			userId:             1,
+			body:               `{}`,
+			found:              false,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Not the author",
+			id:                 "1",
+			userId:             2,
+			body:               `{}`,
+			found:              true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:   "Admin edits recipe of another user",
+			id:     "1",
+			userId: 2,
+			role:   users.RoleAdmin,
+			body:   `{"title":"New title","ingredients":[{"name":"New","quantity":1,"measurement":"pcs"}],"directions":"New directions"}`,
+			found:  true,
+			expectedRecipe: recipes.Recipe{
+				ID:          1,
+				AuthorID:    1,
+				Title:       "New title",
+				Ingredients: []recipes.Ingredient{{Name: "New", Quantity: 1, Measurement: "pcs"}},
+				Directions:  "New directions",
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Moderator cannot edit recipe of another user",
+			id:                 "1",
+			userId:             2,
+			role:               users.RoleModerator,
+			body:               `{}`,
+			found:              true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Cannot decode payload",
+			id:                 "1",
+			userId:             1,
+			body:               `{"title":`,
+			found:              true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid recipe",
+			id:                 "1",
+			userId:             1,
+			body:               `{"title":""}`,
+			found:              true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Repository error",
+			id:     "1",
+			userId: 1,
+			body:   `{"title":"New title","ingredients":[{"name":"New","quantity":1,"measurement":"pcs"}],"directions":"New directions"}`,
+			found:  true,
+			expectedRecipe: recipes.Recipe{
+				ID:          1,
+				AuthorID:    1,
+				Title:       "New title",
+				Ingredients: []recipes.Ingredient{{Name: "New", Quantity: 1, Measurement: "pcs"}},
+				Directions:  "New directions",
+			},
+			repositoryError:    "some repo error",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PUT", "/recipe/"+test.id, strings.NewReader(test.body))
+			req = mux.SetURLVars(withRole(req, test.userId, test.role), map[string]string{
+				"id": test.id,
+			})
+			rr := httptest.NewRecorder()
+
+			expectFindRecipe(mockRepository, test.id, test.found, existing)
+			test.expectedRecipe.Normalize()
+			if test.expectedRecipe.ID != 0 {
+				var err error
+				if test.repositoryError != "" {
+					err = errors.New(test.repositoryError)
+				}
+				mockRepository.EXPECT().UpdateRecipe(&test.expectedRecipe).Return(err)
+			}
+
+			http.HandlerFunc(service.UpdateRecipe).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRecipeService_PatchRecipe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	existing := recipes.Recipe{
+		ID:          1,
+		AuthorID:    1,
+		Title:       "Old title",
+		Ingredients: []recipes.Ingredient{{Name: "Old", Quantity: 1, Measurement: "ml"}},
+		Directions:  "Old directions",
+	}
+
+	tests := []struct {
+		name               string
+		userId             uint
+		body               string
+		expectedRecipe     recipes.Recipe
+		expectedStatusCode int
+	}{
+		{
+			name:   "Patch title",
+			userId: 1,
+			body:   `{"title":"New title"}`,
+			expectedRecipe: recipes.Recipe{
+				ID:          1,
+				AuthorID:    1,
+				Title:       "New title",
+				Ingredients: existing.Ingredients,
+				Directions:  "Old directions",
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "Patch ingredients",
+			userId: 1,
+			body:   `{"ingredients":[{"name":"New","quantity":3,"measurement":"g"}]}`,
+			expectedRecipe: recipes.Recipe{
+				ID:          1,
+				AuthorID:    1,
+				Title:       "Old title",
+				Ingredients: []recipes.Ingredient{{Name: "New", Quantity: 3, Measurement: "g"}},
+				Directions:  "Old directions",
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Patch leaves recipe invalid",
+			userId:             1,
+			body:               `{"directions":""}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Not the author",
+			userId:             2,
+			body:               `{"title":"New title"}`,
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PATCH", "/recipe/1", strings.NewReader(test.body))
+			req = mux.SetURLVars(withUser(req, test.userId), map[string]string{
+				"id": "1",
+			})
+			rr := httptest.NewRecorder()
+
+			expectFindRecipe(mockRepository, "1", true, existing)
+			test.expectedRecipe.Normalize()
+			if test.expectedStatusCode == http.StatusOK {
+				mockRepository.EXPECT().UpdateRecipe(&test.expectedRecipe).Return(nil)
+			}
+
+			http.HandlerFunc(service.PatchRecipe).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				var recipe recipes.Recipe
+				err := json.Unmarshal(rr.Body.Bytes(), &recipe)
+
+				if err != nil {
+					t.Error("error from unmarshal", err)
+				}
+
+				if !reflect.DeepEqual(recipe, test.expectedRecipe) {
+					t.Errorf("Got recipe = %v but wanted %v", recipe, test.expectedRecipe)
+				}
+			}
+		})
+	}
+}
+
+func TestRecipeService_DeleteRecipe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	existing := recipes.Recipe{ID: 1, AuthorID: 1, Title: "Title"}
+
+	tests := []struct {
+		name               string
+		id                 string
+		userId             uint
+		role               users.Role
+		found              bool
+		repositoryError    string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			id:                 "1",
+			userId:             1,
+			found:              true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Cannot parse id",
+			id:                 "a",
+			userId:             1,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Recipe not found",
+			id:                 "2",
+			userId:             1,
+			found:              false,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Not the author",
+			id:                 "1",
+			userId:             2,
+			found:              true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Admin deletes recipe of another user",
+			id:                 "1",
+			userId:             2,
+			role:               users.RoleAdmin,
+			found:              true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Repository error",
+			id:                 "1",
+			userId:             1,
+			found:              true,
+			repositoryError:    "some repo error",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("DELETE", "/recipe/"+test.id, nil)
+			req = mux.SetURLVars(withRole(req, test.userId, test.role), map[string]string{
+				"id": test.id,
+			})
+			rr := httptest.NewRecorder()
+
+			expectFindRecipe(mockRepository, test.id, test.found, existing)
+			if test.expectedStatusCode == http.StatusNoContent || test.repositoryError != "" {
+				var err error
+				if test.repositoryError != "" {
+					err = errors.New(test.repositoryError)
+				}
+				mockRepository.EXPECT().DeleteRecipe(int(existing.ID)).Return(err)
+			}
+
+			http.HandlerFunc(service.DeleteRecipe).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRecipeService_FindRecipeNutrition(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	recipe := &recipes.Recipe{
+		ID:       1,
+		Servings: 2,
+		Ingredients: []recipes.Ingredient{
+			{Name: "rice", Quantity: 200, Measurement: "g"},
+			{Name: "saffron", Quantity: 1, Measurement: "pinch"},
+		},
+	}
+	table := map[string]recipes.IngredientNutrients{
+		"rice": {Name: "rice", Per100g: recipes.Nutrients{Calories: 365, Protein: 7.1, Fat: 0.7, Carbs: 80, Fibre: 1.3}},
+	}
+
+	tests := []struct {
+		name               string
+		id                 string
+		found              bool
+		repositoryError    error
+		expected           recipes.Nutrition
+		expectedStatusCode int
+	}{
+		{
+			name:  "Successful",
+			id:    "1",
+			found: true,
+			expected: recipes.Nutrition{
+				RecipeID:   1,
+				Servings:   2,
+				Total:      recipes.Nutrients{Calories: 730, Protein: 14.2, Fat: 1.4, Carbs: 160, Fibre: 2.6},
+				PerServing: &recipes.Nutrients{Calories: 365, Protein: 7.1, Fat: 0.7, Carbs: 80, Fibre: 1.3},
+				Unmatched:  []recipes.UnmatchedIngredient{{Name: "saffron", Reason: "the nutrients of the ingredient are not known"}},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Cannot parse id",
+			id:                 "a",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Recipe not found",
+			id:                 "2",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Repository error",
+			id:                 "1",
+			found:              true,
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/recipe/"+test.id+"/nutrition", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": test.id})
+			rr := httptest.NewRecorder()
+
+			expectFindRecipe(mockRepository, test.id, test.found, *recipe)
+			if test.found {
+				mockRepository.EXPECT().FindIngredientNutrients([]string{"rice", "saffron"}).Return(table, test.repositoryError)
+			}
+
+			http.HandlerFunc(service.FindRecipeNutrition).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				var nutrition recipes.Nutrition
+				json.Unmarshal(rr.Body.Bytes(), &nutrition)
+				if !reflect.DeepEqual(nutrition, test.expected) {
+					t.Errorf("Got nutrition = %v but wanted %v", nutrition, test.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRecipeService_UpdateIngredientAttributes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	tests := []struct {
+		name               string
+		body               string
+		expected           recipes.IngredientAttributes
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			body:               `{"vegan":true,"vegetarian":true,"allergens":["peanut"]}`,
+			expected:           recipes.IngredientAttributes{Name: "Peanuts", Vegan: true, Vegetarian: true, Allergens: []string{"peanut"}},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Cannot decode payload",
+			body:               `{"vegan":`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid attributes",
+			body:               `{"vegan":true}`,
+			expected:           recipes.IngredientAttributes{Name: "Peanuts", Vegan: true},
+			repositoryError:    apperror.Field("vegetarian", "must be true for vegan ingredients"),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repository error",
+			body:               `{}`,
+			expected:           recipes.IngredientAttributes{Name: "Peanuts"},
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PUT", "/admin/ingredients/Peanuts", strings.NewReader(test.body))
+			req = mux.SetURLVars(req, map[string]string{"name": "Peanuts"})
+			rr := httptest.NewRecorder()
+
+			if test.expected.Name != "" {
+				mockRepository.EXPECT().SaveIngredientAttributes(&test.expected).Return(test.repositoryError)
+			}
+
+			http.HandlerFunc(service.UpdateIngredientAttributes).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestRecipeService_DeleteIngredientAttributes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := RecipeService{RecipeRepository: mockRepository}
+
+	tests := []struct {
+		name               string
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Not classified",
+			repositoryError:    recipes.ErrIngredientNotClassified,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Repository error",
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("DELETE", "/admin/ingredients/saffron", nil)
+			req = mux.SetURLVars(req, map[string]string{"name": "saffron"})
+			rr := httptest.NewRecorder()
+
+			mockRepository.EXPECT().DeleteIngredientAttributes("saffron").Return(test.repositoryError)
+
+			http.HandlerFunc(service.DeleteIngredientAttributes).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func withUser(req *http.Request, userId uint) *http.Request {
+	return withRole(req, userId, users.RoleUser)
+}
+
+func withRole(req *http.Request, userId uint, role users.Role) *http.Request {
+	user := &users.User{ID: userId, Role: role}
+	return req.WithContext(users.NewContext(req.Context(), user))
+}
+
+func expectFindRecipe(mockRepository *mocks.MockRecipeRepository, id string, found bool, recipe recipes.Recipe) {
+	recipeId, err := strconv.Atoi(id)
+	if err != nil {
+		return
+	}
+
+	if found {
+		mockRepository.EXPECT().FindRecipeById(recipeId).Return(&recipe, nil)
+	} else {
+		mockRepository.EXPECT().FindRecipeById(recipeId).Return(nil, recipes.ErrRecipeNotFound)
+	}
+}
+
+func expectProblem(t *testing.T, rr *httptest.ResponseRecorder, status int, fields []string) {
+	if contentType := rr.Header().Get("Content-Type"); contentType != apperror.ProblemContentType {
+		t.Errorf("Got content type %v but wanted %v", contentType, apperror.ProblemContentType)
+	}
+
+	var problem apperror.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatal("error from unmarshal", err)
+	}
+
+	var problemFields []string
+	for _, field := range problem.Errors {
+		problemFields = append(problemFields, field.Field)
+	}
+
+	if problem.Status != status || problem.Title != http.StatusText(status) || !reflect.DeepEqual(problemFields, fields) {
+		t.Errorf("Got problem = %+v but wanted status %v with fields %v", problem, status, fields)
+	}
+}
+
+func expectSearchPage(t *testing.T, body string, items []recipes.RecipeSearchResult, total int, cursor string) {
+	var page recipes.RecipeSearchPage
+	err := json.Unmarshal([]byte(body), &page)
+
+	if err != nil {
+		t.Error("error from unmarshal", err)
+	}
+
+	if page.Items == nil {
+		t.Errorf("Expected items to be a list got %v", body)
+	}
+
+	if len(items) > 0 && !reflect.DeepEqual(page.Items, items) {
+		t.Errorf("Got searchResults = %v but wanted %v", page.Items, items)
+	} else if len(items) == 0 && len(page.Items) != 0 {
+		t.Errorf("Expected [] result but got %v", page.Items)
+	}
+
+	if page.Total != total || page.NextCursor != cursor {
+		t.Errorf("Got total = %v and cursor = %v but wanted %v and %v", page.Total, page.NextCursor, total, cursor)
+	}
+}
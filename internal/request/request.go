@@ -0,0 +1,61 @@
+// Package request reads the json payloads of the requests, capping their size and validating them
+package request
+
+import (
+	"encoding/json"
+	"github.com/krasimiraMilkova/cookit/internal/problem"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/validation"
+	"net/http"
+)
+
+// errBodyTooLarge is the message of the error http.MaxBytesReader returns once the body is over the limit
+const errBodyTooLarge = "http: request body too large"
+
+// LimitBody function returns a middleware which caps the request bodies at maxBytes with http.MaxBytesReader,
+// so a client cannot make a handler read an unbounded payload
+func LimitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Decode function decodes the json payload of the request into the value, name tells what the payload is
+// Writes Status RequestEntityTooLarge if the body is over the limit or Status BadRequest if it cannot be decoded
+// and returns false if the payload cannot be used
+func Decode(w http.ResponseWriter, r *http.Request, name string, value interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(value)
+
+	if err != nil && err.Error() == errBodyTooLarge {
+		problem.Status(w, r, http.StatusRequestEntityTooLarge, "the "+name+" payload is too large")
+		return false
+	}
+
+	if err != nil {
+		problem.Write(w, r, apperror.Validation("cannot decode the "+name+" payload, "+err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// DecodeValid function decodes the payload like Decode and checks it against the rules of its validate tags
+// before it reaches any repository
+// Writes Status BadRequest listing the invalid fields and returns false if the payload is invalid
+func DecodeValid(w http.ResponseWriter, r *http.Request, name string, value interface{}) bool {
+	if !Decode(w, r, name, value) {
+		return false
+	}
+
+	if err := validation.Validate(name, value); err != nil {
+		problem.Write(w, r, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,77 @@
+package request
+
+import (
+	"encoding/json"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type payload struct {
+	Title string `json:"title" validate:"required,max=10"`
+}
+
+func TestDecodeValid(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		expectedStatusCode int
+		expectedDetail     string
+	}{
+		{
+			name:               "Valid payload",
+			body:               `{"title":"Soup"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid payload",
+			body:               `{"title":""}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedDetail:     "invalid recipe",
+		},
+		{
+			name:               "Cannot decode payload",
+			body:               `{"title":`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedDetail:     "cannot decode the recipe payload, unexpected EOF",
+		},
+		{
+			name:               "Payload over the limit",
+			body:               `{"title":"` + strings.Repeat("a", 64) + `"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedDetail:     "the recipe payload is too large",
+		},
+	}
+
+	handler := LimitBody(32)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if DecodeValid(w, r, "recipe", &payload{}) {
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/recipe", strings.NewReader(test.body)))
+
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, test.expectedStatusCode)
+			}
+
+			if test.expectedDetail == "" {
+				return
+			}
+
+			problem := apperror.Problem{}
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatal("error from decode", err)
+			}
+
+			if problem.Detail != test.expectedDetail {
+				t.Errorf("Got detail = %q but wanted %q", problem.Detail, test.expectedDetail)
+			}
+		})
+	}
+}
@@ -2,36 +2,74 @@ package routes
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/krasimiraMilkova/cookit/internal/appconfig"
 	cs "github.com/krasimiraMilkova/cookit/internal/comments/service"
 	rs "github.com/krasimiraMilkova/cookit/internal/recipes/service"
+	"github.com/krasimiraMilkova/cookit/internal/request"
 	"github.com/krasimiraMilkova/cookit/internal/users/auth"
 	us "github.com/krasimiraMilkova/cookit/internal/users/service"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"net/http"
 )
 
 func Handlers() *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
-	router.Use(CommonMiddleware)
+	router.Use(CommonMiddleware, request.LimitBody(appconfig.Get().GetMaxBodyBytes()))
 
 	userService := us.Get()
+	recipeService := rs.Get()
+
+	jwtAuthenticator := auth.GetAuthenticator()
 
+	router.HandleFunc("/.well-known/jwks.json", jwtAuthenticator.JWKS).Methods("GET")
 	router.HandleFunc("/register", userService.CreateUser).Methods("POST")
 	router.HandleFunc("/login", userService.Login).Methods("POST")
+	router.HandleFunc("/refresh", userService.Refresh).Methods("POST")
+	router.HandleFunc("/verify-email", userService.VerifyEmail).Methods("GET", "POST")
+	router.HandleFunc("/password/forgot", userService.ForgotPassword).Methods("POST")
+	router.HandleFunc("/password/reset", userService.ResetPassword).Methods("POST")
+	router.Handle("/logout", jwtAuthenticator.VerifyJWT(http.HandlerFunc(userService.Logout))).Methods("POST")
+	router.Handle("/logout-all", jwtAuthenticator.VerifyJWT(http.HandlerFunc(userService.LogoutAll))).Methods("POST")
+
+	adminSubrouter := router.PathPrefix("/api/v1/admin").Subrouter()
+	adminSubrouter.Use(jwtAuthenticator.VerifyJWT, auth.RequireRole(users.RoleAdmin))
+	adminSubrouter.HandleFunc("/users", userService.ListUsers).Methods("GET")
+	adminSubrouter.HandleFunc("/users/{id}/role", userService.UpdateUserRole).Methods("PUT")
+	adminSubrouter.HandleFunc("/ingredients", recipeService.ListIngredientAttributes).Methods("GET")
+	adminSubrouter.HandleFunc("/ingredients/{name}", recipeService.UpdateIngredientAttributes).Methods("PUT")
+	adminSubrouter.HandleFunc("/ingredients/{name}", recipeService.DeleteIngredientAttributes).Methods("DELETE")
 
-	jwtAuthenticator := auth.GetAuthenticator()
 	authenticatedSubrouter := router.PathPrefix("/api/v1").Subrouter()
 	authenticatedSubrouter.Use(jwtAuthenticator.VerifyJWT)
 
-	recipeService := rs.Get()
+	authenticatedSubrouter.HandleFunc("/verify-email/resend", userService.ResendVerification).Methods("POST")
+	authenticatedSubrouter.HandleFunc("/me", userService.CurrentUser).Methods("GET")
+	authenticatedSubrouter.HandleFunc("/me", userService.UpdateCurrentUser).Methods("PATCH")
+	authenticatedSubrouter.HandleFunc("/me", userService.DeleteCurrentUser).Methods("DELETE")
+	authenticatedSubrouter.HandleFunc("/me/password", userService.ChangePassword).Methods("POST")
+	authenticatedSubrouter.HandleFunc("/users/{id}", userService.FindUserProfile).Methods("GET")
+
+	// users can read before verifying their email, but not post
+	verified := func(handler http.HandlerFunc) http.Handler {
+		return auth.RequireVerified(handler)
+	}
 
-	authenticatedSubrouter.HandleFunc("/recipe", recipeService.CreateRecipe).Methods("POST")
+	authenticatedSubrouter.Handle("/recipe", verified(recipeService.CreateRecipe)).Methods("POST")
+	authenticatedSubrouter.HandleFunc("/recipe/search", recipeService.FindRecipesByText).Methods("GET")
 	authenticatedSubrouter.HandleFunc("/recipe/{id}", recipeService.FindRecipeById).Methods("GET")
-	authenticatedSubrouter.HandleFunc("/recipe", recipeService.FindRecipesByTitle).Queries("title", "{title}").Methods("GET")
-	authenticatedSubrouter.HandleFunc("/recipe", recipeService.FindRecipesByIngredients).Queries("ingredients", "{ingredients}").Methods("GET")
+	authenticatedSubrouter.Handle("/recipe/{id}", verified(recipeService.UpdateRecipe)).Methods("PUT")
+	authenticatedSubrouter.Handle("/recipe/{id}", verified(recipeService.PatchRecipe)).Methods("PATCH")
+	authenticatedSubrouter.Handle("/recipe/{id}", verified(recipeService.DeleteRecipe)).Methods("DELETE")
+	authenticatedSubrouter.Handle("/recipe/{id}/rating", verified(recipeService.RateRecipe)).Methods("PUT")
+	authenticatedSubrouter.HandleFunc("/recipe/{id}/nutrition", recipeService.FindRecipeNutrition).Methods("GET")
+	authenticatedSubrouter.HandleFunc("/recipe", recipeService.FindRecipesByPantry).Queries("pantry", "{pantry}").Methods("GET")
+	authenticatedSubrouter.HandleFunc("/recipe", recipeService.FindRecipes).Methods("GET")
 
 	commentService := cs.Get()
 	authenticatedSubrouter.HandleFunc("/recipe/{recipeId}/comment", commentService.GetComments).Methods("GET")
-	authenticatedSubrouter.HandleFunc("/recipe/{recipeId}/comment", commentService.AddComment).Methods("POST")
+	authenticatedSubrouter.Handle("/recipe/{recipeId}/comment", verified(commentService.AddComment)).Methods("POST")
+	authenticatedSubrouter.Handle("/recipe/{recipeId}/comment/{id}", verified(commentService.UpdateComment)).Methods("PUT")
+	authenticatedSubrouter.Handle("/recipe/{recipeId}/comment/{id}", verified(commentService.DeleteComment)).Methods("DELETE")
 
 	return router
 }
@@ -40,7 +78,7 @@ func CommonMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-Type", "application/json")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Access-Control-Request-Headers, Access-Control-Request-Method, Connection, Host, Origin, User-Agent, Referer, Cache-Control, X-header")
 		next.ServeHTTP(w, r)
 	})
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"database/sql"
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"time"
+)
+
+type ActionTokenRepository struct {
+	*sql.DB
+	Dialect db.Dialect
+}
+
+func GetActionTokenRepository() users.ActionTokenRepository {
+	return &ActionTokenRepository{DB: db.Get(), Dialect: db.GetDialect()}
+}
+
+func (tokenRepository *ActionTokenRepository) CreateActionToken(token *users.ActionToken) error {
+	return db.WithTransaction(tokenRepository.DB, func(tx *sql.Tx) error {
+		// only the latest token sent for a purpose can be used
+		_, err := tx.Exec("update user_tokens set used_at = ? where user_id = ? and purpose = ? and used_at is null;",
+			time.Now().UTC(), token.UserID, token.Purpose)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("insert into user_tokens(id, user_id, purpose, expires_at)values(?,?,?,?);",
+			token.ID, token.UserID, token.Purpose, token.ExpiresAt)
+		return err
+	})
+}
+
+func (tokenRepository *ActionTokenRepository) UseActionToken(id string, purpose string) (bool, error) {
+	now := time.Now().UTC()
+	result, err := tokenRepository.Exec("update user_tokens set used_at = ? "+
+		"where id = ? and purpose = ? and used_at is null and expires_at > ?;", now, id, purpose, now)
+	if err != nil {
+		return false, err
+	}
+
+	used, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return used == 1, nil
+}
@@ -0,0 +1,322 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SigningKey is a key of the key set, a retired key has only its public part and only verifies tokens
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// KeySet holds the keys access tokens are verified with, the active one of them signs new tokens
+// Tokens carry the id of their key in the kid header, so older keys keep verifying tokens while they are retired
+type KeySet struct {
+	keys   map[string]*SigningKey
+	active string
+}
+
+// NewKeySet function creates a key set signing with the key with the given id
+// Returns an error if there is no such key, it has no private part or two keys share an id
+func NewKeySet(activeID string, keys ...*SigningKey) (*KeySet, error) {
+	keySet := &KeySet{keys: map[string]*SigningKey{}, active: activeID}
+
+	for _, key := range keys {
+		if _, ok := keySet.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key %q", key.ID)
+		}
+		keySet.keys[key.ID] = key
+	}
+
+	active, ok := keySet.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", activeID)
+	}
+
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("active signing key %q has no private key", activeID)
+	}
+
+	return keySet, nil
+}
+
+// LoadKeySet function reads the PEM encoded keys from the directory and activates the key with the given id
+// A file named <kid>.<ext> holds a private key and a file named <kid>.<ext>.pub a public key,
+// so a key is retired by removing its private key file and removed by removing its public key file
+// Returns an error if such occurs while reading or parsing the keys
+func LoadKeySet(dir string, activeID string) (*KeySet, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*SigningKey{}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.SplitN(file.Name(), ".", 2)[0]
+		key, ok := keys[id]
+		if !ok {
+			key = &SigningKey{ID: id}
+			keys[id] = key
+		}
+
+		if strings.HasSuffix(file.Name(), ".pub") {
+			if key.PublicKey == nil {
+				key.PublicKey, err = ParsePublicKey(content)
+			}
+		} else if key.PrivateKey, err = ParsePrivateKey(content); err == nil {
+			key.PublicKey = key.PrivateKey.Public()
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse key file %s: %v", file.Name(), err)
+		}
+	}
+
+	loaded := make([]*SigningKey, 0, len(keys))
+	for _, key := range keys {
+		if key.Method, err = signingMethodFor(key.PublicKey); err != nil {
+			return nil, fmt.Errorf("signing key %q: %v", key.ID, err)
+		}
+		loaded = append(loaded, key)
+	}
+
+	return NewKeySet(activeID, loaded...)
+}
+
+// ParsePrivateKey function parses a PEM encoded PKCS1 RSA, SEC1 EC or PKCS8 private key
+func ParsePrivateKey(content []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	return signer, nil
+}
+
+// ParsePublicKey function parses a PEM encoded PKIX or PKCS1 RSA public key
+func ParsePublicKey(content []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// signingMethodFor returns the method tokens are signed with by a key of the type of the public key
+func signingMethodFor(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, errors.New("unsupported elliptic curve")
+	case ed25519.PublicKey:
+		return SigningMethodEdDSA, nil
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+}
+
+// NewSigningKey function creates a signing key with the method matching the type of the private key
+// Returns an error if the key type is not supported
+func NewSigningKey(id string, privateKey crypto.Signer) (*SigningKey, error) {
+	method, err := signingMethodFor(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{ID: id, Method: method, PrivateKey: privateKey, PublicKey: privateKey.Public()}, nil
+}
+
+// Key types which can be generated by GenerateKeyFiles
+const (
+	KeyTypeRSA     = "rsa"
+	KeyTypeEC      = "ec"
+	KeyTypeEd25519 = "ed25519"
+)
+
+// GenerateKeyFiles function generates a key of the given type and writes it to the directory
+// as <id>.pem and <id>.pem.pub files, which LoadKeySet reads as the key with the given id
+// Returns an error if the type is not supported, a key with the id exists or such occurs while writing
+func GenerateKeyFiles(dir string, id string, keyType string) error {
+	if id == "" || strings.ContainsAny(id, "./\\") {
+		return fmt.Errorf("invalid key id %q", id)
+	}
+
+	privatePath := filepath.Join(dir, id+".pem")
+	if matches, _ := filepath.Glob(filepath.Join(dir, id+".*")); len(matches) > 0 {
+		return fmt.Errorf("key %q already exists", id)
+	}
+
+	var privateKey crypto.Signer
+	var err error
+
+	switch keyType {
+	case KeyTypeRSA:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeEC:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeEd25519:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("unsupported key type %q", keyType)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	publicBytes, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes}), 0600)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(privatePath+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes}), 0644)
+}
+
+// Sign function signs the claims with the active key and sets its id in the kid header of the token
+func (keySet *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := keySet.keys[keySet.active]
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc function returns the public key of the token by its kid header, tokens without kid
+// were issued before keys had ids and are verified with the active key
+// Returns an error if the key is unknown or the token is signed with a method other than the method of the key
+func (keySet *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+	if id == "" {
+		id = keySet.active
+	}
+
+	key, ok := keySet.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", id)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), id)
+	}
+
+	return key.PublicKey, nil
+}
+
+// JWKS function returns the public keys of the set ordered by id
+func (keySet *KeySet) JWKS() users.JSONWebKeySet {
+	ids := make([]string, 0, len(keySet.keys))
+	for id := range keySet.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	keys := make([]users.JSONWebKey, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, jsonWebKey(keySet.keys[id]))
+	}
+
+	return users.JSONWebKeySet{Keys: keys}
+}
+
+func jsonWebKey(key *SigningKey) users.JSONWebKey {
+	jwk := users.JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch publicKey := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = encode(publicKey.N.Bytes())
+		jwk.Exponent = encode(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = publicKey.Curve.Params().Name
+		jwk.X = encode(publicKey.X.FillBytes(make([]byte, size)))
+		jwk.Y = encode(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encode(publicKey)
+	}
+
+	return jwk
+}
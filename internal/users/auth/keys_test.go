@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func rsaKey(t *testing.T) crypto.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("cannot generate key", err)
+	}
+	return key
+}
+
+func newKeySet(t *testing.T, activeID string, privateKey crypto.Signer) *KeySet {
+	key, err := NewSigningKey(activeID, privateKey)
+	if err != nil {
+		t.Fatal("cannot create signing key", err)
+	}
+
+	keySet, err := NewKeySet(activeID, key)
+	if err != nil {
+		t.Fatal("cannot create key set", err)
+	}
+	return keySet
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name        string
+		key         crypto.Signer
+		expectedAlg string
+	}{
+		{name: "RSA", key: rsaKey(t), expectedAlg: "RS256"},
+		{name: "ECDSA", key: ecKey, expectedAlg: "ES256"},
+		{name: "Ed25519", key: edKey, expectedAlg: "EdDSA"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keySet := newKeySet(t, "key-"+test.name, test.key)
+
+			signed, err := keySet.Sign(&Token{UserID: 4, StandardClaims: &jwt.StandardClaims{}})
+			if err != nil {
+				t.Fatal("unexpected error on sign", err)
+			}
+
+			claims := &Token{StandardClaims: &jwt.StandardClaims{}}
+			token, err := jwt.ParseWithClaims(signed, claims, keySet.Keyfunc)
+			if err != nil {
+				t.Fatal("unexpected error on verify", err)
+			}
+
+			if token.Header["kid"] != "key-"+test.name || token.Method.Alg() != test.expectedAlg || claims.UserID != 4 {
+				t.Errorf("Got kid = %v, alg = %v, user = %v", token.Header["kid"], token.Method.Alg(), claims.UserID)
+			}
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	oldKey, _ := NewSigningKey("old", rsaKey(t))
+	newKey, _ := NewSigningKey("new", edKey)
+
+	before, _ := NewKeySet("old", oldKey)
+	oldToken, _ := before.Sign(&Token{UserID: 1, StandardClaims: &jwt.StandardClaims{}})
+
+	// the old key is retired, its private part is no longer available
+	retired := &SigningKey{ID: oldKey.ID, Method: oldKey.Method, PublicKey: oldKey.PublicKey}
+	after, err := NewKeySet("new", retired, newKey)
+	if err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	if _, err = jwt.ParseWithClaims(oldToken, &Token{StandardClaims: &jwt.StandardClaims{}}, after.Keyfunc); err != nil {
+		t.Errorf("expected a token of a retired key to be valid, got %v", err)
+	}
+
+	newToken, _ := after.Sign(&Token{UserID: 1, StandardClaims: &jwt.StandardClaims{}})
+	if _, err = jwt.ParseWithClaims(newToken, &Token{StandardClaims: &jwt.StandardClaims{}}, before.Keyfunc); err == nil {
+		t.Error("expected a token of an unknown key to be rejected")
+	}
+
+	if _, err = NewKeySet("old", retired, newKey); err == nil {
+		t.Error("expected a retired key to be rejected as the active key")
+	}
+}
+
+func TestKeySet_RejectsOtherSigningMethod(t *testing.T) {
+	keySet := newKeySet(t, "app", rsaKey(t))
+
+	// a token claiming the id of the RSA key but signed with a shared secret must not be accepted
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Token{UserID: 1, StandardClaims: &jwt.StandardClaims{}})
+	token.Header["kid"] = "app"
+	forged, _ := token.SignedString([]byte("secret"))
+
+	if _, err := jwt.ParseWithClaims(forged, &Token{StandardClaims: &jwt.StandardClaims{}}, keySet.Keyfunc); err == nil {
+		t.Error("expected a token with another signing method to be rejected")
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal("cannot create directory", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for id, keyType := range map[string]string{"a-rsa": KeyTypeRSA, "b-ec": KeyTypeEC, "c-ed": KeyTypeEd25519} {
+		if err = GenerateKeyFiles(dir, id, keyType); err != nil {
+			t.Fatal("unexpected error on generate", err)
+		}
+	}
+
+	if err = GenerateKeyFiles(dir, "c-ed", KeyTypeRSA); err == nil {
+		t.Error("expected an existing key not to be overwritten")
+	}
+
+	// retire the RSA key
+	if err = os.Remove(filepath.Join(dir, "a-rsa.pem")); err != nil {
+		t.Fatal("cannot remove key", err)
+	}
+
+	if _, err = LoadKeySet(dir, "a-rsa"); err == nil {
+		t.Error("expected a retired key to be rejected as the active key")
+	}
+
+	keySet, err := LoadKeySet(dir, "c-ed")
+	if err != nil {
+		t.Fatal("unexpected error on load", err)
+	}
+
+	authenticator := JwtAuthenticator{Keys: keySet}
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(authenticator.JWKS).ServeHTTP(rr, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	jwks := users.JSONWebKeySet{}
+	json.NewDecoder(rr.Body).Decode(&jwks)
+
+	if len(jwks.Keys) != 3 {
+		t.Fatalf("Got %v keys but wanted 3", len(jwks.Keys))
+	}
+
+	expected := []struct{ kid, kty, alg, crv string }{
+		{"a-rsa", "RSA", "RS256", ""},
+		{"b-ec", "EC", "ES256", "P-256"},
+		{"c-ed", "OKP", "EdDSA", "Ed25519"},
+	}
+	for i, key := range jwks.Keys {
+		if key.KeyID != expected[i].kid || key.KeyType != expected[i].kty || key.Algorithm != expected[i].alg ||
+			key.Curve != expected[i].crv || key.Use != "sig" || key.X == "" && key.Modulus == "" {
+			t.Errorf("Got key = %v but wanted %v", key, expected[i])
+		}
+	}
+}
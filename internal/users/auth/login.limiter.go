@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"github.com/krasimiraMilkova/cookit/internal/appconfig"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"log"
+	"strings"
+	"time"
+)
+
+// Stores of the failed logins selected with the LOGIN_ATTEMPTS_STORE setting
+const (
+	AttemptsStoreDatabase = "database"
+	AttemptsStoreMemory   = "memory"
+)
+
+// AttemptLimiter throttles logins by the failures counted for the account and for the client address,
+// a client address gets more failures than an account since many users may share it
+type AttemptLimiter struct {
+	Attempts      users.LoginAttemptRepository
+	AccountPolicy users.LockoutPolicy
+	AddressPolicy users.LockoutPolicy
+	Now           func() time.Time
+}
+
+var limiter *AttemptLimiter
+
+func GetLoginLimiter() *AttemptLimiter {
+	if limiter == nil {
+		store, maxFailures, maxAddressFailures, backoff, maxBackoff, lockout := appconfig.Get().GetLoginLockoutConfig()
+
+		var attempts users.LoginAttemptRepository
+		switch store {
+		case AttemptsStoreDatabase:
+			attempts = GetLoginAttemptRepository()
+		case AttemptsStoreMemory:
+			attempts = NewMemoryLoginAttemptRepository()
+		default:
+			log.Fatalf("Unsupported login attempts store %q", store)
+		}
+
+		limiter = &AttemptLimiter{
+			Attempts:      attempts,
+			AccountPolicy: users.LockoutPolicy{MaxFailures: maxFailures, BaseDelay: backoff, MaxDelay: maxBackoff, Lockout: lockout},
+			AddressPolicy: users.LockoutPolicy{MaxFailures: maxAddressFailures, BaseDelay: backoff, MaxDelay: maxBackoff, Lockout: lockout},
+			Now:           time.Now,
+		}
+	}
+
+	return limiter
+}
+
+func (attemptLimiter *AttemptLimiter) Check(email string, address string) (time.Duration, error) {
+	now := attemptLimiter.Now()
+	var wait time.Duration
+
+	for _, key := range attemptKeys(email, address) {
+		attempts, err := attemptLimiter.Attempts.FindLoginAttempts(key)
+		if err != nil {
+			return 0, err
+		}
+
+		if attempts != nil && attempts.LockedUntil.Sub(now) > wait {
+			wait = attempts.LockedUntil.Sub(now)
+		}
+	}
+
+	return wait, nil
+}
+
+func (attemptLimiter *AttemptLimiter) Fail(email string, address string) error {
+	now := attemptLimiter.Now()
+
+	if _, err := attemptLimiter.Attempts.AddLoginFailure(accountKey(email), now, attemptLimiter.AccountPolicy); err != nil {
+		return err
+	}
+
+	if address == "" {
+		return nil
+	}
+
+	_, err := attemptLimiter.Attempts.AddLoginFailure(addressKey(address), now, attemptLimiter.AddressPolicy)
+	return err
+}
+
+func (attemptLimiter *AttemptLimiter) Succeed(email string) error {
+	return attemptLimiter.Attempts.ResetLoginAttempts(accountKey(email))
+}
+
+// attemptKeys returns the keys the failures of a login are counted by
+func attemptKeys(email string, address string) []string {
+	if address == "" {
+		return []string{accountKey(email)}
+	}
+
+	return []string{accountKey(email), addressKey(address)}
+}
+
+// accountKey is the same for every spelling of the email, whether or not it has an account
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func addressKey(address string) string {
+	return "ip:" + address
+}
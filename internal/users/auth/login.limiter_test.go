@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"testing"
+	"time"
+)
+
+func newMemoryDatabaseAttempts(t *testing.T) users.LoginAttemptRepository {
+	database, dialect, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return &LoginAttemptRepository{DB: database, Dialect: dialect}
+}
+
+func TestAttemptLimiter(t *testing.T) {
+	stores := []struct {
+		name     string
+		attempts func(t *testing.T) users.LoginAttemptRepository
+	}{
+		{name: "Database", attempts: newMemoryDatabaseAttempts},
+		{name: "Memory", attempts: func(t *testing.T) users.LoginAttemptRepository {
+			return NewMemoryLoginAttemptRepository()
+		}},
+	}
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+			limiter := &AttemptLimiter{
+				Attempts:      store.attempts(t),
+				AccountPolicy: users.LockoutPolicy{MaxFailures: 3, BaseDelay: time.Second, MaxDelay: time.Minute, Lockout: time.Hour},
+				AddressPolicy: users.LockoutPolicy{MaxFailures: 5, BaseDelay: time.Second, MaxDelay: time.Minute, Lockout: time.Hour},
+				Now:           func() time.Time { return now },
+			}
+
+			check := func(email string, address string, expected time.Duration) {
+				t.Helper()
+				if wait, err := limiter.Check(email, address); err != nil || wait != expected {
+					t.Errorf("Got wait = %v, %v for %v from %v but wanted %v", wait, err, email, address, expected)
+				}
+			}
+
+			check("chef@test.com", "192.0.2.1", 0)
+
+			for i := 0; i < 2; i++ {
+				if err := limiter.Fail("chef@test.com", "192.0.2.1"); err != nil {
+					t.Fatal("unexpected error on fail", err)
+				}
+			}
+
+			// the email is throttled in any spelling and from any address
+			check("Chef@Test.com ", "198.51.100.1", 2*time.Second)
+			check("cook@test.com", "192.0.2.1", 2*time.Second)
+			check("cook@test.com", "198.51.100.1", 0)
+
+			now = now.Add(2 * time.Second)
+			check("chef@test.com", "192.0.2.1", 0)
+
+			limiter.Fail("chef@test.com", "192.0.2.1")
+			check("chef@test.com", "198.51.100.1", time.Hour)
+
+			// a successful login of the email does not reset the failures of the address
+			if err := limiter.Succeed("CHEF@test.com"); err != nil {
+				t.Fatal("unexpected error on succeed", err)
+			}
+			check("chef@test.com", "198.51.100.1", 0)
+			check("chef@test.com", "192.0.2.1", 4*time.Second)
+
+			// the address is locked out after failures with different emails
+			now = now.Add(time.Minute)
+			limiter.Fail("cook@test.com", "192.0.2.1")
+			limiter.Fail("baker@test.com", "192.0.2.1")
+			check("new@test.com", "192.0.2.1", time.Hour)
+			check("new@test.com", "", 0)
+
+			// the failures are forgotten once the lockout is over
+			now = now.Add(time.Hour + time.Second)
+			check("new@test.com", "192.0.2.1", 0)
+			limiter.Fail("cook@test.com", "192.0.2.1")
+			check("new@test.com", "192.0.2.1", time.Second)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"sync"
+	"time"
+)
+
+// MemoryLoginAttemptRepository counts the failed logins in the memory of a single server,
+// the counts are lost on restart and not shared between servers
+type MemoryLoginAttemptRepository struct {
+	mutex     sync.Mutex
+	attempts  map[string]users.LoginAttempts
+	lastPrune time.Time
+}
+
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{attempts: map[string]users.LoginAttempts{}}
+}
+
+func (attemptRepository *MemoryLoginAttemptRepository) FindLoginAttempts(key string) (*users.LoginAttempts, error) {
+	attemptRepository.mutex.Lock()
+	defer attemptRepository.mutex.Unlock()
+
+	attempts, ok := attemptRepository.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &attempts, nil
+}
+
+func (attemptRepository *MemoryLoginAttemptRepository) AddLoginFailure(key string, now time.Time, policy users.LockoutPolicy) (*users.LoginAttempts, error) {
+	attemptRepository.mutex.Lock()
+	defer attemptRepository.mutex.Unlock()
+
+	// the failures of other keys are forgotten after they expire, so the map does not keep growing
+	if now.Sub(attemptRepository.lastPrune) > policy.Lockout {
+		for other, attempts := range attemptRepository.attempts {
+			if policy.Expired(attempts, now) {
+				delete(attemptRepository.attempts, other)
+			}
+		}
+		attemptRepository.lastPrune = now
+	}
+
+	updated := policy.AddFailure(attemptRepository.attempts[key], now)
+	attemptRepository.attempts[key] = updated
+
+	return &updated, nil
+}
+
+func (attemptRepository *MemoryLoginAttemptRepository) ResetLoginAttempts(key string) error {
+	attemptRepository.mutex.Lock()
+	defer attemptRepository.mutex.Unlock()
+
+	delete(attemptRepository.attempts, key)
+	return nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"database/sql"
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"time"
+)
+
+type LoginAttemptRepository struct {
+	*sql.DB
+	Dialect db.Dialect
+}
+
+func GetLoginAttemptRepository() users.LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db.Get(), Dialect: db.GetDialect()}
+}
+
+func (attemptRepository *LoginAttemptRepository) FindLoginAttempts(key string) (*users.LoginAttempts, error) {
+	return findLoginAttempts(attemptRepository.QueryRow, key)
+}
+
+func (attemptRepository *LoginAttemptRepository) AddLoginFailure(key string, now time.Time, policy users.LockoutPolicy) (*users.LoginAttempts, error) {
+	var updated users.LoginAttempts
+
+	err := db.WithTransaction(attemptRepository.DB, func(tx *sql.Tx) error {
+		attempts, err := findLoginAttempts(tx.QueryRow, key)
+		if err != nil {
+			return err
+		}
+
+		if attempts == nil {
+			attempts = &users.LoginAttempts{}
+		}
+
+		updated = policy.AddFailure(*attempts, now.UTC())
+		upsert := attemptRepository.Dialect.Upsert("login_attempts",
+			[]string{"attempt_key"}, []string{"failures", "last_failure_at", "locked_until"})
+
+		_, err = tx.Exec(upsert, key, updated.Failures, updated.LastFailure, updated.LockedUntil)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the failures of other keys are forgotten after they expire, so the table does not keep growing
+	_, err = attemptRepository.Exec("delete from login_attempts where last_failure_at < ? and locked_until < ?;",
+		now.UTC().Add(-policy.Lockout), now.UTC())
+
+	return &updated, err
+}
+
+func (attemptRepository *LoginAttemptRepository) ResetLoginAttempts(key string) error {
+	_, err := attemptRepository.Exec("delete from login_attempts where attempt_key = ?;", key)
+	return err
+}
+
+// findLoginAttempts reads the attempts of the key either in or outside of a transaction
+func findLoginAttempts(queryRow func(query string, args ...interface{}) *sql.Row, key string) (*users.LoginAttempts, error) {
+	attempts := &users.LoginAttempts{}
+
+	err := queryRow("select failures, last_failure_at, locked_until from login_attempts where attempt_key = ?;", key).
+		Scan(&attempts.Failures, &attempts.LastFailure, &attempts.LockedUntil)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"database/sql"
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"time"
+)
+
+type SessionRepository struct {
+	*sql.DB
+	Dialect db.Dialect
+}
+
+func GetSessionRepository() users.SessionRepository {
+	return &SessionRepository{DB: db.Get(), Dialect: db.GetDialect()}
+}
+
+func (sessionRepository *SessionRepository) CreateSession(session *users.Session) error {
+	_, err := sessionRepository.Exec("insert into sessions(id, user_id, refresh_token_hash, created_at, expires_at)"+
+		"values(?,?,?,?,?);", session.ID, session.User.ID, session.RefreshTokenHash, session.CreatedAt, session.ExpiresAt)
+	return err
+}
+
+func (sessionRepository *SessionRepository) FindSessionByRefreshToken(tokenHash string) (*users.Session, bool, error) {
+	session := &users.Session{}
+	var previousHash sql.NullString
+	var revokedAt sql.NullTime
+
+	row := sessionRepository.QueryRow("select s.id, s.refresh_token_hash, s.previous_token_hash, s.created_at, "+
+		"s.expires_at, s.revoked_at, u.id, u.name, u.email, u.role, "+
+		"u.email_verified_at is not null from sessions as s join users as u on u.id = s.user_id "+
+		"where s.refresh_token_hash = ? or s.previous_token_hash = ?;", tokenHash, tokenHash)
+	err := row.Scan(&session.ID, &session.RefreshTokenHash, &previousHash, &session.CreatedAt, &session.ExpiresAt,
+		&revokedAt, &session.User.ID, &session.User.Name, &session.User.Email, &session.User.Role,
+		&session.User.EmailVerified)
+
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, session.RefreshTokenHash != tokenHash, nil
+}
+
+func (sessionRepository *SessionRepository) RotateRefreshToken(session *users.Session, tokenHash string, expiresAt time.Time) (bool, error) {
+	result, err := sessionRepository.Exec("update sessions set previous_token_hash = refresh_token_hash, "+
+		"refresh_token_hash = ?, expires_at = ? where id = ? and refresh_token_hash = ? and revoked_at is null;",
+		tokenHash, expiresAt, session.ID, session.RefreshTokenHash)
+	if err != nil {
+		return false, err
+	}
+
+	rotated, err := result.RowsAffected()
+	if err != nil || rotated == 0 {
+		return false, err
+	}
+
+	session.RefreshTokenHash = tokenHash
+	session.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (sessionRepository *SessionRepository) RevokeSession(id string) error {
+	_, err := sessionRepository.Exec("update sessions set revoked_at = ? where id = ? and revoked_at is null;",
+		time.Now().UTC(), id)
+	return err
+}
+
+func (sessionRepository *SessionRepository) RevokeUserSessions(userId uint) error {
+	_, err := sessionRepository.Exec("update sessions set revoked_at = ? where user_id = ? and revoked_at is null;",
+		time.Now().UTC(), userId)
+	return err
+}
+
+func (sessionRepository *SessionRepository) IsSessionRevoked(id string) (bool, error) {
+	var revokedAt sql.NullTime
+	err := sessionRepository.QueryRow("select revoked_at from sessions where id = ?;", id).Scan(&revokedAt)
+
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+
+	if err != nil {
+		return true, err
+	}
+
+	return revokedAt.Valid, nil
+}
@@ -1,10 +1,26 @@
 package auth
 
-import "github.com/dgrijalva/jwt-go"
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+)
 
 type Token struct {
 	UserID uint
 	Name   string
 	Email  string
+	// Role is checked by the route middleware, a changed role takes effect when the user sessions are ended
+	Role users.Role
+	// Verified tells if the email of the user was verified when the token was issued
+	Verified bool
+	// SessionID allows the token to be revoked together with its session before it expires
+	SessionID string
+	*jwt.StandardClaims
+}
+
+// ActionClaims are the claims of a single use token sent by email, the Id claim identifies the stored token
+type ActionClaims struct {
+	UserID  uint
+	Purpose string
 	*jwt.StandardClaims
 }
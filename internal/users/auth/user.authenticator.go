@@ -1,118 +1,312 @@
 package auth
 
 import (
-	"context"
-	"crypto/rsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/krasimiraMilkova/cookit/internal/appconfig"
+	"github.com/krasimiraMilkova/cookit/internal/problem"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
 	"github.com/krasimiraMilkova/cookit/pkg/users"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
-type JwtAuthenticator struct{}
+type JwtAuthenticator struct {
+	Sessions     users.SessionRepository
+	ActionTokens users.ActionTokenRepository
+	Keys         *KeySet
+}
 
 const (
-	privateKeyPath = "/keys/app.rsa"
-	publicKeyPath  = "/keys/app.rsa.pub"
-	TokenName      = "cookit-access-token"
-	Expiration     = 30 * time.Minute
+	keysDir           = "/keys"
+	TokenName         = "cookit-access-token"
+	RefreshTokenName  = "cookit-refresh-token"
+	Expiration        = 30 * time.Minute
+	RefreshExpiration = 30 * 24 * time.Hour
 )
 
-var (
-	publicKey  *rsa.PublicKey
-	privateKey *rsa.PrivateKey
-)
+// actionExpirations are the lifetimes of the single use tokens sent by email for every purpose
+var actionExpirations = map[string]time.Duration{
+	users.PurposeVerifyEmail:   48 * time.Hour,
+	users.PurposeResetPassword: time.Hour,
+}
 
 var authenticator *JwtAuthenticator
 
 func GetAuthenticator() *JwtAuthenticator {
 	if authenticator == nil {
-		authenticator = &JwtAuthenticator{}
-		initKeys()
+		config := appconfig.Get()
+
+		keys, err := LoadKeySet(config.GetProjectDir()+keysDir, config.GetSigningKeyID())
+		if err != nil {
+			log.Fatalf("Error loading the token signing keys, %s", err)
+		}
+
+		authenticator = &JwtAuthenticator{
+			Sessions:     GetSessionRepository(),
+			ActionTokens: GetActionTokenRepository(),
+			Keys:         keys,
+		}
 	}
 
 	return authenticator
 }
 
-func initKeys() {
-	projectDir := appconfig.Get().GetProjectDir()
+func (jwtAuth JwtAuthenticator) StartSession(user *users.User) (*users.Tokens, error) {
+	sessionId, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	session := &users.Session{
+		ID:               sessionId,
+		User:             *user,
+		RefreshTokenHash: hashToken(refreshToken),
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(RefreshExpiration),
+	}
+
+	if err = jwtAuth.Sessions.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	return jwtAuth.issueTokens(session, refreshToken)
+}
 
-	privateKeyFileContent, err := ioutil.ReadFile(projectDir + privateKeyPath)
+func (jwtAuth JwtAuthenticator) RefreshSession(refreshToken string) (*users.Tokens, error) {
+	session, rotated, err := jwtAuth.Sessions.FindSessionByRefreshToken(hashToken(refreshToken))
 	if err != nil {
-		log.Fatal(err)
-		return
+		return nil, err
 	}
 
-	privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(privateKeyFileContent)
+	if session == nil || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, users.ErrInvalidRefreshToken
+	}
+
+	// a rotated token is used again only if it was copied, so the whole session is no longer trusted
+	if rotated {
+		if err = jwtAuth.Sessions.RevokeSession(session.ID); err != nil {
+			return nil, err
+		}
+		return nil, users.ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := randomToken(32)
 	if err != nil {
-		log.Fatal(err)
-		return
+		return nil, err
 	}
 
-	publicKeyFileContent, err := ioutil.ReadFile(projectDir + publicKeyPath)
+	ok, err := jwtAuth.Sessions.RotateRefreshToken(session, hashToken(newRefreshToken), time.Now().UTC().Add(RefreshExpiration))
 	if err != nil {
-		log.Fatal(err)
-		return
+		return nil, err
+	}
+
+	if !ok {
+		return nil, users.ErrInvalidRefreshToken
+	}
+
+	return jwtAuth.issueTokens(session, newRefreshToken)
+}
+
+func (jwtAuth JwtAuthenticator) EndSession(sessionId string) error {
+	return jwtAuth.Sessions.RevokeSession(sessionId)
+}
+
+func (jwtAuth JwtAuthenticator) EndAllSessions(userId uint) error {
+	return jwtAuth.Sessions.RevokeUserSessions(userId)
+}
+
+func (jwtAuth JwtAuthenticator) IssueActionToken(user *users.User, purpose string) (string, error) {
+	expiration, ok := actionExpirations[purpose]
+	if !ok {
+		return "", errors.New("unknown action token purpose " + purpose)
 	}
 
-	publicKey, err = jwt.ParseRSAPublicKeyFromPEM(publicKeyFileContent)
+	id, err := randomToken(16)
 	if err != nil {
-		log.Fatal(err)
-		return
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	token := &users.ActionToken{ID: id, UserID: user.ID, Purpose: purpose, ExpiresAt: now.Add(expiration)}
+	if err = jwtAuth.ActionTokens.CreateActionToken(token); err != nil {
+		return "", err
 	}
+
+	return jwtAuth.Keys.Sign(&ActionClaims{
+		UserID:  user.ID,
+		Purpose: purpose,
+		StandardClaims: &jwt.StandardClaims{
+			Id:        id,
+			ExpiresAt: token.ExpiresAt.Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	})
 }
 
-func (jwtAuth JwtAuthenticator) GenerateTokenForUser(user *users.User) (string, error) {
+func (jwtAuth JwtAuthenticator) ConsumeActionToken(tokenString string, purpose string) (uint, error) {
+	claims := &ActionClaims{StandardClaims: &jwt.StandardClaims{}}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, jwtAuth.Keys.Keyfunc)
+	if err != nil || claims.Purpose != purpose || claims.Id == "" {
+		return 0, users.ErrInvalidActionToken
+	}
+
+	used, err := jwtAuth.ActionTokens.UseActionToken(claims.Id, purpose)
+	if err != nil {
+		return 0, err
+	}
+
+	if !used {
+		return 0, users.ErrInvalidActionToken
+	}
+
+	return claims.UserID, nil
+}
+
+// issueTokens signs a new access token for the session and pairs it with the refresh token
+func (jwtAuth JwtAuthenticator) issueTokens(session *users.Session, refreshToken string) (*users.Tokens, error) {
+	expiresAt := time.Now().Add(Expiration)
 	token := &Token{
-		UserID: user.ID,
-		Name:   user.Name,
-		Email:  user.Email,
+		UserID:    session.User.ID,
+		Name:      session.User.Name,
+		Email:     session.User.Email,
+		Role:      session.User.Role,
+		Verified:  session.User.EmailVerified,
+		SessionID: session.ID,
 		StandardClaims: &jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(Expiration).Unix(),
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  time.Now().Unix(),
 		},
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, token)
-	tokenString, err := jwtToken.SignedString(privateKey)
+	tokenString, err := jwtAuth.Keys.Sign(token)
 	if err != nil {
-		return "", errors.New("error while signing generated jwt token")
+		return nil, errors.New("error while signing generated jwt token")
+	}
+
+	return &users.Tokens{
+		AccessToken:      tokenString,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// randomToken returns size random bytes encoded for use in urls and cookies
+func randomToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
 	}
 
-	return tokenString, nil
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashToken returns the hash under which a refresh token is stored, so a leaked database cannot be used to log in
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }
 
+// Errors of the requests whose access token cannot be used
+var (
+	errMissingToken = apperror.Forbidden("the request has no access token")
+	errInvalidToken = apperror.Forbidden("the access token is not valid, expired or revoked")
+)
+
 func (jwtAuth JwtAuthenticator) VerifyJWT(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		token := getToken(r)
 		if token == "" {
-			w.WriteHeader(http.StatusForbidden)
+			problem.Write(w, r, errMissingToken)
 			return
 		}
 
-		valid := isTokenValid(token)
-		if !valid {
-			w.WriteHeader(http.StatusForbidden)
+		user, sessionId, err := jwtAuth.userFromToken(token)
+
+		if err != nil {
+			problem.Write(w, r, errInvalidToken)
 			return
 		}
 
-		user, err := userFromToken(token)
+		revoked, err := jwtAuth.Sessions.IsSessionRevoked(sessionId)
 
 		if err != nil {
-			w.WriteHeader(http.StatusForbidden)
+			problem.Write(w, r, err)
+			return
+		}
+
+		if revoked {
+			problem.Write(w, r, errInvalidToken)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "user", user)
+		ctx := users.NewContext(r.Context(), user)
+		ctx = users.NewSessionContext(ctx, sessionId)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequireRole function returns a middleware handler letting through only requests of users
+// with the given role or a role ranked above it, it has to be used after VerifyJWT
+// Returns status Forbidden for other users
+func RequireRole(role users.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := users.FromContext(r.Context())
+
+			if !ok || !user.HasRole(role) {
+				problem.Write(w, r, apperror.Forbidden("the request needs the "+string(role)+" role"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireVerified function returns a middleware handler letting through only requests of users
+// with a verified email, it has to be used after VerifyJWT
+// Returns status Forbidden for other users
+func RequireVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := users.FromContext(r.Context())
+
+		if !ok || !user.EmailVerified {
+			problem.Write(w, r, apperror.Forbidden("the email of the user is not verified"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getToken returns the access token from the Authorization header, or from the cookie if there is no such header
+// A header with another scheme is not replaced by the cookie, so the request is rejected
 func getToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], users.TokenType) {
+			return ""
+		}
+		return strings.TrimSpace(parts[1])
+	}
+
 	var token = ""
 	cookie, err := r.Cookie(TokenName)
 	if err == nil {
@@ -121,34 +315,29 @@ func getToken(r *http.Request) string {
 	return token
 }
 
-func isTokenValid(token string) bool {
-	tk := &Token{}
-
-	_, err := jwt.ParseWithClaims(token, tk, func(token *jwt.Token) (interface{}, error) {
-		return publicKey, nil
-	})
-
-	if err != nil {
-		return false
-	}
-
-	return true
+// JWKS function handles requests for the public keys verifying the access tokens
+func (jwtAuth JwtAuthenticator) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	json.NewEncoder(w).Encode(jwtAuth.Keys.JWKS())
 }
 
-func userFromToken(tokenString string) (*users.User, error) {
-	token := &Token{}
+func (jwtAuth JwtAuthenticator) userFromToken(tokenString string) (*users.User, string, error) {
+	// the claims are validated before the signature, so they cannot be left nil for tokens without registered claims
+	token := &Token{StandardClaims: &jwt.StandardClaims{}}
 
-	_, err := jwt.ParseWithClaims(tokenString, token, func(token *jwt.Token) (interface{}, error) {
-		return publicKey, nil
-	})
+	_, err := jwt.ParseWithClaims(tokenString, token, jwtAuth.Keys.Keyfunc)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var usr = users.User{
 		ID:    token.UserID,
 		Email: token.Email,
 		Name:  token.Name,
+		Role:  token.Role,
+		// a verification after the token was issued is known once the token is refreshed
+		EmailVerified: token.Verified,
 	}
-	return &usr, err
+	return &usr, token.SessionID, err
 }
@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newMemoryAuthenticator(t *testing.T) (*JwtAuthenticator, *users.User) {
+	database, dialect, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err = database.Exec("insert into users(id, name, email, password)values(5, 'Chef', 'chef@test.com', 'x');"); err != nil {
+		t.Fatal("cannot insert user", err)
+	}
+
+	authenticator := &JwtAuthenticator{
+		Sessions:     &SessionRepository{DB: database, Dialect: dialect},
+		ActionTokens: &ActionTokenRepository{DB: database, Dialect: dialect},
+		Keys:         newKeySet(t, "app", rsaKey(t)),
+	}
+	return authenticator, &users.User{ID: 5, Name: "Chef", Email: "chef@test.com"}
+}
+
+// verify returns the status of a request with the access token to a handler protected by VerifyJWT
+func verify(authenticator *JwtAuthenticator, accessToken string) int {
+	req, _ := http.NewRequest("GET", "/recipe", nil)
+	req.AddCookie(&http.Cookie{Name: TokenName, Value: accessToken})
+	rr := httptest.NewRecorder()
+
+	authenticator.VerifyJWT(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := users.SessionFromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})).ServeHTTP(rr, req)
+
+	return rr.Code
+}
+
+func TestJwtAuthenticator_RefreshSession(t *testing.T) {
+	authenticator, user := newMemoryAuthenticator(t)
+
+	tokens, err := authenticator.StartSession(user)
+	if err != nil {
+		t.Fatal("unexpected error on start", err)
+	}
+
+	if status := verify(authenticator, tokens.AccessToken); status != http.StatusOK {
+		t.Errorf("Got status = %v for a new session but wanted %v", status, http.StatusOK)
+	}
+
+	refreshed, err := authenticator.RefreshSession(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal("unexpected error on refresh", err)
+	}
+
+	if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Error("expected the refresh token to be rotated")
+	}
+
+	if status := verify(authenticator, refreshed.AccessToken); status != http.StatusOK {
+		t.Errorf("Got status = %v for a refreshed session but wanted %v", status, http.StatusOK)
+	}
+
+	// reusing the rotated token revokes the session, so the newest tokens stop working too
+	if _, err = authenticator.RefreshSession(tokens.RefreshToken); err != users.ErrInvalidRefreshToken {
+		t.Errorf("Got error = %v on reuse but wanted %v", err, users.ErrInvalidRefreshToken)
+	}
+
+	if _, err = authenticator.RefreshSession(refreshed.RefreshToken); err != users.ErrInvalidRefreshToken {
+		t.Errorf("Got error = %v after revocation but wanted %v", err, users.ErrInvalidRefreshToken)
+	}
+
+	if status := verify(authenticator, refreshed.AccessToken); status != http.StatusForbidden {
+		t.Errorf("Got status = %v for a revoked session but wanted %v", status, http.StatusForbidden)
+	}
+
+	if _, err = authenticator.RefreshSession("unknown"); err != users.ErrInvalidRefreshToken {
+		t.Errorf("Got error = %v for an unknown token but wanted %v", err, users.ErrInvalidRefreshToken)
+	}
+}
+
+func TestJwtAuthenticator_EndSessions(t *testing.T) {
+	authenticator, user := newMemoryAuthenticator(t)
+
+	first, _ := authenticator.StartSession(user)
+	second, _ := authenticator.StartSession(user)
+	third, _ := authenticator.StartSession(user)
+
+	_, sessionId, err := authenticator.userFromToken(first.AccessToken)
+	if err != nil {
+		t.Fatal("cannot parse access token", err)
+	}
+
+	if err = authenticator.EndSession(sessionId); err != nil {
+		t.Fatal("unexpected error on end", err)
+	}
+
+	if status := verify(authenticator, first.AccessToken); status != http.StatusForbidden {
+		t.Errorf("Got status = %v after logout but wanted %v", status, http.StatusForbidden)
+	}
+
+	if status := verify(authenticator, second.AccessToken); status != http.StatusOK {
+		t.Errorf("Got status = %v for another session but wanted %v", status, http.StatusOK)
+	}
+
+	if err = authenticator.EndAllSessions(user.ID); err != nil {
+		t.Fatal("unexpected error on end all", err)
+	}
+
+	for _, tokens := range []*users.Tokens{second, third} {
+		if status := verify(authenticator, tokens.AccessToken); status != http.StatusForbidden {
+			t.Errorf("Got status = %v after logout from all sessions but wanted %v", status, http.StatusForbidden)
+		}
+
+		if _, err := authenticator.RefreshSession(tokens.RefreshToken); err != users.ErrInvalidRefreshToken {
+			t.Errorf("Got error = %v after logout from all sessions but wanted %v", err, users.ErrInvalidRefreshToken)
+		}
+	}
+}
+
+func TestGetToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		cookie        string
+		expected      string
+	}{
+		{name: "Cookie", cookie: "cookieToken", expected: "cookieToken"},
+		{name: "Bearer header", authorization: "Bearer headerToken", expected: "headerToken"},
+		{name: "Header before cookie", authorization: "bearer headerToken", cookie: "cookieToken", expected: "headerToken"},
+		{name: "Other scheme", authorization: "Basic dXNlcjpwYXNz", cookie: "cookieToken", expected: ""},
+		{name: "Missing token", authorization: "Bearer", expected: ""},
+		{name: "No token", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/recipe", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: TokenName, Value: test.cookie})
+			}
+
+			if token := getToken(req); token != test.expected {
+				t.Errorf("Got token = %v but wanted %v", token, test.expected)
+			}
+		})
+	}
+}
+
+func TestJwtAuthenticator_VerifyBearerToken(t *testing.T) {
+	authenticator, user := newMemoryAuthenticator(t)
+
+	tokens, err := authenticator.StartSession(user)
+	if err != nil {
+		t.Fatal("unexpected error on start", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/recipe", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	rr := httptest.NewRecorder()
+
+	authenticator.VerifyJWT(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if found, ok := users.FromContext(r.Context()); !ok || found.ID != user.ID {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Got status = %v for a bearer token but wanted %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name               string
+		user               *users.User
+		expectedStatusCode int
+	}{
+		{name: "Admin", user: &users.User{ID: 1, Role: users.RoleAdmin}, expectedStatusCode: http.StatusOK},
+		{name: "Moderator", user: &users.User{ID: 2, Role: users.RoleModerator}, expectedStatusCode: http.StatusForbidden},
+		{name: "User", user: &users.User{ID: 3, Role: users.RoleUser}, expectedStatusCode: http.StatusForbidden},
+		{name: "Missing user", expectedStatusCode: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/admin/users", nil)
+			if test.user != nil {
+				req = req.WithContext(users.NewContext(req.Context(), test.user))
+			}
+			rr := httptest.NewRecorder()
+
+			RequireRole(users.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatusCode {
+				t.Errorf("Got status = %v but wanted %v", rr.Code, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestJwtAuthenticator_RoleClaim(t *testing.T) {
+	authenticator, user := newMemoryAuthenticator(t)
+	user.Role = users.RoleModerator
+
+	tokens, err := authenticator.StartSession(user)
+	if err != nil {
+		t.Fatal("unexpected error on start", err)
+	}
+
+	found, _, err := authenticator.userFromToken(tokens.AccessToken)
+	if err != nil || found.Role != users.RoleModerator {
+		t.Errorf("Got user = %v, %v but wanted role %v", found, err, users.RoleModerator)
+	}
+
+	// refreshed tokens carry the role stored for the user
+	refreshed, err := authenticator.RefreshSession(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal("unexpected error on refresh", err)
+	}
+
+	found, _, err = authenticator.userFromToken(refreshed.AccessToken)
+	if err != nil || found.Role != users.RoleUser {
+		t.Errorf("Got user = %v, %v but wanted role %v", found, err, users.RoleUser)
+	}
+}
+
+func TestJwtAuthenticator_ActionToken(t *testing.T) {
+	authenticator, user := newMemoryAuthenticator(t)
+
+	first, err := authenticator.IssueActionToken(user, users.PurposeVerifyEmail)
+	if err != nil {
+		t.Fatal("unexpected error on issue", err)
+	}
+
+	second, _ := authenticator.IssueActionToken(user, users.PurposeVerifyEmail)
+	reset, _ := authenticator.IssueActionToken(user, users.PurposeResetPassword)
+
+	// a new token for the same purpose replaces the older ones
+	if _, err = authenticator.ConsumeActionToken(first, users.PurposeVerifyEmail); err != users.ErrInvalidActionToken {
+		t.Errorf("Got error = %v for a replaced token but wanted %v", err, users.ErrInvalidActionToken)
+	}
+
+	if _, err = authenticator.ConsumeActionToken(second, users.PurposeResetPassword); err != users.ErrInvalidActionToken {
+		t.Errorf("Got error = %v for another purpose but wanted %v", err, users.ErrInvalidActionToken)
+	}
+
+	userId, err := authenticator.ConsumeActionToken(second, users.PurposeVerifyEmail)
+	if err != nil || userId != user.ID {
+		t.Errorf("Got user id = %v, %v but wanted %v", userId, err, user.ID)
+	}
+
+	if _, err = authenticator.ConsumeActionToken(second, users.PurposeVerifyEmail); err != users.ErrInvalidActionToken {
+		t.Errorf("Got error = %v on reuse but wanted %v", err, users.ErrInvalidActionToken)
+	}
+
+	if userId, err = authenticator.ConsumeActionToken(reset, users.PurposeResetPassword); err != nil || userId != user.ID {
+		t.Errorf("Got user id = %v, %v for the reset token but wanted %v", userId, err, user.ID)
+	}
+
+	// an action token is not an access token
+	if status := verify(authenticator, reset); status != http.StatusForbidden {
+		t.Errorf("Got status = %v for an action token but wanted %v", status, http.StatusForbidden)
+	}
+
+	if _, err = authenticator.IssueActionToken(user, "unknown"); err == nil {
+		t.Error("expected an unknown purpose to be rejected")
+	}
+}
+
+func TestJwtAuthenticator_ExpiredActionToken(t *testing.T) {
+	authenticator, user := newMemoryAuthenticator(t)
+
+	expired := &users.ActionToken{
+		ID:        "expired",
+		UserID:    user.ID,
+		Purpose:   users.PurposeResetPassword,
+		ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	}
+	if err := authenticator.ActionTokens.CreateActionToken(expired); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	if used, err := authenticator.ActionTokens.UseActionToken(expired.ID, expired.Purpose); used || err != nil {
+		t.Errorf("Got used = %v, %v for an expired token but wanted false", used, err)
+	}
+}
+
+func TestRequireVerified(t *testing.T) {
+	tests := []struct {
+		name               string
+		user               *users.User
+		expectedStatusCode int
+	}{
+		{name: "Verified", user: &users.User{ID: 1, EmailVerified: true}, expectedStatusCode: http.StatusOK},
+		{name: "Unverified", user: &users.User{ID: 2}, expectedStatusCode: http.StatusForbidden},
+		{name: "Missing user", expectedStatusCode: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/v1/recipe", nil)
+			if test.user != nil {
+				req = req.WithContext(users.NewContext(req.Context(), test.user))
+			}
+			rr := httptest.NewRecorder()
+
+			RequireVerified(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedStatusCode {
+				t.Errorf("Got status = %v but wanted %v", rr.Code, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestJwtAuthenticator_VerifiedClaim(t *testing.T) {
+	authenticator, user := newMemoryAuthenticator(t)
+
+	tokens, err := authenticator.StartSession(user)
+	if err != nil {
+		t.Fatal("unexpected error on start", err)
+	}
+
+	if found, _, err := authenticator.userFromToken(tokens.AccessToken); err != nil || found.EmailVerified {
+		t.Errorf("Got user = %v, %v but wanted an unverified user", found, err)
+	}
+
+	if _, err = authenticator.Sessions.(*SessionRepository).Exec("update users set email_verified_at = ? where id = ?;",
+		time.Now().UTC(), user.ID); err != nil {
+		t.Fatal("cannot verify user", err)
+	}
+
+	// the verification shows in the tokens issued after it
+	refreshed, err := authenticator.RefreshSession(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal("unexpected error on refresh", err)
+	}
+
+	if found, _, err := authenticator.userFromToken(refreshed.AccessToken); err != nil || !found.EmailVerified {
+		t.Errorf("Got user = %v, %v but wanted a verified user", found, err)
+	}
+}
@@ -2,23 +2,59 @@ package service
 
 import (
 	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/krasimiraMilkova/cookit/internal/appconfig"
+	"github.com/krasimiraMilkova/cookit/internal/mailer"
+	"github.com/krasimiraMilkova/cookit/internal/problem"
+	rs "github.com/krasimiraMilkova/cookit/internal/recipes/service"
+	"github.com/krasimiraMilkova/cookit/internal/request"
 	"github.com/krasimiraMilkova/cookit/internal/users/auth"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/mail"
+	"github.com/krasimiraMilkova/cookit/pkg/recipes"
 	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
 type UserService struct {
 	UserRepository    users.UserRepository
 	UserAuthenticator users.UserAuthenticator
+	RecipeRepository  recipes.RecipeRepository
+	Mailer            mail.Mailer
+	LoginLimiter      users.LoginLimiter
+	// AppURL is the public url of the app used in the links sent by email
+	AppURL string
+	// SecureCookies makes the token cookies be sent only over https
+	SecureCookies bool
 }
 
+// errInvalidUserId is written when the user id path variable is not a positive number
+var errInvalidUserId = apperror.Field("id", "must be a positive number")
+
 var usersService *UserService
 
 func Get() *UserService {
 	if usersService == nil {
-		usersService = &UserService{UserRepository: GetUsersRepository(), UserAuthenticator: auth.GetAuthenticator()}
+		usersService = &UserService{
+			UserRepository:    GetUsersRepository(),
+			UserAuthenticator: auth.GetAuthenticator(),
+			RecipeRepository:  rs.GetRecipeRepository(),
+			Mailer:            mailer.Get(),
+			LoginLimiter:      auth.GetLoginLimiter(),
+			AppURL:            appconfig.Get().GetAppURL(),
+			SecureCookies:     appconfig.Get().GetSecureCookies(),
+		}
 	}
 
 	return usersService
@@ -26,61 +62,682 @@ func Get() *UserService {
 
 func (us *UserService) CreateUser(w http.ResponseWriter, r *http.Request) {
 	user := &users.User{}
-	err := json.NewDecoder(r.Body).Decode(user)
 
-	if err != nil {
-		log.Print("Error occurred when decoding user payload", err.Error())
-		w.WriteHeader(http.StatusBadRequest)
+	if !request.DecodeValid(w, r, "user", user) {
 		return
 	}
 
 	existUser := us.UserRepository.ExistUser(user.Email)
 
 	if existUser {
-		log.Print("User already exists")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, users.ErrEmailTaken)
 		return
 	}
 
 	if err := us.UserRepository.CreateUser(user); err != nil {
-		log.Print("Error occurred when creating a user", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
+	// the user can ask for another email if this one is not sent
+	if err := us.sendVerificationEmail(user); err != nil {
+		log.Print("Error occurred when sending the verification email ", err.Error())
+	}
+
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (us *UserService) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	payload := users.TokenPayload{Token: r.URL.Query().Get("token")}
+
+	if payload.Token == "" {
+		if !request.Decode(w, r, "token", &payload) {
+			return
+		}
+	}
+
+	userId, err := us.UserAuthenticator.ConsumeActionToken(payload.Token, users.PurposeVerifyEmail)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err = us.UserRepository.MarkEmailVerified(userId); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (us *UserService) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	contextUser, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
+	user, err := us.UserRepository.FindUserById(contextUser.ID)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if user == nil {
+		problem.Write(w, r, users.ErrUserNotFound)
+		return
+	}
+
+	if user.EmailVerified {
+		problem.Write(w, r, apperror.Conflict("the email is already verified"))
+		return
+	}
+
+	if err = us.sendVerificationEmail(user); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (us *UserService) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	payload := users.EmailPayload{}
+
+	if !request.DecodeValid(w, r, "email", &payload) {
+		return
+	}
+
+	user, err := us.UserRepository.FindUserByEmail(payload.Email)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	// the response is the same for unknown emails, so it does not tell who has an account
+	if user != nil {
+		if err = us.sendPasswordResetEmail(user); err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (us *UserService) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	payload := users.PasswordResetPayload{}
+
+	if !request.DecodeValid(w, r, "password reset", &payload) {
+		return
+	}
+
+	userId, err := us.UserAuthenticator.ConsumeActionToken(payload.Token, users.PurposeResetPassword)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err = us.UserRepository.UpdatePassword(userId, payload.Password); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	// whoever knew the old password is logged out, and the reset proves the email belongs to the user
+	if err = us.UserAuthenticator.EndAllSessions(userId); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err = us.UserRepository.MarkEmailVerified(userId); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (us *UserService) CurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := us.findCurrentUser(w, r)
+	if !ok {
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
+
+func (us *UserService) UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := us.findCurrentUser(w, r)
+	if !ok {
+		return
+	}
+
+	payload := users.ProfilePayload{}
+	if !request.DecodeValid(w, r, "profile", &payload) {
+		return
+	}
+
+	if payload.Name != nil {
+		user.Name = *payload.Name
+	}
+
+	emailChanged := payload.Email != nil && *payload.Email != user.Email
+	if emailChanged {
+		user.Email = *payload.Email
+	}
+
+	if emailChanged {
+		owner, err := us.UserRepository.FindUserByEmail(user.Email)
+
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		if owner != nil {
+			problem.Write(w, r, users.ErrEmailTaken)
+			return
+		}
+	}
+
+	if err := us.UserRepository.UpdateUser(user); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if !emailChanged {
+		json.NewEncoder(w).Encode(user)
+		return
+	}
+
+	// the new email has to be verified, the user can ask for another email if this one is not sent
+	user.EmailVerified = false
+	if err := us.sendVerificationEmail(user); err != nil {
+		log.Print("Error occurred when sending the verification email ", err.Error())
+	}
+
+	// the tokens of every session still claim the old email is verified, so they are replaced with one unverified session
+	if err := us.UserAuthenticator.EndAllSessions(user.ID); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	tokens, err := us.UserAuthenticator.StartSession(user)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	us.writeTokens(w, tokens)
+}
+
+func (us *UserService) DeleteCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
+	payload := users.PasswordPayload{}
+	if !request.DecodeValid(w, r, "password", &payload) {
+		return
+	}
+
+	if !us.verifyPassword(w, r, user.ID, payload.Password) {
+		return
+	}
+
+	if err := us.UserRepository.DeleteUser(user.ID); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	us.clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (us *UserService) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	contextUser, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
+	payload := users.PasswordChangePayload{}
+
+	if !request.DecodeValid(w, r, "password change", &payload) {
+		return
+	}
+
+	if !us.verifyPassword(w, r, contextUser.ID, payload.CurrentPassword) {
+		return
+	}
+
+	if err := us.UserRepository.UpdatePassword(contextUser.ID, payload.NewPassword); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	// every session is ended and the current one is replaced, so only this client stays logged in
+	if err := us.UserAuthenticator.EndAllSessions(contextUser.ID); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	user, err := us.UserRepository.FindUserById(contextUser.ID)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if user == nil {
+		problem.Write(w, r, users.ErrUserNotFound)
+		return
+	}
+
+	tokens, err := us.UserAuthenticator.StartSession(user)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	us.writeTokens(w, tokens)
+}
+
+func (us *UserService) FindUserProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err != nil || id <= 0 {
+		problem.Write(w, r, errInvalidUserId)
+		return
+	}
+
+	limit, offset, err := pageParameters(r)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	user, err := us.UserRepository.FindUserById(uint(id))
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if user == nil {
+		problem.Write(w, r, users.ErrUserNotFound)
+		return
+	}
+
+	options := recipes.SearchOptions{Limit: limit, Offset: offset, SortBy: recipes.SortByCreatedAt, Descending: true}
+	found, total, err := us.RecipeRepository.FindRecipesByAuthor(user.ID, options)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if found == nil {
+		found = []recipes.RecipeSearchResult{}
+	}
+
+	json.NewEncoder(w).Encode(users.Profile{ID: user.ID, Name: user.Name, Recipes: found, RecipeCount: total})
+}
+
+// findCurrentUser fetches the user from the request context and writes the error status if it cannot
+func (us *UserService) findCurrentUser(w http.ResponseWriter, r *http.Request) (*users.User, bool) {
+	contextUser, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return nil, false
+	}
+
+	user, err := us.UserRepository.FindUserById(contextUser.ID)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return nil, false
+	}
+
+	if user == nil {
+		problem.Write(w, r, users.ErrUserNotFound)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// verifyPassword checks the password of the user and writes the error status if it is wrong
+func (us *UserService) verifyPassword(w http.ResponseWriter, r *http.Request, id uint, password string) bool {
+	err := us.UserRepository.VerifyPassword(id, password)
+
+	// the user is logged in, so a wrong password forbids the change instead of asking for credentials
+	if err == users.ErrInvalidCredentials {
+		err = apperror.Forbidden("the password is wrong")
+	}
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return false
+	}
+
+	return true
+}
+
+// sendVerificationEmail sends the user a link confirming their email
+func (us *UserService) sendVerificationEmail(user *users.User) error {
+	token, err := us.UserAuthenticator.IssueActionToken(user, users.PurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	return us.Mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your CookIt email",
+		Body: "Hi " + user.Name + ",\n\n" +
+			"confirm your email by opening the link below, until then you cannot post recipes, comments and ratings.\n\n" +
+			us.AppURL + "/verify-email?token=" + url.QueryEscape(token) + "\n\n" +
+			"The link expires in 48 hours.\n",
+	})
+}
+
+// sendPasswordResetEmail sends the user a token for setting a new password
+func (us *UserService) sendPasswordResetEmail(user *users.User) error {
+	token, err := us.UserAuthenticator.IssueActionToken(user, users.PurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	return us.Mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your CookIt password",
+		Body: "Hi " + user.Name + ",\n\n" +
+			"a new password for your account was requested. Set it with the token below, " +
+			"or ignore this email if you did not ask for it.\n\n" +
+			token + "\n\n" +
+			"The token expires in 1 hour and can be used only once.\n",
+	})
+}
+
+// clientAddress returns the ip address the request comes from
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
 func (us *UserService) Login(w http.ResponseWriter, r *http.Request) {
+	// the credentials are not validated, so the password policy does not tell anything about the account
 	user := &users.User{}
-	err := json.NewDecoder(r.Body).Decode(user)
+
+	if !request.Decode(w, r, "user", user) {
+		return
+	}
+
+	address := clientAddress(r)
+	wait, err := us.LoginLimiter.Check(user.Email, address)
 
 	if err != nil {
-		log.Print("Error occurred when decoding user payload", err.Error())
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, r, err)
+		return
+	}
+
+	// the same lockout applies to emails without an account, so it does not tell which emails exist
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		problem.Status(w, r, http.StatusTooManyRequests, "too many failed logins, try again later")
 		return
 	}
 
 	foundUser, err := us.UserRepository.FindUser(user.Email, user.Password)
 
+	if err == users.ErrInvalidCredentials {
+		if failErr := us.LoginLimiter.Fail(user.Email, address); failErr != nil {
+			log.Print("Error occurred when counting the failed login ", failErr.Error())
+		}
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err = us.LoginLimiter.Succeed(user.Email); err != nil {
+		log.Print("Error occurred when resetting the failed logins ", err.Error())
+	}
+
+	tokens, err := us.UserAuthenticator.StartSession(foundUser)
+
 	if err != nil {
-		log.Print("Error occurred when fetching user ", err.Error())
-		w.WriteHeader(http.StatusNotFound)
+		problem.Write(w, r, err)
 		return
 	}
 
-	token, err := us.UserAuthenticator.GenerateTokenForUser(foundUser)
+	us.writeTokens(w, tokens)
+}
+
+func (us *UserService) Refresh(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(auth.RefreshTokenName)
+
+	if err != nil || cookie.Value == "" {
+		problem.Write(w, r, apperror.Forbidden("the request has no refresh token"))
+		return
+	}
+
+	tokens, err := us.UserAuthenticator.RefreshSession(cookie.Value)
+
+	if err == users.ErrInvalidRefreshToken {
+		us.clearTokenCookies(w)
+	}
 
 	if err != nil {
-		log.Print("Error occurred when generating user token", err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
+	us.writeTokens(w, tokens)
+}
+
+func (us *UserService) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionId, ok := users.SessionFromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, apperror.Forbidden("the request has no session"))
+		return
+	}
+
+	if err := us.UserAuthenticator.EndSession(sessionId); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	us.clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (us *UserService) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	user, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
+	if err := us.UserAuthenticator.EndAllSessions(user.ID); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	us.clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (us *UserService) ListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := pageParameters(r)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	found, total, err := us.UserRepository.ListUsers(limit, offset)
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if found == nil {
+		found = []users.User{}
+	}
+
+	json.NewEncoder(w).Encode(users.UserPage{Items: found, Total: total})
+}
+
+func (us *UserService) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	admin, ok := users.FromContext(r.Context())
+
+	if !ok {
+		problem.Write(w, r, users.ErrMissingUser)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err != nil || id <= 0 {
+		problem.Write(w, r, errInvalidUserId)
+		return
+	}
+
+	payload := users.RolePayload{}
+
+	if !request.Decode(w, r, "role", &payload) {
+		return
+	}
+
+	if !payload.Role.Valid() {
+		problem.Write(w, r, apperror.Field("role", "must be one of user, moderator and admin"))
+		return
+	}
+
+	// an admin demoting themselves could leave nobody able to manage the users
+	if uint(id) == admin.ID {
+		problem.Write(w, r, apperror.Conflict("admins cannot change their own role"))
+		return
+	}
+
+	if err = us.UserRepository.UpdateUserRole(uint(id), payload.Role); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if err = us.UserAuthenticator.EndAllSessions(uint(id)); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	user, err := us.UserRepository.FindUserById(uint(id))
+
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if user == nil {
+		problem.Write(w, r, users.ErrUserNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
+
+// pageParameters reads the limit and offset query parameters of a listing request
+// Returns a validation error if any of them is invalid
+func pageParameters(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+	limit, offset := defaultPageLimit, 0
+
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPageLimit {
+			return 0, 0, apperror.Field("limit", "must be a number between 1 and "+strconv.Itoa(maxPageLimit))
+		}
+		limit = parsed
+	}
+
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, apperror.Field("offset", "must be a non-negative number")
+		}
+		offset = parsed
+	}
+
+	return limit, offset, nil
+}
+
+// writeTokens sends the access and refresh tokens as cookies, which are not readable by scripts,
+// and the access token in the body for clients using the Authorization header
+func (us *UserService) writeTokens(w http.ResponseWriter, tokens *users.Tokens) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.TokenName,
+		Value:    tokens.AccessToken,
+		Path:     "/",
+		Expires:  tokens.AccessExpiresAt,
+		HttpOnly: true,
+		Secure:   us.SecureCookies,
+		SameSite: http.SameSiteStrictMode,
+	})
+
 	http.SetCookie(w, &http.Cookie{
-		Name:    auth.TokenName,
-		Value:   token,
-		Path:    "/",
-		Expires: time.Now().Add(auth.Expiration),
+		Name:     auth.RefreshTokenName,
+		Value:    tokens.RefreshToken,
+		Path:     "/",
+		Expires:  tokens.RefreshExpiresAt,
+		HttpOnly: true,
+		Secure:   us.SecureCookies,
+		SameSite: http.SameSiteStrictMode,
 	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(users.AccessTokenResponse{
+		AccessToken: tokens.AccessToken,
+		ExpiresIn:   int(time.Until(tokens.AccessExpiresAt).Round(time.Second).Seconds()),
+		TokenType:   users.TokenType,
+	})
+}
+
+// clearTokenCookies makes the client drop the access and refresh token cookies
+func (us *UserService) clearTokenCookies(w http.ResponseWriter) {
+	for _, name := range []string{auth.TokenName, auth.RefreshTokenName} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			Expires:  time.Unix(0, 0),
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   us.SecureCookies,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
 }
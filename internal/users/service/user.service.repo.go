@@ -4,41 +4,85 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
 	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"golang.org/x/crypto/bcrypt"
 	"log"
+	"time"
 )
 
+// emailVerified selects if the email of the user is verified
+const emailVerified = "email_verified_at is not null"
+
 type UserRepository struct {
 	*sql.DB
+	Dialect db.Dialect
 }
 
 func GetUsersRepository() users.UserRepository {
-	return &UserRepository{db.Get()}
+	return &UserRepository{DB: db.Get(), Dialect: db.GetDialect()}
 }
 
 func (userRepository *UserRepository) CreateUser(user *users.User) error {
-	if user.Email == "" || user.Password == "" || user.Name == "" {
-		return errors.New("user cannot have empty fields")
+	if err := requireFields("invalid user", "name", user.Name, "email", user.Email, "password", user.Password); err != nil {
+		return err
 	}
 
-	pass, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	pass, err := encryptPassword(user.Password)
 	if err != nil {
-		log.Print(err)
-		return errors.New("password encryption failed")
+		return err
 	}
 
-	user.Password = string(pass)
+	user.Password = pass
 
-	_, err = userRepository.Exec("insert into users(name,email,password)values(?,?,?)", user.Name, user.Email, user.Password)
+	user.Role = users.RoleUser
+	user.EmailVerified = false
+	result, err := userRepository.Exec("insert into users(name,email,password,role)values(?,?,?,?)",
+		user.Name, user.Email, user.Password, user.Role)
 
 	if err != nil {
 		return err
 	}
 
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	user.ID = uint(id)
+	return nil
+}
+
+// requireFields checks that none of the values given after the names of their fields is empty
+// Returns a validation error with the message listing the empty fields
+func requireFields(message string, namesAndValues ...string) error {
+	var fields []apperror.FieldError
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		if namesAndValues[i+1] == "" {
+			fields = append(fields, apperror.FieldError{Field: namesAndValues[i], Message: "must not be empty"})
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperror.Validation(message, fields...)
+	}
+
 	return nil
 }
 
+// unknownUserPassword is compared with the passwords sent for emails without an account
+var unknownUserPassword, _ = bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost)
+
+func encryptPassword(password string) (string, error) {
+	pass, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Print(err)
+		return "", errors.New("password encryption failed")
+	}
+
+	return string(pass), nil
+}
+
 func (userRepository *UserRepository) ExistUser(email string) bool {
 	user := &users.User{}
 
@@ -61,21 +105,180 @@ func (userRepository *UserRepository) FindUser(email, password string) (*users.U
 	user := &users.User{}
 
 	if email == "" || password == "" {
-		return nil, errors.New("email or password cannot be empty")
+		return nil, users.ErrInvalidCredentials
 	}
 
-	row := userRepository.QueryRow("select id,name,email,password from users where email = ?", email)
+	row := userRepository.QueryRow("select id,name,email,password,role,"+emailVerified+" from users where email = ?", email)
 
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password)
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.EmailVerified)
 
 	if err == sql.ErrNoRows {
+		// the password is still compared, so an unknown email takes as long as a wrong password
+		bcrypt.CompareHashAndPassword(unknownUserPassword, []byte(password))
+		return nil, users.ErrInvalidCredentials
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return nil, errors.New("invalid password for email")
+		return nil, users.ErrInvalidCredentials
 	}
 
 	return user, nil
 }
+
+func (userRepository *UserRepository) FindUserById(id uint) (*users.User, error) {
+	return userRepository.findUser("id = ?", id)
+}
+
+func (userRepository *UserRepository) FindUserByEmail(email string) (*users.User, error) {
+	return userRepository.findUser("email = ?", email)
+}
+
+func (userRepository *UserRepository) findUser(condition string, value interface{}) (*users.User, error) {
+	user := &users.User{}
+
+	row := userRepository.QueryRow("select id,name,email,role,"+emailVerified+" from users where "+condition, value)
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.EmailVerified)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (userRepository *UserRepository) ListUsers(limit, offset int) ([]users.User, int, error) {
+	var total int
+	if err := userRepository.QueryRow("select count(*) from users").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := userRepository.Query("select id,name,email,role,"+emailVerified+" from users order by id limit ? offset ?",
+		limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []users.User
+	for rows.Next() {
+		user := users.User{}
+		if err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.EmailVerified); err != nil {
+			return nil, 0, err
+		}
+		result = append(result, user)
+	}
+
+	return result, total, rows.Err()
+}
+
+func (userRepository *UserRepository) UpdateUserRole(id uint, role users.Role) error {
+	return userRepository.updateUser(id, "role = ?", role)
+}
+
+func (userRepository *UserRepository) MarkEmailVerified(id uint) error {
+	return userRepository.updateUser(id, "email_verified_at = coalesce(email_verified_at, ?)", time.Now().UTC())
+}
+
+func (userRepository *UserRepository) UpdateUser(user *users.User) error {
+	if err := requireFields("invalid user", "name", user.Name, "email", user.Email); err != nil {
+		return err
+	}
+
+	// the verification is assigned first, since mysql compares the email after assigning it otherwise
+	return userRepository.updateUser(user.ID,
+		"email_verified_at = case when email = ? then email_verified_at else null end, name = ?, email = ?",
+		user.Email, user.Name, user.Email)
+}
+
+func (userRepository *UserRepository) VerifyPassword(id uint, password string) error {
+	var hash string
+	err := userRepository.QueryRow("select password from users where id = ?", id).Scan(&hash)
+
+	if err == sql.ErrNoRows {
+		return users.ErrUserNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if password == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return users.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+func (userRepository *UserRepository) DeleteUser(id uint) error {
+	return db.WithTransaction(userRepository.DB, func(tx *sql.Tx) error {
+		// the content of the user is referenced only by author id, so it is deleted before the user
+		for _, statement := range []string{
+			"delete from recipe_ratings where user_id = ?",
+			"delete from comments where author_id = ?",
+			"delete from recipes where author_id = ?",
+		} {
+			if _, err := tx.Exec(statement, id); err != nil {
+				return err
+			}
+		}
+
+		// the sessions and the tokens sent by email are deleted together with the user
+		result, err := tx.Exec("delete from users where id = ?", id)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if deleted == 0 {
+			return users.ErrUserNotFound
+		}
+
+		return nil
+	})
+}
+
+func (userRepository *UserRepository) UpdatePassword(id uint, password string) error {
+	if password == "" {
+		return apperror.Field("password", "must not be empty")
+	}
+
+	pass, err := encryptPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return userRepository.updateUser(id, "password = ?", pass)
+}
+
+// updateUser executes the assignments with the given values on the user with the given id
+// Returns ErrUserNotFound if there is no such user
+func (userRepository *UserRepository) updateUser(id uint, assignments string, values ...interface{}) error {
+	result, err := userRepository.Exec("update users set "+assignments+" where id = ?", append(values, id)...)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return users.ErrUserNotFound
+	}
+
+	return nil
+}
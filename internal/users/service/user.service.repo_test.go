@@ -0,0 +1,191 @@
+package service
+
+import (
+	"github.com/krasimiraMilkova/cookit/internal/db"
+	"github.com/krasimiraMilkova/cookit/pkg/users"
+	"testing"
+)
+
+func newMemoryUserRepository(t *testing.T) *UserRepository {
+	database, dialect, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal("cannot open memory storage", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return &UserRepository{DB: database, Dialect: dialect}
+}
+
+func TestUserRepository_Roles(t *testing.T) {
+	repository := newMemoryUserRepository(t)
+
+	for _, user := range []*users.User{
+		{Name: "First", Email: "first@test.com", Password: "secret", Role: users.RoleAdmin},
+		{Name: "Second", Email: "second@test.com", Password: "secret"},
+	} {
+		if err := repository.CreateUser(user); err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+
+	// the role sent on registration is ignored
+	found, err := repository.FindUser("first@test.com", "secret")
+	if err != nil || found.Role != users.RoleUser {
+		t.Fatalf("Got user = %v, %v but wanted a user with role %v", found, err, users.RoleUser)
+	}
+
+	if err = repository.UpdateUserRole(found.ID, users.RoleAdmin); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	if err = repository.UpdateUserRole(100, users.RoleAdmin); err != users.ErrUserNotFound {
+		t.Errorf("Got error = %v but wanted %v", err, users.ErrUserNotFound)
+	}
+
+	byId, err := repository.FindUserById(found.ID)
+	if err != nil || byId == nil || byId.Role != users.RoleAdmin || byId.Password != "" {
+		t.Errorf("Got user = %v, %v but wanted an admin without password", byId, err)
+	}
+
+	byEmail, err := repository.FindUserByEmail("second@test.com")
+	if err != nil || byEmail == nil || byEmail.Name != "Second" {
+		t.Errorf("Got user = %v, %v but wanted the second user", byEmail, err)
+	}
+
+	if missing, err := repository.FindUserById(100); missing != nil || err != nil {
+		t.Errorf("Got user = %v, %v but wanted none", missing, err)
+	}
+
+	page, total, err := repository.ListUsers(1, 1)
+	if err != nil || total != 2 || len(page) != 1 || page[0].Email != "second@test.com" || page[0].Role != users.RoleUser {
+		t.Errorf("Got page = %v, %v, %v but wanted the second user out of 2", page, total, err)
+	}
+}
+
+func TestUserRepository_VerificationAndPassword(t *testing.T) {
+	repository := newMemoryUserRepository(t)
+
+	user := &users.User{Name: "Chef", Email: "chef@test.com", Password: "secret"}
+	if err := repository.CreateUser(user); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	if found, err := repository.FindUserById(user.ID); err != nil || found == nil || found.EmailVerified {
+		t.Fatalf("Got user = %v, %v but wanted an unverified user", found, err)
+	}
+
+	if err := repository.MarkEmailVerified(user.ID); err != nil {
+		t.Fatal("unexpected error on verify", err)
+	}
+
+	if err := repository.UpdatePassword(user.ID, "newSecret"); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	if err := repository.UpdatePassword(user.ID, ""); err == nil {
+		t.Error("expected an empty password to be rejected")
+	}
+
+	if found, _ := repository.FindUser("chef@test.com", "secret"); found != nil {
+		t.Error("expected the old password to stop working")
+	}
+
+	found, err := repository.FindUser("chef@test.com", "newSecret")
+	if err != nil || found == nil || !found.EmailVerified {
+		t.Errorf("Got user = %v, %v but wanted a verified user with the new password", found, err)
+	}
+}
+
+func TestUserRepository_FindUserCredentials(t *testing.T) {
+	repository := newMemoryUserRepository(t)
+
+	if err := repository.CreateUser(&users.User{Name: "Chef", Email: "chef@test.com", Password: "secret"}); err != nil {
+		t.Fatal("unexpected error on create", err)
+	}
+
+	// an unknown email and a wrong password are not told apart
+	for _, credentials := range [][2]string{{"chef@test.com", "wrong"}, {"unknown@test.com", "secret"}, {"chef@test.com", ""}} {
+		if found, err := repository.FindUser(credentials[0], credentials[1]); found != nil || err != users.ErrInvalidCredentials {
+			t.Errorf("Got user = %v, %v for %v but wanted %v", found, err, credentials, users.ErrInvalidCredentials)
+		}
+	}
+
+	if found, err := repository.FindUser("chef@test.com", "secret"); err != nil || found == nil {
+		t.Errorf("Got user = %v, %v but wanted the user", found, err)
+	}
+}
+
+func TestUserRepository_UpdateAndDeleteUser(t *testing.T) {
+	repository := newMemoryUserRepository(t)
+
+	user := &users.User{Name: "Chef", Email: "chef@test.com", Password: "secret"}
+	other := &users.User{Name: "Cook", Email: "cook@test.com", Password: "secret"}
+	for _, created := range []*users.User{user, other} {
+		if err := repository.CreateUser(created); err != nil {
+			t.Fatal("unexpected error on create", err)
+		}
+	}
+	repository.MarkEmailVerified(user.ID)
+
+	// an unchanged email stays verified
+	user.Name = "Head chef"
+	if err := repository.UpdateUser(user); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	if found, _ := repository.FindUserById(user.ID); found.Name != "Head chef" || !found.EmailVerified {
+		t.Errorf("Got user = %v but wanted a verified user with the new name", found)
+	}
+
+	user.Email = "head@test.com"
+	if err := repository.UpdateUser(user); err != nil {
+		t.Fatal("unexpected error on update", err)
+	}
+
+	if found, _ := repository.FindUserByEmail("head@test.com"); found == nil || found.EmailVerified {
+		t.Errorf("Got user = %v but wanted an unverified user with the new email", found)
+	}
+
+	if err := repository.UpdateUser(&users.User{ID: 100, Name: "Nobody", Email: "nobody@test.com"}); err != users.ErrUserNotFound {
+		t.Errorf("Got error = %v but wanted %v", err, users.ErrUserNotFound)
+	}
+
+	if err := repository.VerifyPassword(user.ID, "wrong"); err != users.ErrInvalidCredentials {
+		t.Errorf("Got error = %v for a wrong password but wanted %v", err, users.ErrInvalidCredentials)
+	}
+
+	if err := repository.VerifyPassword(user.ID, "secret"); err != nil {
+		t.Errorf("Got error = %v for the password", err)
+	}
+
+	for _, statement := range []string{
+		"insert into recipes(id, title, author_id)values(1, 'Soup', 1), (2, 'Stew', 2);",
+		"insert into comments(recipe_id, comment, author_id)values(2, 'Tasty', 1), (2, 'Thanks', 2), (1, 'Nice', 2);",
+		"insert into recipe_ratings(recipe_id, user_id, rating, rated_at)values(2, 1, 5, '2021-05-01'), (1, 2, 4, '2021-05-01');",
+	} {
+		if _, err := repository.Exec(statement); err != nil {
+			t.Fatal("cannot insert content", err)
+		}
+	}
+
+	if err := repository.DeleteUser(user.ID); err != nil {
+		t.Fatal("unexpected error on delete", err)
+	}
+
+	if err := repository.DeleteUser(user.ID); err != users.ErrUserNotFound {
+		t.Errorf("Got error = %v for a deleted user but wanted %v", err, users.ErrUserNotFound)
+	}
+
+	// only the content of the other user on their own recipe is left
+	var recipes, comments, ratings int
+	repository.QueryRow("select count(*) from recipes;").Scan(&recipes)
+	repository.QueryRow("select count(*) from comments;").Scan(&comments)
+	repository.QueryRow("select count(*) from recipe_ratings;").Scan(&ratings)
+	if recipes != 1 || comments != 1 || ratings != 0 {
+		t.Errorf("Got %v recipes, %v comments and %v ratings but wanted 1, 1 and 0", recipes, comments, ratings)
+	}
+
+	if found, _ := repository.FindUserById(other.ID); found == nil {
+		t.Error("expected the other user to be kept")
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
 	"github.com/krasimiraMilkova/cookit/internal/users/auth"
 	"github.com/krasimiraMilkova/cookit/mocks"
+	"github.com/krasimiraMilkova/cookit/pkg/mail"
+	"github.com/krasimiraMilkova/cookit/pkg/recipes"
 	"github.com/krasimiraMilkova/cookit/pkg/users"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestUserService_CreateUser(t *testing.T) {
@@ -19,14 +24,21 @@ func TestUserService_CreateUser(t *testing.T) {
 
 	mockRepository := mocks.NewMockUserRepository(mockCtrl)
 	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+	mockMailer := mocks.NewMockMailer(mockCtrl)
 
-	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator}
+	service := UserService{
+		UserRepository:    mockRepository,
+		UserAuthenticator: mockAuthenticator,
+		Mailer:            mockMailer,
+		AppURL:            "http://cookit.test",
+	}
 
 	tests := []struct {
 		name               string
 		user               users.User
 		existUser          bool
 		repositoryError    string
+		mailError          error
 		expectedStatusCode int
 	}{
 		{
@@ -34,7 +46,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			user: users.User{
 				Name:     "Test",
 				Email:    "test@test.com",
-				Password: "test",
+				Password: "cookit42",
 			},
 			existUser:          false,
 			repositoryError:    "",
@@ -45,18 +57,46 @@ func TestUserService_CreateUser(t *testing.T) {
 			user: users.User{
 				Name:     "Test",
 				Email:    "test@test.com",
-				Password: "test",
+				Password: "cookit42",
 			},
 			existUser:          true,
 			repositoryError:    "",
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name: "Invalid email",
+			user: users.User{
+				Name:     "Test",
+				Email:    "Test <test@test.com>",
+				Password: "cookit42",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "Weak password",
+			user: users.User{
+				Name:     "Test",
+				Email:    "weak@test.com",
+				Password: "password",
+			},
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name: "Mail error",
+			user: users.User{
+				Name:     "Test",
+				Email:    "test@test.com",
+				Password: "cookit42",
+			},
+			mailError:          errors.New("connection refused"),
+			expectedStatusCode: http.StatusCreated,
+		},
 		{
 			name: "Repo error during create",
 			user: users.User{
 				Name:     "Test",
 				Email:    "test@test.com",
-				Password: "test",
+				Password: "cookit42",
 			},
 			existUser:          false,
 			repositoryError:    "some error",
@@ -66,17 +106,30 @@ func TestUserService_CreateUser(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			jsonUser, _ := json.Marshal(test.user)
+			jsonUser, _ := json.Marshal(map[string]string{
+				"name":     test.user.Name,
+				"email":    test.user.Email,
+				"password": test.user.Password,
+			})
 			req, _ := http.NewRequest("POST", "/register", strings.NewReader(string(jsonUser)))
 			rr := httptest.NewRecorder()
 
-			mockRepository.EXPECT().ExistUser(test.user.Email).Return(test.existUser)
+			if test.user.Email == "test@test.com" {
+				mockRepository.EXPECT().ExistUser(test.user.Email).Return(test.existUser)
+			}
 
-			if !test.existUser {
+			if test.user.Email == "test@test.com" && !test.existUser {
 				mockExpect := mockRepository.EXPECT().CreateUser(&test.user)
 
 				if test.repositoryError == "" {
 					mockExpect.Return(nil)
+					mockAuthenticator.EXPECT().IssueActionToken(&test.user, users.PurposeVerifyEmail).Return("verifyToken", nil)
+					mockMailer.EXPECT().Send(gomock.Any()).DoAndReturn(func(message mail.Message) error {
+						if message.To != test.user.Email || !strings.Contains(message.Body, "http://cookit.test/verify-email?token=verifyToken") {
+							t.Errorf("Got message = %v", message)
+						}
+						return test.mailError
+					})
 				} else {
 					mockExpect.Return(errors.New(test.repositoryError))
 				}
@@ -99,17 +152,20 @@ func TestUserService_Login(t *testing.T) {
 
 	mockRepository := mocks.NewMockUserRepository(mockCtrl)
 	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+	mockLimiter := mocks.NewMockLoginLimiter(mockCtrl)
 
-	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator}
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator, LoginLimiter: mockLimiter}
 
 	tests := []struct {
 		name               string
 		searchEmail        string
 		searchPassword     string
 		user               users.User
+		wait               time.Duration
 		repositoryError    error
 		authenticatorError error
 		expectedStatusCode int
+		expectedRetryAfter string
 	}{
 		{
 			name:           "Successful",
@@ -125,14 +181,31 @@ func TestUserService_Login(t *testing.T) {
 			authenticatorError: nil,
 			expectedStatusCode: http.StatusOK,
 		},
+		{
+			name:               "Invalid credentials",
+			searchEmail:        "test@test.com",
+			searchPassword:     "test",
+			user:               users.User{},
+			repositoryError:    users.ErrInvalidCredentials,
+			authenticatorError: nil,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Locked out",
+			searchEmail:        "test@test.com",
+			searchPassword:     "test",
+			wait:               1500 * time.Millisecond,
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedRetryAfter: "2",
+		},
 		{
 			name:               "Repo error",
 			searchEmail:        "test@test.com",
 			searchPassword:     "test",
 			user:               users.User{},
-			repositoryError:    errors.New("empty result set"),
+			repositoryError:    errors.New("connection lost"),
 			authenticatorError: nil,
-			expectedStatusCode: http.StatusNotFound,
+			expectedStatusCode: http.StatusInternalServerError,
 		},
 		{
 			name:               "Authenticator error",
@@ -147,18 +220,32 @@ func TestUserService_Login(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			loginUser := users.User{
-				Email:    test.searchEmail,
-				Password: test.searchPassword,
-			}
-			jsonUser, _ := json.Marshal(loginUser)
+			jsonUser, _ := json.Marshal(map[string]string{
+				"email":    test.searchEmail,
+				"password": test.searchPassword,
+			})
 			req, _ := http.NewRequest("POST", "/login", strings.NewReader(string(jsonUser)))
+			req.RemoteAddr = "192.0.2.1:1234"
 			rr := httptest.NewRecorder()
 
-			mockRepository.EXPECT().FindUser(test.searchEmail, test.searchPassword).Return(&test.user, test.repositoryError)
+			mockLimiter.EXPECT().Check(test.searchEmail, "192.0.2.1").Return(test.wait, nil)
+
+			if test.wait == 0 {
+				mockRepository.EXPECT().FindUser(test.searchEmail, test.searchPassword).Return(&test.user, test.repositoryError)
+			}
+
+			if test.repositoryError == users.ErrInvalidCredentials {
+				mockLimiter.EXPECT().Fail(test.searchEmail, "192.0.2.1").Return(nil)
+			}
+
+			if test.wait == 0 && test.repositoryError == nil {
+				mockLimiter.EXPECT().Succeed(test.searchEmail).Return(nil)
 
-			if test.expectedStatusCode != http.StatusNotFound {
-				mockAuthenticator.EXPECT().GenerateTokenForUser(&test.user).Return("someToken", test.authenticatorError)
+				var tokens *users.Tokens
+				if test.authenticatorError == nil {
+					tokens = &users.Tokens{AccessToken: "someToken", RefreshToken: "someRefreshToken"}
+				}
+				mockAuthenticator.EXPECT().StartSession(&test.user).Return(tokens, test.authenticatorError)
 			}
 
 			http.HandlerFunc(service.Login).ServeHTTP(rr, req)
@@ -168,16 +255,36 @@ func TestUserService_Login(t *testing.T) {
 				t.Fail()
 			}
 
+			if retryAfter := rr.Header().Get("Retry-After"); retryAfter != test.expectedRetryAfter {
+				t.Errorf("handler returned wrong Retry-After header: got %v want %v", retryAfter, test.expectedRetryAfter)
+			}
+
 			cookies := rr.Result().Cookies()
 
 			if test.expectedStatusCode == http.StatusOK {
-				if len(cookies) != 1 {
-					t.Errorf("expected 1 cookie got %v", len(cookies))
+				if len(cookies) != 2 {
+					t.Errorf("expected 2 cookies got %v", len(cookies))
+					t.FailNow()
+				}
+
+				if cookies[0].Name != auth.TokenName || cookies[1].Name != auth.RefreshTokenName {
+					t.Errorf("expected cookies with names %v and %v got %v and %v",
+						auth.TokenName, auth.RefreshTokenName, cookies[0].Name, cookies[1].Name)
 					t.Fail()
 				}
 
-				if cookies[0].Name != auth.TokenName {
-					t.Errorf("expected cookie with name %v got %v", cookies[0].Name, auth.TokenName)
+				for _, cookie := range cookies {
+					if !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+						t.Errorf("expected cookie %v to be HttpOnly and SameSite", cookie.Name)
+						t.Fail()
+					}
+				}
+
+				body := users.AccessTokenResponse{}
+				json.NewDecoder(rr.Body).Decode(&body)
+
+				if body.AccessToken != "someToken" || body.TokenType != users.TokenType {
+					t.Errorf("expected access token someToken of type %v got %v", users.TokenType, body)
 					t.Fail()
 				}
 			} else if len(cookies) > 0 {
@@ -187,3 +294,982 @@ func TestUserService_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_Refresh(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+
+	service := UserService{UserAuthenticator: mockAuthenticator}
+
+	tests := []struct {
+		name               string
+		refreshToken       string
+		authenticatorError error
+		expectedStatusCode int
+		expectedCookies    int
+	}{
+		{
+			name:               "Successful",
+			refreshToken:       "someRefreshToken",
+			expectedStatusCode: http.StatusOK,
+			expectedCookies:    2,
+		},
+		{
+			name:               "Missing refresh token",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Invalid refresh token",
+			refreshToken:       "reusedRefreshToken",
+			authenticatorError: users.ErrInvalidRefreshToken,
+			expectedStatusCode: http.StatusForbidden,
+			expectedCookies:    2,
+		},
+		{
+			name:               "Authenticator error",
+			refreshToken:       "someRefreshToken",
+			authenticatorError: errors.New("error during refresh"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/refresh", nil)
+			rr := httptest.NewRecorder()
+
+			if test.refreshToken != "" {
+				req.AddCookie(&http.Cookie{Name: auth.RefreshTokenName, Value: test.refreshToken})
+
+				var tokens *users.Tokens
+				if test.authenticatorError == nil {
+					tokens = &users.Tokens{AccessToken: "newToken", RefreshToken: "newRefreshToken"}
+				}
+				mockAuthenticator.EXPECT().RefreshSession(test.refreshToken).Return(tokens, test.authenticatorError)
+			}
+
+			http.HandlerFunc(service.Refresh).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+
+			cookies := rr.Result().Cookies()
+			if len(cookies) != test.expectedCookies {
+				t.Errorf("expected %v cookies got %v", test.expectedCookies, len(cookies))
+			} else if test.expectedStatusCode == http.StatusOK && cookies[1].Value != "newRefreshToken" {
+				t.Errorf("expected refresh token newRefreshToken got %v", cookies[1].Value)
+			}
+		})
+	}
+}
+
+func TestUserService_Logout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+
+	service := UserService{UserAuthenticator: mockAuthenticator}
+
+	tests := []struct {
+		name               string
+		sessionId          string
+		authenticatorError error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			sessionId:          "someSession",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Missing session",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Authenticator error",
+			sessionId:          "someSession",
+			authenticatorError: errors.New("error during revoke"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/logout", nil)
+			rr := httptest.NewRecorder()
+
+			if test.sessionId != "" {
+				req = req.WithContext(users.NewSessionContext(req.Context(), test.sessionId))
+				mockAuthenticator.EXPECT().EndSession(test.sessionId).Return(test.authenticatorError)
+			}
+
+			http.HandlerFunc(service.Logout).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestUserService_LogoutAll(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+
+	service := UserService{UserAuthenticator: mockAuthenticator}
+
+	tests := []struct {
+		name               string
+		user               *users.User
+		authenticatorError error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			user:               &users.User{ID: 1, Name: "Test"},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Missing user",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Authenticator error",
+			user:               &users.User{ID: 1, Name: "Test"},
+			authenticatorError: errors.New("error during revoke"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/logout-all", nil)
+			rr := httptest.NewRecorder()
+
+			if test.user != nil {
+				req = req.WithContext(users.NewContext(req.Context(), test.user))
+				mockAuthenticator.EXPECT().EndAllSessions(test.user.ID).Return(test.authenticatorError)
+			}
+
+			http.HandlerFunc(service.LogoutAll).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository}
+
+	found := []users.User{{ID: 1, Name: "Admin", Email: "admin@test.com", Role: users.RoleAdmin}}
+
+	tests := []struct {
+		name               string
+		query              string
+		expectedLimit      int
+		expectedOffset     int
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Default page",
+			expectedLimit:      defaultPageLimit,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Selected page",
+			query:              "?limit=5&offset=10",
+			expectedLimit:      5,
+			expectedOffset:     10,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid limit",
+			query:              "?limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid offset",
+			query:              "?offset=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repository error",
+			expectedLimit:      defaultPageLimit,
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/admin/users"+test.query, nil)
+			rr := httptest.NewRecorder()
+
+			if test.expectedLimit != 0 {
+				mockRepository.EXPECT().ListUsers(test.expectedLimit, test.expectedOffset).Return(found, 1, test.repositoryError)
+			}
+
+			http.HandlerFunc(service.ListUsers).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				page := users.UserPage{}
+				json.NewDecoder(rr.Body).Decode(&page)
+
+				if page.Total != 1 || len(page.Items) != 1 || page.Items[0].Role != users.RoleAdmin {
+					t.Errorf("Got page = %v", page)
+				}
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateUserRole(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator}
+
+	admin := &users.User{ID: 1, Name: "Admin", Role: users.RoleAdmin}
+
+	tests := []struct {
+		name               string
+		id                 string
+		payload            string
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			id:                 "2",
+			payload:            `{"role":"moderator"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Cannot parse id",
+			id:                 "a",
+			payload:            `{"role":"moderator"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown role",
+			id:                 "2",
+			payload:            `{"role":"owner"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Own role",
+			id:                 "1",
+			payload:            `{"role":"user"}`,
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:               "User not found",
+			id:                 "3",
+			payload:            `{"role":"admin"}`,
+			repositoryError:    users.ErrUserNotFound,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Repository error",
+			id:                 "2",
+			payload:            `{"role":"admin"}`,
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PUT", "/api/v1/admin/users/"+test.id+"/role", strings.NewReader(test.payload))
+			req = mux.SetURLVars(req.WithContext(users.NewContext(req.Context(), admin)),
+				map[string]string{"id": test.id})
+			rr := httptest.NewRecorder()
+
+			payload := users.RolePayload{}
+			json.Unmarshal([]byte(test.payload), &payload)
+
+			if id, err := strconv.Atoi(test.id); err == nil && payload.Role.Valid() && uint(id) != admin.ID {
+				mockRepository.EXPECT().UpdateUserRole(uint(id), payload.Role).Return(test.repositoryError)
+
+				if test.repositoryError == nil {
+					mockAuthenticator.EXPECT().EndAllSessions(uint(id)).Return(nil)
+					mockRepository.EXPECT().FindUserById(uint(id)).Return(&users.User{ID: uint(id), Role: payload.Role}, nil)
+				}
+			}
+
+			http.HandlerFunc(service.UpdateUserRole).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				user := users.User{}
+				json.NewDecoder(rr.Body).Decode(&user)
+
+				if user.Role != users.RoleModerator {
+					t.Errorf("Got role = %v but wanted %v", user.Role, users.RoleModerator)
+				}
+			}
+		})
+	}
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator}
+
+	tests := []struct {
+		name               string
+		query              string
+		body               string
+		token              string
+		authenticatorError error
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful with query",
+			query:              "?token=verifyToken",
+			token:              "verifyToken",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Successful with payload",
+			body:               `{"token":"verifyToken"}`,
+			token:              "verifyToken",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Invalid payload",
+			body:               `{"token":`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid token",
+			query:              "?token=usedToken",
+			token:              "usedToken",
+			authenticatorError: users.ErrInvalidActionToken,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Authenticator error",
+			query:              "?token=verifyToken",
+			token:              "verifyToken",
+			authenticatorError: errors.New("error during use"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "Repo error",
+			query:              "?token=verifyToken",
+			token:              "verifyToken",
+			repositoryError:    errors.New("error during update"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/verify-email"+test.query, strings.NewReader(test.body))
+			rr := httptest.NewRecorder()
+
+			if test.token != "" {
+				mockAuthenticator.EXPECT().ConsumeActionToken(test.token, users.PurposeVerifyEmail).Return(uint(3), test.authenticatorError)
+
+				if test.authenticatorError == nil {
+					mockRepository.EXPECT().MarkEmailVerified(uint(3)).Return(test.repositoryError)
+				}
+			}
+
+			http.HandlerFunc(service.VerifyEmail).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestUserService_ResendVerification(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+	mockMailer := mocks.NewMockMailer(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator, Mailer: mockMailer}
+
+	tests := []struct {
+		name               string
+		user               *users.User
+		mailError          error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			user:               &users.User{ID: 3, Email: "test@test.com"},
+			expectedStatusCode: http.StatusAccepted,
+		},
+		{
+			name:               "Already verified",
+			user:               &users.User{ID: 3, Email: "test@test.com", EmailVerified: true},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:               "Mail error",
+			user:               &users.User{ID: 3, Email: "test@test.com"},
+			mailError:          errors.New("connection refused"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "Missing user",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/v1/verify-email/resend", nil)
+			rr := httptest.NewRecorder()
+
+			if test.user != nil {
+				req = req.WithContext(users.NewContext(req.Context(), &users.User{ID: test.user.ID}))
+				mockRepository.EXPECT().FindUserById(test.user.ID).Return(test.user, nil)
+
+				if !test.user.EmailVerified {
+					mockAuthenticator.EXPECT().IssueActionToken(test.user, users.PurposeVerifyEmail).Return("verifyToken", nil)
+					mockMailer.EXPECT().Send(gomock.Any()).Return(test.mailError)
+				}
+			}
+
+			http.HandlerFunc(service.ResendVerification).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestUserService_ForgotPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+	mockMailer := mocks.NewMockMailer(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator, Mailer: mockMailer}
+
+	tests := []struct {
+		name               string
+		payload            string
+		user               *users.User
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			payload:            `{"email":"test@test.com"}`,
+			user:               &users.User{ID: 3, Email: "test@test.com"},
+			expectedStatusCode: http.StatusAccepted,
+		},
+		{
+			name:               "Unknown email",
+			payload:            `{"email":"test@test.com"}`,
+			expectedStatusCode: http.StatusAccepted,
+		},
+		{
+			name:               "Missing email",
+			payload:            `{}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repo error",
+			payload:            `{"email":"test@test.com"}`,
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/password/forgot", strings.NewReader(test.payload))
+			rr := httptest.NewRecorder()
+
+			if test.payload != `{}` {
+				mockRepository.EXPECT().FindUserByEmail("test@test.com").Return(test.user, test.repositoryError)
+			}
+
+			if test.user != nil {
+				mockAuthenticator.EXPECT().IssueActionToken(test.user, users.PurposeResetPassword).Return("resetToken", nil)
+				mockMailer.EXPECT().Send(gomock.Any()).DoAndReturn(func(message mail.Message) error {
+					if message.To != test.user.Email || !strings.Contains(message.Body, "resetToken") {
+						t.Errorf("Got message = %v", message)
+					}
+					return nil
+				})
+			}
+
+			http.HandlerFunc(service.ForgotPassword).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator}
+
+	tests := []struct {
+		name               string
+		payload            users.PasswordResetPayload
+		authenticatorError error
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			payload:            users.PasswordResetPayload{Token: "resetToken", Password: "newPassword1"},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Empty password",
+			payload:            users.PasswordResetPayload{Token: "resetToken"},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid token",
+			payload:            users.PasswordResetPayload{Token: "usedToken", Password: "newPassword1"},
+			authenticatorError: users.ErrInvalidActionToken,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Repo error",
+			payload:            users.PasswordResetPayload{Token: "resetToken", Password: "newPassword1"},
+			repositoryError:    errors.New("error during update"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, _ := json.Marshal(test.payload)
+			req, _ := http.NewRequest("POST", "/password/reset", strings.NewReader(string(payload)))
+			rr := httptest.NewRecorder()
+
+			if test.payload.Password != "" {
+				mockAuthenticator.EXPECT().ConsumeActionToken(test.payload.Token, users.PurposeResetPassword).Return(uint(3), test.authenticatorError)
+			}
+
+			if test.payload.Password != "" && test.authenticatorError == nil {
+				mockRepository.EXPECT().UpdatePassword(uint(3), test.payload.Password).Return(test.repositoryError)
+
+				if test.repositoryError == nil {
+					mockAuthenticator.EXPECT().EndAllSessions(uint(3)).Return(nil)
+					mockRepository.EXPECT().MarkEmailVerified(uint(3)).Return(nil)
+				}
+			}
+
+			http.HandlerFunc(service.ResetPassword).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestUserService_CurrentUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository}
+
+	tests := []struct {
+		name               string
+		found              *users.User
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			found:              &users.User{ID: 3, Name: "Chef", Email: "chef@test.com", Password: "hash"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Deleted user",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Repo error",
+			repositoryError:    errors.New("some repo error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+			req = req.WithContext(users.NewContext(req.Context(), &users.User{ID: 3}))
+			rr := httptest.NewRecorder()
+
+			mockRepository.EXPECT().FindUserById(uint(3)).Return(test.found, test.repositoryError)
+
+			http.HandlerFunc(service.CurrentUser).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode == http.StatusOK && (!strings.Contains(rr.Body.String(), `"email":"chef@test.com"`) ||
+				strings.Contains(rr.Body.String(), "password")) {
+				t.Errorf("handler returned unexpected body %v", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateCurrentUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+	mockMailer := mocks.NewMockMailer(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator, Mailer: mockMailer}
+
+	tests := []struct {
+		name               string
+		payload            string
+		emailOwner         *users.User
+		sessionError       error
+		expectedUser       *users.User
+		expectedStatusCode int
+	}{
+		{
+			name:               "Change name",
+			payload:            `{"name":"Head chef"}`,
+			expectedUser:       &users.User{ID: 3, Name: "Head chef", Email: "chef@test.com", EmailVerified: true},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Change email",
+			payload:            `{"email":"cook@test.com"}`,
+			expectedUser:       &users.User{ID: 3, Name: "Chef", Email: "cook@test.com"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Session error on email change",
+			payload:            `{"email":"cook@test.com"}`,
+			sessionError:       errors.New("error during revocation"),
+			expectedUser:       &users.User{ID: 3, Name: "Chef", Email: "cook@test.com"},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "Email of another user",
+			payload:            `{"email":"cook@test.com"}`,
+			emailOwner:         &users.User{ID: 4, Email: "cook@test.com"},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:               "Empty name",
+			payload:            `{"name":""}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid email",
+			payload:            `{"email":"cook"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PATCH", "/api/v1/me", strings.NewReader(test.payload))
+			req = req.WithContext(users.NewContext(req.Context(), &users.User{ID: 3}))
+			rr := httptest.NewRecorder()
+
+			stored := &users.User{ID: 3, Name: "Chef", Email: "chef@test.com", EmailVerified: true}
+			mockRepository.EXPECT().FindUserById(uint(3)).Return(stored, nil)
+
+			if strings.Contains(test.payload, "cook@test.com") {
+				mockRepository.EXPECT().FindUserByEmail("cook@test.com").Return(test.emailOwner, nil)
+			}
+
+			if test.expectedUser != nil {
+				mockRepository.EXPECT().UpdateUser(gomock.Any()).Return(nil)
+			}
+
+			if test.expectedUser != nil && !test.expectedUser.EmailVerified {
+				mockAuthenticator.EXPECT().IssueActionToken(gomock.Any(), users.PurposeVerifyEmail).Return("verifyToken", nil)
+				mockMailer.EXPECT().Send(gomock.Any()).DoAndReturn(func(message mail.Message) error {
+					if message.To != "cook@test.com" {
+						t.Errorf("Got verification email to %v", message.To)
+					}
+					return nil
+				})
+
+				// the sessions claiming the old email is verified are replaced
+				mockAuthenticator.EXPECT().EndAllSessions(uint(3)).Return(test.sessionError)
+				if test.sessionError == nil {
+					mockAuthenticator.EXPECT().StartSession(test.expectedUser).Return(&users.Tokens{AccessToken: "someToken", RefreshToken: "someRefreshToken"}, nil)
+				}
+			}
+
+			http.HandlerFunc(service.UpdateCurrentUser).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			if !test.expectedUser.EmailVerified {
+				tokens := users.AccessTokenResponse{}
+				json.NewDecoder(rr.Body).Decode(&tokens)
+
+				if tokens.AccessToken != "someToken" || len(rr.Result().Cookies()) != 2 {
+					t.Errorf("handler returned tokens %v and cookies %v want new tokens", tokens, rr.Result().Cookies())
+				}
+			} else {
+				user := users.User{}
+				json.NewDecoder(rr.Body).Decode(&user)
+
+				if user != *test.expectedUser {
+					t.Errorf("handler returned user %v want %v", user, *test.expectedUser)
+				}
+			}
+		})
+	}
+}
+
+func TestUserService_DeleteCurrentUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository}
+
+	tests := []struct {
+		name               string
+		passwordError      error
+		repositoryError    error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Wrong password",
+			passwordError:      users.ErrInvalidCredentials,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Repo error",
+			repositoryError:    errors.New("error during delete"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("DELETE", "/api/v1/me", strings.NewReader(`{"password":"secret"}`))
+			req = req.WithContext(users.NewContext(req.Context(), &users.User{ID: 3}))
+			rr := httptest.NewRecorder()
+
+			mockRepository.EXPECT().VerifyPassword(uint(3), "secret").Return(test.passwordError)
+
+			if test.passwordError == nil {
+				mockRepository.EXPECT().DeleteUser(uint(3)).Return(test.repositoryError)
+			}
+
+			http.HandlerFunc(service.DeleteCurrentUser).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode == http.StatusNoContent && len(rr.Result().Cookies()) != 2 {
+				t.Errorf("expected the token cookies to be cleared")
+			}
+		})
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockAuthenticator := mocks.NewMockUserAuthenticator(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, UserAuthenticator: mockAuthenticator}
+
+	tests := []struct {
+		name               string
+		payload            users.PasswordChangePayload
+		passwordError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			payload:            users.PasswordChangePayload{CurrentPassword: "secret", NewPassword: "newSecret1"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Wrong current password",
+			payload:            users.PasswordChangePayload{CurrentPassword: "wrong", NewPassword: "newSecret1"},
+			passwordError:      users.ErrInvalidCredentials,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Empty new password",
+			payload:            users.PasswordChangePayload{CurrentPassword: "secret"},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Weak new password",
+			payload:            users.PasswordChangePayload{CurrentPassword: "secret", NewPassword: "12345678"},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, _ := json.Marshal(test.payload)
+			req, _ := http.NewRequest("POST", "/api/v1/me/password", strings.NewReader(string(payload)))
+			req = req.WithContext(users.NewContext(req.Context(), &users.User{ID: 3}))
+			rr := httptest.NewRecorder()
+
+			if test.expectedStatusCode != http.StatusBadRequest {
+				mockRepository.EXPECT().VerifyPassword(uint(3), test.payload.CurrentPassword).Return(test.passwordError)
+			}
+
+			if test.expectedStatusCode == http.StatusOK {
+				user := &users.User{ID: 3, Name: "Chef"}
+				mockRepository.EXPECT().UpdatePassword(uint(3), test.payload.NewPassword).Return(nil)
+				mockAuthenticator.EXPECT().EndAllSessions(uint(3)).Return(nil)
+				mockRepository.EXPECT().FindUserById(uint(3)).Return(user, nil)
+				mockAuthenticator.EXPECT().StartSession(user).Return(&users.Tokens{AccessToken: "someToken", RefreshToken: "someRefreshToken"}, nil)
+			}
+
+			http.HandlerFunc(service.ChangePassword).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestUserService_FindUserProfile(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRepository := mocks.NewMockUserRepository(mockCtrl)
+	mockRecipeRepository := mocks.NewMockRecipeRepository(mockCtrl)
+
+	service := UserService{UserRepository: mockRepository, RecipeRepository: mockRecipeRepository}
+
+	tests := []struct {
+		name               string
+		path               string
+		found              *users.User
+		expectedStatusCode int
+	}{
+		{
+			name:               "Successful",
+			path:               "/api/v1/users/3?limit=5&offset=5",
+			found:              &users.User{ID: 3, Name: "Chef", Email: "chef@test.com", Role: users.RoleAdmin},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Unknown user",
+			path:               "/api/v1/users/3",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Invalid id",
+			path:               "/api/v1/users/chef",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.path, nil)
+			rr := httptest.NewRecorder()
+
+			if test.expectedStatusCode != http.StatusBadRequest {
+				mockRepository.EXPECT().FindUserById(uint(3)).Return(test.found, nil)
+			}
+
+			if test.found != nil {
+				options := recipes.SearchOptions{Limit: 5, Offset: 5, SortBy: recipes.SortByCreatedAt, Descending: true}
+				mockRecipeRepository.EXPECT().FindRecipesByAuthor(uint(3), options).
+					Return([]recipes.RecipeSearchResult{{ID: 8, Title: "Soup", AuthorID: 3}}, 6, nil)
+			}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/users/{id}", service.FindUserProfile)
+			router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != test.expectedStatusCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, test.expectedStatusCode)
+			}
+
+			if test.found != nil {
+				profile := users.Profile{}
+				json.NewDecoder(strings.NewReader(rr.Body.String())).Decode(&profile)
+
+				if profile.Name != "Chef" || profile.RecipeCount != 6 || len(profile.Recipes) != 1 ||
+					strings.Contains(rr.Body.String(), "chef@test.com") || strings.Contains(rr.Body.String(), "admin") {
+					t.Errorf("handler returned unexpected profile %v", rr.Body.String())
+				}
+			}
+		})
+	}
+}
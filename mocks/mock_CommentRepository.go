@@ -6,6 +6,7 @@ package mocks
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	comments "github.com/krasimiraMilkova/cookit/pkg/comments"
 	reflect "reflect"
 )
 
@@ -33,24 +34,24 @@ func (m *MockCommentRepository) EXPECT() *MockCommentRepositoryMockRecorder {
 }
 
 // AddComment mocks base method
-func (m *MockCommentRepository) AddComment(recipeId int, comment string) error {
+func (m *MockCommentRepository) AddComment(comment *comments.Comment) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddComment", recipeId, comment)
+	ret := m.ctrl.Call(m, "AddComment", comment)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddComment indicates an expected call of AddComment
-func (mr *MockCommentRepositoryMockRecorder) AddComment(recipeId, comment interface{}) *gomock.Call {
+func (mr *MockCommentRepositoryMockRecorder) AddComment(comment interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockCommentRepository)(nil).AddComment), recipeId, comment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockCommentRepository)(nil).AddComment), comment)
 }
 
 // GetComments mocks base method
-func (m *MockCommentRepository) GetComments(recipeId int) ([]string, error) {
+func (m *MockCommentRepository) GetComments(recipeId int) ([]comments.Comment, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetComments", recipeId)
-	ret0, _ := ret[0].([]string)
+	ret0, _ := ret[0].([]comments.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -60,3 +61,46 @@ func (mr *MockCommentRepositoryMockRecorder) GetComments(recipeId interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockCommentRepository)(nil).GetComments), recipeId)
 }
+
+// FindCommentById mocks base method
+func (m *MockCommentRepository) FindCommentById(id int) (*comments.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCommentById", id)
+	ret0, _ := ret[0].(*comments.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCommentById indicates an expected call of FindCommentById
+func (mr *MockCommentRepositoryMockRecorder) FindCommentById(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCommentById", reflect.TypeOf((*MockCommentRepository)(nil).FindCommentById), id)
+}
+
+// UpdateComment mocks base method
+func (m *MockCommentRepository) UpdateComment(comment *comments.Comment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", comment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateComment indicates an expected call of UpdateComment
+func (mr *MockCommentRepositoryMockRecorder) UpdateComment(comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockCommentRepository)(nil).UpdateComment), comment)
+}
+
+// DeleteComment mocks base method
+func (m *MockCommentRepository) DeleteComment(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment
+func (mr *MockCommentRepositoryMockRecorder) DeleteComment(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockCommentRepository)(nil).DeleteComment), id)
+}
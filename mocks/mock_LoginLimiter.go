@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/users/lockout.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	users "github.com/krasimiraMilkova/cookit/pkg/users"
+	reflect "reflect"
+	time "time"
+)
+
+// MockLoginAttemptRepository is a mock of LoginAttemptRepository interface
+type MockLoginAttemptRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptRepositoryMockRecorder
+}
+
+// MockLoginAttemptRepositoryMockRecorder is the mock recorder for MockLoginAttemptRepository
+type MockLoginAttemptRepositoryMockRecorder struct {
+	mock *MockLoginAttemptRepository
+}
+
+// NewMockLoginAttemptRepository creates a new mock instance
+func NewMockLoginAttemptRepository(ctrl *gomock.Controller) *MockLoginAttemptRepository {
+	mock := &MockLoginAttemptRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLoginAttemptRepository) EXPECT() *MockLoginAttemptRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindLoginAttempts mocks base method
+func (m *MockLoginAttemptRepository) FindLoginAttempts(key string) (*users.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindLoginAttempts", key)
+	ret0, _ := ret[0].(*users.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLoginAttempts indicates an expected call of FindLoginAttempts
+func (mr *MockLoginAttemptRepositoryMockRecorder) FindLoginAttempts(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLoginAttempts", reflect.TypeOf((*MockLoginAttemptRepository)(nil).FindLoginAttempts), key)
+}
+
+// AddLoginFailure mocks base method
+func (m *MockLoginAttemptRepository) AddLoginFailure(key string, now time.Time, policy users.LockoutPolicy) (*users.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", key, now, policy)
+	ret0, _ := ret[0].(*users.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure
+func (mr *MockLoginAttemptRepositoryMockRecorder) AddLoginFailure(key, now, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockLoginAttemptRepository)(nil).AddLoginFailure), key, now, policy)
+}
+
+// ResetLoginAttempts mocks base method
+func (m *MockLoginAttemptRepository) ResetLoginAttempts(key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginAttempts", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginAttempts indicates an expected call of ResetLoginAttempts
+func (mr *MockLoginAttemptRepositoryMockRecorder) ResetLoginAttempts(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginAttempts", reflect.TypeOf((*MockLoginAttemptRepository)(nil).ResetLoginAttempts), key)
+}
+
+// MockLoginLimiter is a mock of LoginLimiter interface
+type MockLoginLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginLimiterMockRecorder
+}
+
+// MockLoginLimiterMockRecorder is the mock recorder for MockLoginLimiter
+type MockLoginLimiterMockRecorder struct {
+	mock *MockLoginLimiter
+}
+
+// NewMockLoginLimiter creates a new mock instance
+func NewMockLoginLimiter(ctrl *gomock.Controller) *MockLoginLimiter {
+	mock := &MockLoginLimiter{ctrl: ctrl}
+	mock.recorder = &MockLoginLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLoginLimiter) EXPECT() *MockLoginLimiterMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method
+func (m *MockLoginLimiter) Check(email, address string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", email, address)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check
+func (mr *MockLoginLimiterMockRecorder) Check(email, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLoginLimiter)(nil).Check), email, address)
+}
+
+// Fail mocks base method
+func (m *MockLoginLimiter) Fail(email, address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", email, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail
+func (mr *MockLoginLimiterMockRecorder) Fail(email, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockLoginLimiter)(nil).Fail), email, address)
+}
+
+// Succeed mocks base method
+func (m *MockLoginLimiter) Succeed(email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Succeed", email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Succeed indicates an expected call of Succeed
+func (mr *MockLoginLimiterMockRecorder) Succeed(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Succeed", reflect.TypeOf((*MockLoginLimiter)(nil).Succeed), email)
+}
@@ -47,34 +47,198 @@ func (mr *MockRecipeRepositoryMockRecorder) CreateRecipe(recipe interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecipe", reflect.TypeOf((*MockRecipeRepository)(nil).CreateRecipe), recipe)
 }
 
-// FindRecipesByTitle mocks base method
-func (m *MockRecipeRepository) FindRecipesByTitle(title string) ([]recipes.RecipeSearchResult, error) {
+// UpdateRecipe mocks base method
+func (m *MockRecipeRepository) UpdateRecipe(recipe *recipes.Recipe) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindRecipesByTitle", title)
+	ret := m.ctrl.Call(m, "UpdateRecipe", recipe)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRecipe indicates an expected call of UpdateRecipe
+func (mr *MockRecipeRepositoryMockRecorder) UpdateRecipe(recipe interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecipe", reflect.TypeOf((*MockRecipeRepository)(nil).UpdateRecipe), recipe)
+}
+
+// DeleteRecipe mocks base method
+func (m *MockRecipeRepository) DeleteRecipe(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecipe", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecipe indicates an expected call of DeleteRecipe
+func (mr *MockRecipeRepositoryMockRecorder) DeleteRecipe(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecipe", reflect.TypeOf((*MockRecipeRepository)(nil).DeleteRecipe), id)
+}
+
+// FindRecipes mocks base method
+func (m *MockRecipeRepository) FindRecipes(filter recipes.SearchFilter, options recipes.SearchOptions) ([]recipes.RecipeSearchResult, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRecipes", filter, options)
 	ret0, _ := ret[0].([]recipes.RecipeSearchResult)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindRecipes indicates an expected call of FindRecipes
+func (mr *MockRecipeRepositoryMockRecorder) FindRecipes(filter, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecipes", reflect.TypeOf((*MockRecipeRepository)(nil).FindRecipes), filter, options)
+}
+
+// CountFacets mocks base method
+func (m *MockRecipeRepository) CountFacets(filter recipes.SearchFilter, options recipes.SearchOptions) (map[string][]recipes.FacetCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFacets", filter, options)
+	ret0, _ := ret[0].(map[string][]recipes.FacetCount)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindRecipesByTitle indicates an expected call of FindRecipesByTitle
-func (mr *MockRecipeRepositoryMockRecorder) FindRecipesByTitle(title interface{}) *gomock.Call {
+// CountFacets indicates an expected call of CountFacets
+func (mr *MockRecipeRepositoryMockRecorder) CountFacets(filter, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecipesByTitle", reflect.TypeOf((*MockRecipeRepository)(nil).FindRecipesByTitle), title)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFacets", reflect.TypeOf((*MockRecipeRepository)(nil).CountFacets), filter, options)
 }
 
-// FindRecipesByIngredients mocks base method
-func (m *MockRecipeRepository) FindRecipesByIngredients(ingredients []string) ([]recipes.RecipeSearchResult, error) {
+// FindRecipesByAuthor mocks base method
+func (m *MockRecipeRepository) FindRecipesByAuthor(authorId uint, options recipes.SearchOptions) ([]recipes.RecipeSearchResult, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindRecipesByIngredients", ingredients)
+	ret := m.ctrl.Call(m, "FindRecipesByAuthor", authorId, options)
 	ret0, _ := ret[0].([]recipes.RecipeSearchResult)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindRecipesByAuthor indicates an expected call of FindRecipesByAuthor
+func (mr *MockRecipeRepositoryMockRecorder) FindRecipesByAuthor(authorId, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecipesByAuthor", reflect.TypeOf((*MockRecipeRepository)(nil).FindRecipesByAuthor), authorId, options)
+}
+
+// FindRecipesForPantry mocks base method
+func (m *MockRecipeRepository) FindRecipesForPantry(query *recipes.PantryQuery) ([]recipes.RecipeIngredients, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRecipesForPantry", query)
+	ret0, _ := ret[0].([]recipes.RecipeIngredients)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRecipesForPantry indicates an expected call of FindRecipesForPantry
+func (mr *MockRecipeRepositoryMockRecorder) FindRecipesForPantry(query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecipesForPantry", reflect.TypeOf((*MockRecipeRepository)(nil).FindRecipesForPantry), query)
+}
+
+// FindRecipesForText mocks base method
+func (m *MockRecipeRepository) FindRecipesForText(query recipes.TextQuery) ([]recipes.RecipeTerms, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRecipesForText", query)
+	ret0, _ := ret[0].([]recipes.RecipeTerms)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindRecipesForText indicates an expected call of FindRecipesForText
+func (mr *MockRecipeRepositoryMockRecorder) FindRecipesForText(query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecipesForText", reflect.TypeOf((*MockRecipeRepository)(nil).FindRecipesForText), query)
+}
+
+// RateRecipe mocks base method
+func (m *MockRecipeRepository) RateRecipe(rating recipes.Rating) (recipes.RatingSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RateRecipe", rating)
+	ret0, _ := ret[0].(recipes.RatingSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RateRecipe indicates an expected call of RateRecipe
+func (mr *MockRecipeRepositoryMockRecorder) RateRecipe(rating interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateRecipe", reflect.TypeOf((*MockRecipeRepository)(nil).RateRecipe), rating)
+}
+
+// FindIngredientAttributes mocks base method
+func (m *MockRecipeRepository) FindIngredientAttributes() ([]recipes.IngredientAttributes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIngredientAttributes")
+	ret0, _ := ret[0].([]recipes.IngredientAttributes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIngredientAttributes indicates an expected call of FindIngredientAttributes
+func (mr *MockRecipeRepositoryMockRecorder) FindIngredientAttributes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIngredientAttributes", reflect.TypeOf((*MockRecipeRepository)(nil).FindIngredientAttributes))
+}
+
+// SaveIngredientAttributes mocks base method
+func (m *MockRecipeRepository) SaveIngredientAttributes(attributes *recipes.IngredientAttributes) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIngredientAttributes", attributes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIngredientAttributes indicates an expected call of SaveIngredientAttributes
+func (mr *MockRecipeRepositoryMockRecorder) SaveIngredientAttributes(attributes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIngredientAttributes", reflect.TypeOf((*MockRecipeRepository)(nil).SaveIngredientAttributes), attributes)
+}
+
+// DeleteIngredientAttributes mocks base method
+func (m *MockRecipeRepository) DeleteIngredientAttributes(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIngredientAttributes", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIngredientAttributes indicates an expected call of DeleteIngredientAttributes
+func (mr *MockRecipeRepositoryMockRecorder) DeleteIngredientAttributes(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIngredientAttributes", reflect.TypeOf((*MockRecipeRepository)(nil).DeleteIngredientAttributes), name)
+}
+
+// FindIngredientNutrients mocks base method
+func (m *MockRecipeRepository) FindIngredientNutrients(names []string) (map[string]recipes.IngredientNutrients, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIngredientNutrients", names)
+	ret0, _ := ret[0].(map[string]recipes.IngredientNutrients)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIngredientNutrients indicates an expected call of FindIngredientNutrients
+func (mr *MockRecipeRepositoryMockRecorder) FindIngredientNutrients(names interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIngredientNutrients", reflect.TypeOf((*MockRecipeRepository)(nil).FindIngredientNutrients), names)
+}
+
+// SaveIngredientNutrients mocks base method
+func (m *MockRecipeRepository) SaveIngredientNutrients(nutrients []recipes.IngredientNutrients) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIngredientNutrients", nutrients)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindRecipesByIngredients indicates an expected call of FindRecipesByIngredients
-func (mr *MockRecipeRepositoryMockRecorder) FindRecipesByIngredients(ingredients interface{}) *gomock.Call {
+// SaveIngredientNutrients indicates an expected call of SaveIngredientNutrients
+func (mr *MockRecipeRepositoryMockRecorder) SaveIngredientNutrients(nutrients interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecipesByIngredients", reflect.TypeOf((*MockRecipeRepository)(nil).FindRecipesByIngredients), ingredients)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIngredientNutrients", reflect.TypeOf((*MockRecipeRepository)(nil).SaveIngredientNutrients), nutrients)
 }
 
 // FindRecipeById mocks base method
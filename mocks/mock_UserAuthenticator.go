@@ -34,19 +34,92 @@ func (m *MockUserAuthenticator) EXPECT() *MockUserAuthenticatorMockRecorder {
 	return m.recorder
 }
 
-// GenerateTokenForUser mocks base method
-func (m *MockUserAuthenticator) GenerateTokenForUser(user *users.User) (string, error) {
+// StartSession mocks base method
+func (m *MockUserAuthenticator) StartSession(user *users.User) (*users.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateTokenForUser", user)
+	ret := m.ctrl.Call(m, "StartSession", user)
+	ret0, _ := ret[0].(*users.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartSession indicates an expected call of StartSession
+func (mr *MockUserAuthenticatorMockRecorder) StartSession(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartSession", reflect.TypeOf((*MockUserAuthenticator)(nil).StartSession), user)
+}
+
+// RefreshSession mocks base method
+func (m *MockUserAuthenticator) RefreshSession(refreshToken string) (*users.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshSession", refreshToken)
+	ret0, _ := ret[0].(*users.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshSession indicates an expected call of RefreshSession
+func (mr *MockUserAuthenticatorMockRecorder) RefreshSession(refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSession", reflect.TypeOf((*MockUserAuthenticator)(nil).RefreshSession), refreshToken)
+}
+
+// EndSession mocks base method
+func (m *MockUserAuthenticator) EndSession(sessionId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndSession", sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EndSession indicates an expected call of EndSession
+func (mr *MockUserAuthenticatorMockRecorder) EndSession(sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndSession", reflect.TypeOf((*MockUserAuthenticator)(nil).EndSession), sessionId)
+}
+
+// EndAllSessions mocks base method
+func (m *MockUserAuthenticator) EndAllSessions(userId uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndAllSessions", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EndAllSessions indicates an expected call of EndAllSessions
+func (mr *MockUserAuthenticatorMockRecorder) EndAllSessions(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndAllSessions", reflect.TypeOf((*MockUserAuthenticator)(nil).EndAllSessions), userId)
+}
+
+// IssueActionToken mocks base method
+func (m *MockUserAuthenticator) IssueActionToken(user *users.User, purpose string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueActionToken", user, purpose)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GenerateTokenForUser indicates an expected call of GenerateTokenForUser
-func (mr *MockUserAuthenticatorMockRecorder) GenerateTokenForUser(user interface{}) *gomock.Call {
+// IssueActionToken indicates an expected call of IssueActionToken
+func (mr *MockUserAuthenticatorMockRecorder) IssueActionToken(user, purpose interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueActionToken", reflect.TypeOf((*MockUserAuthenticator)(nil).IssueActionToken), user, purpose)
+}
+
+// ConsumeActionToken mocks base method
+func (m *MockUserAuthenticator) ConsumeActionToken(token, purpose string) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeActionToken", token, purpose)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeActionToken indicates an expected call of ConsumeActionToken
+func (mr *MockUserAuthenticatorMockRecorder) ConsumeActionToken(token, purpose interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTokenForUser", reflect.TypeOf((*MockUserAuthenticator)(nil).GenerateTokenForUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeActionToken", reflect.TypeOf((*MockUserAuthenticator)(nil).ConsumeActionToken), token, purpose)
 }
 
 // VerifyJWT mocks base method
@@ -62,3 +135,15 @@ func (mr *MockUserAuthenticatorMockRecorder) VerifyJWT(next interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyJWT", reflect.TypeOf((*MockUserAuthenticator)(nil).VerifyJWT), next)
 }
+
+// JWKS mocks base method
+func (m *MockUserAuthenticator) JWKS(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JWKS", w, r)
+}
+
+// JWKS indicates an expected call of JWKS
+func (mr *MockUserAuthenticatorMockRecorder) JWKS(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockUserAuthenticator)(nil).JWKS), w, r)
+}
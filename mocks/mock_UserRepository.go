@@ -8,6 +8,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	users "github.com/krasimiraMilkova/cookit/pkg/users"
 	reflect "reflect"
+	time "time"
 )
 
 // MockUserRepository is a mock of UserRepository interface
@@ -75,3 +76,296 @@ func (mr *MockUserRepositoryMockRecorder) FindUser(email, password interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUser", reflect.TypeOf((*MockUserRepository)(nil).FindUser), email, password)
 }
+
+// FindUserById mocks base method
+func (m *MockUserRepository) FindUserById(id uint) (*users.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserById", id)
+	ret0, _ := ret[0].(*users.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserById indicates an expected call of FindUserById
+func (mr *MockUserRepositoryMockRecorder) FindUserById(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserById", reflect.TypeOf((*MockUserRepository)(nil).FindUserById), id)
+}
+
+// FindUserByEmail mocks base method
+func (m *MockUserRepository) FindUserByEmail(email string) (*users.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByEmail", email)
+	ret0, _ := ret[0].(*users.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByEmail indicates an expected call of FindUserByEmail
+func (mr *MockUserRepositoryMockRecorder) FindUserByEmail(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByEmail", reflect.TypeOf((*MockUserRepository)(nil).FindUserByEmail), email)
+}
+
+// ListUsers mocks base method
+func (m *MockUserRepository) ListUsers(limit, offset int) ([]users.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", limit, offset)
+	ret0, _ := ret[0].([]users.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers
+func (mr *MockUserRepositoryMockRecorder) ListUsers(limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserRepository)(nil).ListUsers), limit, offset)
+}
+
+// UpdateUserRole mocks base method
+func (m *MockUserRepository) UpdateUserRole(id uint, role users.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", id, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole
+func (mr *MockUserRepositoryMockRecorder) UpdateUserRole(id, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockUserRepository)(nil).UpdateUserRole), id, role)
+}
+
+// MarkEmailVerified mocks base method
+func (m *MockUserRepository) MarkEmailVerified(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified
+func (mr *MockUserRepositoryMockRecorder) MarkEmailVerified(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserRepository)(nil).MarkEmailVerified), id)
+}
+
+// UpdateUser mocks base method
+func (m *MockUserRepository) UpdateUser(user *users.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser
+func (mr *MockUserRepositoryMockRecorder) UpdateUser(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), user)
+}
+
+// VerifyPassword mocks base method
+func (m *MockUserRepository) VerifyPassword(id uint, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPassword", id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyPassword indicates an expected call of VerifyPassword
+func (mr *MockUserRepositoryMockRecorder) VerifyPassword(id, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPassword", reflect.TypeOf((*MockUserRepository)(nil).VerifyPassword), id, password)
+}
+
+// DeleteUser mocks base method
+func (m *MockUserRepository) DeleteUser(id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser
+func (mr *MockUserRepositoryMockRecorder) DeleteUser(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepository)(nil).DeleteUser), id)
+}
+
+// UpdatePassword mocks base method
+func (m *MockUserRepository) UpdatePassword(id uint, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(id, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), id, password)
+}
+
+// MockActionTokenRepository is a mock of ActionTokenRepository interface
+type MockActionTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockActionTokenRepositoryMockRecorder
+}
+
+// MockActionTokenRepositoryMockRecorder is the mock recorder for MockActionTokenRepository
+type MockActionTokenRepositoryMockRecorder struct {
+	mock *MockActionTokenRepository
+}
+
+// NewMockActionTokenRepository creates a new mock instance
+func NewMockActionTokenRepository(ctrl *gomock.Controller) *MockActionTokenRepository {
+	mock := &MockActionTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockActionTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockActionTokenRepository) EXPECT() *MockActionTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateActionToken mocks base method
+func (m *MockActionTokenRepository) CreateActionToken(token *users.ActionToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateActionToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateActionToken indicates an expected call of CreateActionToken
+func (mr *MockActionTokenRepositoryMockRecorder) CreateActionToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateActionToken", reflect.TypeOf((*MockActionTokenRepository)(nil).CreateActionToken), token)
+}
+
+// UseActionToken mocks base method
+func (m *MockActionTokenRepository) UseActionToken(id, purpose string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseActionToken", id, purpose)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseActionToken indicates an expected call of UseActionToken
+func (mr *MockActionTokenRepositoryMockRecorder) UseActionToken(id, purpose interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseActionToken", reflect.TypeOf((*MockActionTokenRepository)(nil).UseActionToken), id, purpose)
+}
+
+// MockSessionRepository is a mock of SessionRepository interface
+type MockSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRepositoryMockRecorder
+}
+
+// MockSessionRepositoryMockRecorder is the mock recorder for MockSessionRepository
+type MockSessionRepositoryMockRecorder struct {
+	mock *MockSessionRepository
+}
+
+// NewMockSessionRepository creates a new mock instance
+func NewMockSessionRepository(ctrl *gomock.Controller) *MockSessionRepository {
+	mock := &MockSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSessionRepository) EXPECT() *MockSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSession mocks base method
+func (m *MockSessionRepository) CreateSession(session *users.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession
+func (mr *MockSessionRepositoryMockRecorder) CreateSession(session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionRepository)(nil).CreateSession), session)
+}
+
+// FindSessionByRefreshToken mocks base method
+func (m *MockSessionRepository) FindSessionByRefreshToken(tokenHash string) (*users.Session, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSessionByRefreshToken", tokenHash)
+	ret0, _ := ret[0].(*users.Session)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindSessionByRefreshToken indicates an expected call of FindSessionByRefreshToken
+func (mr *MockSessionRepositoryMockRecorder) FindSessionByRefreshToken(tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSessionByRefreshToken", reflect.TypeOf((*MockSessionRepository)(nil).FindSessionByRefreshToken), tokenHash)
+}
+
+// RotateRefreshToken mocks base method
+func (m *MockSessionRepository) RotateRefreshToken(session *users.Session, tokenHash string, expiresAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", session, tokenHash, expiresAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken
+func (mr *MockSessionRepositoryMockRecorder) RotateRefreshToken(session, tokenHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockSessionRepository)(nil).RotateRefreshToken), session, tokenHash, expiresAt)
+}
+
+// RevokeSession mocks base method
+func (m *MockSessionRepository) RevokeSession(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession
+func (mr *MockSessionRepositoryMockRecorder) RevokeSession(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionRepository)(nil).RevokeSession), id)
+}
+
+// RevokeUserSessions mocks base method
+func (m *MockSessionRepository) RevokeUserSessions(userId uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions
+func (mr *MockSessionRepositoryMockRecorder) RevokeUserSessions(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockSessionRepository)(nil).RevokeUserSessions), userId)
+}
+
+// IsSessionRevoked mocks base method
+func (m *MockSessionRepository) IsSessionRevoked(id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSessionRevoked", id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsSessionRevoked indicates an expected call of IsSessionRevoked
+func (mr *MockSessionRepositoryMockRecorder) IsSessionRevoked(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionRevoked", reflect.TypeOf((*MockSessionRepository)(nil).IsSessionRevoked), id)
+}
@@ -0,0 +1,101 @@
+// Package apperror provides the typed errors returned by the repositories and handlers,
+// which are sent to the clients as problem details
+package apperror
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kind classifies an error by the way the client has to handle it
+type Kind string
+
+const (
+	// KindNotFound is the kind of errors for resources which do not exist
+	KindNotFound Kind = "not_found"
+	// KindValidation is the kind of errors for invalid payloads and parameters
+	KindValidation Kind = "validation"
+	// KindConflict is the kind of errors for changes conflicting with the current state of a resource
+	KindConflict Kind = "conflict"
+	// KindUnauthorized is the kind of errors for requests without valid credentials
+	KindUnauthorized Kind = "unauthorized"
+	// KindForbidden is the kind of errors for requests which the user is not allowed to make
+	KindForbidden Kind = "forbidden"
+)
+
+// FieldError struct describes why the value of a field of a payload or a parameter is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error struct describes an error of a known kind whose message can be shown to the client
+// Fields lists the invalid fields of validation errors
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+}
+
+func (err *Error) Error() string {
+	if len(err.Fields) == 0 {
+		return err.Message
+	}
+
+	fields := make([]string, 0, len(err.Fields))
+	for _, field := range err.Fields {
+		fields = append(fields, field.Field+" "+field.Message)
+	}
+
+	return err.Message + ": " + strings.Join(fields, ", ")
+}
+
+// NotFound function creates an error for a resource which does not exist
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// Validation function creates an error for an invalid payload or parameter with the invalid fields
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+// Field function creates a validation error for a single invalid field
+func Field(field string, message string) *Error {
+	return Validation("invalid "+field, FieldError{Field: field, Message: message})
+}
+
+// Conflict function creates an error for a change conflicting with the current state of a resource
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Unauthorized function creates an error for a request without valid credentials
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+// Forbidden function creates an error for a request which the user is not allowed to make
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// As function finds the first typed error in the chain of the error
+// Returns false if the error is not typed
+func As(err error) (*Error, bool) {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed, true
+	}
+
+	return nil, false
+}
+
+// KindOf function returns the kind of the error or an empty kind if the error is not typed
+func KindOf(err error) Kind {
+	if typed, ok := As(err); ok {
+		return typed.Kind
+	}
+
+	return ""
+}
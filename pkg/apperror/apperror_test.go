@@ -0,0 +1,42 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Kind
+	}{
+		{name: "Typed error", err: NotFound("recipe not found"), expected: KindNotFound},
+		{name: "Wrapped typed error", err: fmt.Errorf("rating: %w", Field("rating", "must be between 1 and 5")), expected: KindValidation},
+		{name: "Error which is not typed", err: errors.New("connection refused"), expected: ""},
+		{name: "No error", err: nil, expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if kind := KindOf(test.err); kind != test.expected {
+				t.Errorf("Got kind = %q but wanted %q", kind, test.expected)
+			}
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	err := Validation("invalid user",
+		FieldError{Field: "name", Message: "must not be empty"},
+		FieldError{Field: "email", Message: "must be a valid email address"})
+
+	if message := err.Error(); message != "invalid user: name must not be empty, email must be a valid email address" {
+		t.Errorf("Got message = %q", message)
+	}
+
+	if message := Conflict("the email is already verified").Error(); message != "the email is already verified" {
+		t.Errorf("Got message = %q", message)
+	}
+}
@@ -0,0 +1,15 @@
+package comments
+
+import "time"
+
+// Comment struct describes a comment left by a user under a recipe
+// EditedAt is nil for comments which have never been edited
+type Comment struct {
+	ID        uint       `json:"id"`
+	RecipeID  uint       `json:"recipe_id"`
+	AuthorID  uint       `json:"author_id"`
+	Author    string     `json:"author"`
+	Comment   string     `json:"comment" validate:"required,max=5000"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+}
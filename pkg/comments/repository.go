@@ -1,12 +1,34 @@
 package comments
 
+import "github.com/krasimiraMilkova/cookit/pkg/apperror"
+
+// ErrRecipeNotFound is returned when a comment is added to a recipe that does not exist
+var ErrRecipeNotFound error = apperror.NotFound("recipe not found")
+
+// ErrCommentNotFound is returned when the recipe has no comment with the requested id
+var ErrCommentNotFound error = apperror.NotFound("comment not found")
+
 // CommentRepository interface provides functions for CRUD operations for recipe comments
 type CommentRepository interface {
-	// AddComment provides an insert operation for the given recipe id and comment
-	// Returns an error if such occurs during db query execution
-	AddComment(recipeId int, comment string) error
+	// AddComment provides an insert operation for the given comment setting its id and creation time
+	// Returns ErrRecipeNotFound if the recipe does not exist, a validation error if the comment is empty or too long
+	// or an error if such occurs during db query execution
+	AddComment(comment *Comment) error
 
-	// GetComments provides a fetch operation for recipe comments for provided recipeId
+	// GetComments provides a fetch operation for recipe comments for provided recipeId ordered from the oldest
 	// Returns an error such occurs during db query execution otherwise returns the found comments
-	GetComments(recipeId int) ([]string, error)
+	GetComments(recipeId int) ([]Comment, error)
+
+	// FindCommentById provides a fetch operation for the comment with the given id
+	// Returns an error if such occurs during db query execution otherwise returns the Comment
+	// or nil if a comment with this id does not exist
+	FindCommentById(id int) (*Comment, error)
+
+	// UpdateComment provides an update operation for the text of the comment with the same id setting its edit time
+	// Returns a validation error if the comment is empty or too long or an error if such occurs during db query execution
+	UpdateComment(comment *Comment) error
+
+	// DeleteComment provides a delete operation for the comment with the given id
+	// Returns an error if such occurs during db query execution
+	DeleteComment(id int) error
 }
@@ -6,10 +6,12 @@ import "net/http"
 // CommentService interface provide handlers for creating and fetching recipe comments
 type CommentService interface {
 	// AddComment function handles comment payload for recipeId provided as a path variable
-	// Returns Status BadRequest if cannot parse the recipeId,
+	// authored by the user from the request context
+	// Returns Status BadRequest if cannot parse the recipeId or decode the payload or the comment is empty or too long,
+	// Status Forbidden if there is no user in the request context,
 	// Status InternalServerError if error occurs during comment insertion and
 	// Status NotFound if recipe with given id does not exist and
-	// Status Created if comment is successfully inserted into the db
+	// Status Created and the Comment if it is successfully inserted into the db
 	AddComment(w http.ResponseWriter, r *http.Request)
 
 	// GetComments function handles requests for fetching comments by provided id as a path variable
@@ -18,4 +20,22 @@ type CommentService interface {
 	// Status NotFound if a recipe with this id or comments for it does not exist and
 	// Status OK and the Comments if such are found
 	GetComments(w http.ResponseWriter, r *http.Request)
+
+	// UpdateComment function handles payload replacing the text of the comment with id
+	// of the recipe with recipeId, both provided as path variables
+	// Returns Status BadRequest if cannot parse the ids or decode the payload or the comment is empty or too long,
+	// Status Forbidden if the user is not the author of the comment,
+	// Status NotFound if the recipe has no comment with this id,
+	// Status InternalServerError if error occurs during comment update and
+	// Status OK and the updated Comment if it is successfully updated
+	UpdateComment(w http.ResponseWriter, r *http.Request)
+
+	// DeleteComment function handles requests for deleting the comment with id
+	// of the recipe with recipeId, both provided as path variables
+	// Returns Status BadRequest if cannot parse the ids,
+	// Status Forbidden if the user is neither the author of the comment nor a moderator,
+	// Status NotFound if the recipe has no comment with this id,
+	// Status InternalServerError if error occurs during deletion and
+	// Status NoContent if the comment is successfully deleted
+	DeleteComment(w http.ResponseWriter, r *http.Request)
 }
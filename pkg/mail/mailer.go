@@ -0,0 +1,15 @@
+package mail
+
+// Message struct describes a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer interface provides a function for delivering emails
+type Mailer interface {
+	// Send function delivers the message to its recipient
+	// Returns an error if such occurs during the delivery
+	Send(message Message) error
+}
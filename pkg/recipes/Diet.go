@@ -0,0 +1,174 @@
+package recipes
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/validation"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diets recipes are labelled with when none of their classified ingredients is unsuitable for them
+const (
+	DietVegan      = "vegan"
+	DietVegetarian = "vegetarian"
+	DietGlutenFree = "gluten-free"
+)
+
+// Diets lists the diets in the order they are shown
+var Diets = []string{DietVegan, DietVegetarian, DietGlutenFree}
+
+// Allergens lists the allergens ingredients can contain
+var Allergens = []string{"celery", "crustacean", "dairy", "egg", "fish", "gluten", "lupin", "mollusc", "mustard",
+	"peanut", "sesame", "soy", "sulphite", "tree-nut"}
+
+// allergenDiets maps the allergens coming from animals to the diets ingredients containing them are not suitable for
+var allergenDiets = map[string][]string{
+	"crustacean": {DietVegan, DietVegetarian},
+	"dairy":      {DietVegan},
+	"egg":        {DietVegan},
+	"fish":       {DietVegan, DietVegetarian},
+	"mollusc":    {DietVegan, DietVegetarian},
+}
+
+// ErrIngredientNotClassified is returned when the attributes of an ingredient which has none are removed
+var ErrIngredientNotClassified error = apperror.NotFound("the ingredient is not classified")
+
+// IngredientAttributes struct describes the diets an ingredient is suitable for and the allergens it contains
+// Name is the canonical name of the ingredient, which does not have to be used by any recipe yet
+type IngredientAttributes struct {
+	Name       string   `json:"name" validate:"required,max=100"`
+	Vegan      bool     `json:"vegan"`
+	Vegetarian bool     `json:"vegetarian"`
+	GlutenFree bool     `json:"gluten_free"`
+	Allergens  []string `json:"allergens" validate:"max=14,allergens"`
+}
+
+// DietaryProfile struct describes the diets a recipe is suitable for and the allergens its ingredients contain
+// Ingredients which are not classified yet are listed in Unknown, the diets and allergens only tell what is known
+// about the other ingredients
+type DietaryProfile struct {
+	Diets     []string `json:"diets"`
+	Allergens []string `json:"allergens"`
+	Unknown   []string `json:"unknown_ingredients,omitempty"`
+}
+
+// IsDiet function checks if the diet is one of the Diets
+func IsDiet(diet string) bool {
+	return contains(Diets, diet)
+}
+
+// IsAllergen function checks if the allergen is one of the Allergens
+func IsAllergen(allergen string) bool {
+	return contains(Allergens, allergen)
+}
+
+// Normalize function normalizes the allergens like NormalizeTag does, dropping duplicates and sorting them
+func (attributes *IngredientAttributes) Normalize() {
+	attributes.Allergens = normalizeTags(attributes.Allergens)
+	sort.Strings(attributes.Allergens)
+}
+
+// Validate function checks the attributes against their validate tags and checks that they do not contradict
+// each other, vegan ingredients must be vegetarian and ingredients must not be suitable for a diet their allergens are not
+// Returns a validation error listing the invalid fields
+func (attributes *IngredientAttributes) Validate() error {
+	if err := validation.Validate("ingredient attributes", attributes); err != nil {
+		return err
+	}
+
+	if attributes.Vegan && !attributes.Vegetarian {
+		return apperror.Field("vegetarian", "must be true for vegan ingredients")
+	}
+
+	for _, allergen := range attributes.Allergens {
+		if allergen == "gluten" && attributes.GlutenFree {
+			return apperror.Field("gluten_free", "must be false for ingredients containing gluten")
+		}
+
+		for _, diet := range allergenDiets[allergen] {
+			if attributes.SuitableFor(diet) {
+				return apperror.Field("allergens", "must not contain "+allergen+" for "+diet+" ingredients")
+			}
+		}
+	}
+
+	return nil
+}
+
+// SuitableFor function checks if the ingredient is suitable for the diet
+func (attributes IngredientAttributes) SuitableFor(diet string) bool {
+	switch diet {
+	case DietVegan:
+		return attributes.Vegan
+	case DietVegetarian:
+		return attributes.Vegetarian
+	case DietGlutenFree:
+		return attributes.GlutenFree
+	}
+
+	return false
+}
+
+// DeriveDietaryProfile function derives the dietary profile of a recipe with the ingredients of the given names
+// from the attributes of the classified ingredients, keyed by ingredient name
+// Ingredients without attributes are reported as unknown and do not make the recipe unsuitable for any diet
+func DeriveDietaryProfile(ingredients []string, attributes map[string]IngredientAttributes) DietaryProfile {
+	profile := DietaryProfile{Diets: []string{}, Allergens: []string{}}
+	found := map[string]bool{}
+	var classified []IngredientAttributes
+
+	for _, name := range ingredients {
+		ingredient, ok := attributes[name]
+		if !ok {
+			profile.Unknown = append(profile.Unknown, name)
+			continue
+		}
+
+		classified = append(classified, ingredient)
+		for _, allergen := range ingredient.Allergens {
+			found[allergen] = true
+		}
+	}
+
+	for _, diet := range Diets {
+		suitable := true
+		for _, ingredient := range classified {
+			suitable = suitable && ingredient.SuitableFor(diet)
+		}
+
+		if suitable {
+			profile.Diets = append(profile.Diets, diet)
+		}
+	}
+
+	for _, allergen := range Allergens {
+		if found[allergen] {
+			profile.Allergens = append(profile.Allergens, allergen)
+		}
+	}
+
+	return profile
+}
+
+func contains(values []string, value string) bool {
+	for _, allowed := range values {
+		if value == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the rule is available in the validate tags of the ingredient attributes
+func init() {
+	validation.Register("allergens", func(value reflect.Value, _ string) string {
+		for i := 0; i < value.Len(); i++ {
+			if !IsAllergen(value.Index(i).String()) {
+				return "must be some of " + strings.Join(Allergens, ", ")
+			}
+		}
+		return ""
+	})
+}
@@ -0,0 +1,107 @@
+package recipes
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"reflect"
+	"testing"
+)
+
+func TestDeriveDietaryProfile(t *testing.T) {
+	attributes := map[string]IngredientAttributes{
+		"pasta":    {Name: "pasta", Vegan: true, Vegetarian: true, Allergens: []string{"gluten"}},
+		"tomato":   {Name: "tomato", Vegan: true, Vegetarian: true, GlutenFree: true},
+		"rice":     {Name: "rice", Vegan: true, Vegetarian: true, GlutenFree: true},
+		"parmesan": {Name: "parmesan", GlutenFree: true, Allergens: []string{"dairy"}},
+		"pesto":    {Name: "pesto", Vegetarian: true, GlutenFree: true, Allergens: []string{"tree-nut", "dairy"}},
+	}
+
+	tests := []struct {
+		name        string
+		ingredients []string
+		expected    DietaryProfile
+	}{
+		{
+			name:        "Suitable for all diets",
+			ingredients: []string{"rice", "tomato"},
+			expected:    DietaryProfile{Diets: []string{DietVegan, DietVegetarian, DietGlutenFree}, Allergens: []string{}},
+		},
+		{
+			name:        "Allergens of all ingredients",
+			ingredients: []string{"pasta", "pesto", "tomato"},
+			expected:    DietaryProfile{Diets: []string{DietVegetarian}, Allergens: []string{"dairy", "gluten", "tree-nut"}},
+		},
+		{
+			name:        "Not suitable for any diet",
+			ingredients: []string{"pasta", "parmesan"},
+			expected:    DietaryProfile{Diets: []string{}, Allergens: []string{"dairy", "gluten"}},
+		},
+		{
+			name:        "Unknown ingredients",
+			ingredients: []string{"rice", "saffron"},
+			expected: DietaryProfile{Diets: []string{DietVegan, DietVegetarian, DietGlutenFree}, Allergens: []string{},
+				Unknown: []string{"saffron"}},
+		},
+		{
+			name:        "Unknown and unsuitable ingredients",
+			ingredients: []string{"saffron", "parmesan"},
+			expected:    DietaryProfile{Diets: []string{DietGlutenFree}, Allergens: []string{"dairy"}, Unknown: []string{"saffron"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if profile := DeriveDietaryProfile(test.ingredients, attributes); !reflect.DeepEqual(profile, test.expected) {
+				t.Errorf("Got profile = %v but wanted %v", profile, test.expected)
+			}
+		})
+	}
+}
+
+func TestIngredientAttributes_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		attributes    IngredientAttributes
+		expectedField string
+	}{
+		{
+			name:       "Valid",
+			attributes: IngredientAttributes{Name: "walnut", Vegan: true, Vegetarian: true, GlutenFree: true, Allergens: []string{"Tree Nut"}},
+		},
+		{
+			name:          "Unknown allergen",
+			attributes:    IngredientAttributes{Name: "walnut", Allergens: []string{"nuts"}},
+			expectedField: "allergens",
+		},
+		{
+			name:          "Vegan but not vegetarian",
+			attributes:    IngredientAttributes{Name: "tofu", Vegan: true},
+			expectedField: "vegetarian",
+		},
+		{
+			name:          "Gluten free with gluten",
+			attributes:    IngredientAttributes{Name: "bread", GlutenFree: true, Allergens: []string{"gluten"}},
+			expectedField: "gluten_free",
+		},
+		{
+			name:          "Vegetarian with fish",
+			attributes:    IngredientAttributes{Name: "anchovy", Vegetarian: true, Allergens: []string{"fish"}},
+			expectedField: "allergens",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.attributes.Normalize()
+			err := test.attributes.Validate()
+
+			var field string
+			if typed, ok := apperror.As(err); ok && len(typed.Fields) > 0 {
+				field = typed.Fields[0].Field
+			}
+
+			if field != test.expectedField || (err == nil) != (test.expectedField == "") {
+				t.Errorf("Got error = %v but wanted an error for %q", err, test.expectedField)
+			}
+		})
+	}
+}
@@ -1,9 +1,40 @@
 package recipes
 
-// Ingredient struct describes a recipe ingredient with name, quantity and the quantity measurement
+import "github.com/krasimiraMilkova/cookit/pkg/units"
+
+// Ingredient struct describes a recipe ingredient with name, quantity and the quantity measurement,
+// the measurement is the symbol of a unit of the units package
 type Ingredient struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Quantity    uint   `json:"quantity"`
-	Measurement string `json:"measurement"`
+	ID          uint           `json:"id"`
+	Name        string         `json:"name" validate:"required,max=100"`
+	Quantity    units.Quantity `json:"quantity" validate:"quantity"`
+	Measurement string         `json:"measurement" validate:"required,unit"`
+}
+
+// InSystem function returns the ingredient with its quantity converted to the unit system,
+// measurements which are not known units are kept as they are
+func (ingredient Ingredient) InSystem(system units.System) Ingredient {
+	unit, ok := units.Lookup(ingredient.Measurement)
+	if !ok {
+		return ingredient
+	}
+
+	quantity, unit := units.ToSystem(ingredient.Quantity, unit, system)
+	ingredient.Quantity = quantity
+	ingredient.Measurement = unit.Symbol
+	return ingredient
+}
+
+// Scale function returns the ingredient with its quantity multiplied by the factor, in the unit it is best read in,
+// measurements which are not known units are only multiplied
+func (ingredient Ingredient) Scale(factor float64) Ingredient {
+	unit, ok := units.Lookup(ingredient.Measurement)
+	if !ok {
+		ingredient.Quantity = units.Quantity(float64(ingredient.Quantity) * factor).Round()
+		return ingredient
+	}
+
+	ingredient.Quantity, unit = units.Scale(ingredient.Quantity, unit, factor)
+	ingredient.Measurement = unit.Symbol
+	return ingredient
 }
@@ -0,0 +1,146 @@
+package recipes
+
+import (
+	"strings"
+)
+
+// irregularPlurals maps plural forms which cannot be singularized by removing a suffix
+var irregularPlurals = map[string]string{
+	"leaves":  "leaf",
+	"loaves":  "loaf",
+	"halves":  "half",
+	"knives":  "knife",
+	"calves":  "calf",
+	"geese":   "goose",
+	"mice":    "mouse",
+	"teeth":   "tooth",
+	"feet":    "foot",
+	"cookies": "cookie",
+}
+
+// uncountables are left unchanged although they look like plural forms
+var uncountables = map[string]bool{
+	"molasses": true, "hummus": true, "couscous": true, "asparagus": true, "swiss": true,
+	"series": true, "species": true, "grits": true, "citrus": true, "octopus": true,
+}
+
+// IngredientCanonicalizer maps ingredient names to the single name they are stored and searched by
+// Aliases maps normalized alternative names to normalized canonical names
+type IngredientCanonicalizer struct {
+	Aliases map[string]string
+}
+
+// Canonical function returns the normalized name, or the name it is an alias of
+func (canonicalizer IngredientCanonicalizer) Canonical(name string) string {
+	name = NormalizeIngredientName(name)
+
+	if canonical, ok := canonicalizer.Aliases[name]; ok {
+		return canonical
+	}
+
+	return name
+}
+
+// Closest function returns the canonical name, or if it is not one of the known names
+// the known name within a small edit distance from it, so that searches tolerate typos
+func (canonicalizer IngredientCanonicalizer) Closest(name string, known []string) string {
+	name = canonicalizer.Canonical(name)
+
+	best, bestDistance := name, maxTypos(name)+1
+	for _, candidate := range known {
+		if candidate == name {
+			return name
+		}
+
+		if distance := EditDistance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+// maxTypos allows one typo in short names and two in longer ones
+func maxTypos(name string) int {
+	switch length := len([]rune(name)); {
+	case length <= 3:
+		return 0
+	case length <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// NormalizeIngredientName function lower cases the name, collapses its whitespace
+// and turns its last word into singular, so tomatoes and " Tomato" are the same ingredient
+func NormalizeIngredientName(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+
+	words[len(words)-1] = Singular(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// Singular function returns the singular form of an english noun
+func Singular(word string) string {
+	if singular, ok := irregularPlurals[word]; ok {
+		return singular
+	}
+
+	if uncountables[word] || len(word) <= 3 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zzes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "oes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+
+	return word
+}
+
+// EditDistance function returns the Levenshtein distance between the two strings
+func EditDistance(a, b string) int {
+	first, second := []rune(a), []rune(b)
+
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(second)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package recipes
+
+import (
+	"testing"
+)
+
+func TestNormalizeIngredientName(t *testing.T) {
+	tests := map[string]string{
+		" Tomatoes ":        "tomato",
+		"tomato":            "tomato",
+		"Cherry  Tomatoes":  "cherry tomato",
+		"berries":           "berry",
+		"Bay leaves":        "bay leaf",
+		"olives":            "olive",
+		"peaches":           "peach",
+		"molasses":          "molasses",
+		"asparagus":         "asparagus",
+		"  ":                "",
+		"green onions":      "green onion",
+		"pies":              "pie",
+		"GLASSES OF WATER ": "glasses of water",
+	}
+
+	for name, expected := range tests {
+		if got := NormalizeIngredientName(name); got != expected {
+			t.Errorf("Got %q for %q but wanted %q", got, name, expected)
+		}
+	}
+}
+
+func TestIngredientCanonicalizer(t *testing.T) {
+	canonicalizer := IngredientCanonicalizer{Aliases: map[string]string{"cilantro": "coriander leaf"}}
+	known := []string{"tomato", "coriander leaf", "rice", "ice"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Alias", input: "Cilantro", expected: "coriander leaf"},
+		{name: "Known name", input: "ice", expected: "ice"},
+		{name: "One typo", input: "tomatto", expected: "tomato"},
+		{name: "Typo in alias target", input: "coriandr leaves", expected: "coriander leaf"},
+		{name: "Short names are exact", input: "icy", expected: "icy"},
+		{name: "Too many typos", input: "potato", expected: "potato"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := canonicalizer.Closest(test.input, known); got != test.expected {
+				t.Errorf("Got %q but wanted %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	if distance := EditDistance("kitten", "sitting"); distance != 3 {
+		t.Errorf("Got distance %v but wanted 3", distance)
+	}
+
+	if distance := EditDistance("", "egg"); distance != 3 {
+		t.Errorf("Got distance %v but wanted 3", distance)
+	}
+}
@@ -0,0 +1,240 @@
+package recipes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/krasimiraMilkova/cookit/pkg/units"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// gramsPerPinch is what a pinch of any ingredient weighs
+const gramsPerPinch = 0.36
+
+// Nutrients struct describes the energy in kcal and the protein, fat, carbs and fibre in grams of an amount of food
+type Nutrients struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Fat      float64 `json:"fat"`
+	Carbs    float64 `json:"carbs"`
+	Fibre    float64 `json:"fibre"`
+}
+
+// IngredientNutrients struct describes the nutrients in 100 grams of an ingredient and how many grams
+// a milliliter and a piece of it weigh, 0 when it is not known, which convert the other units to grams
+// Name is the canonical name of the ingredient, which does not have to be used by any recipe yet
+type IngredientNutrients struct {
+	Name          string    `json:"name"`
+	Per100g       Nutrients `json:"per_100g"`
+	GramsPerMl    float64   `json:"grams_per_ml,omitempty"`
+	GramsPerPiece float64   `json:"grams_per_piece,omitempty"`
+}
+
+// UnmatchedIngredient struct describes an ingredient left out of the nutrition of a recipe and why
+type UnmatchedIngredient struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Nutrition struct describes the nutrients of all ingredients of a recipe and of a single serving,
+// which is left out when the recipe does not say how many servings it makes
+// Unmatched lists the ingredients whose nutrients are not known, which are not part of the totals
+type Nutrition struct {
+	RecipeID   uint                  `json:"recipe_id"`
+	Servings   uint                  `json:"servings,omitempty"`
+	Total      Nutrients             `json:"total"`
+	PerServing *Nutrients            `json:"per_serving,omitempty"`
+	Unmatched  []UnmatchedIngredient `json:"unmatched_ingredients"`
+}
+
+// Round function rounds the nutrients to one decimal
+func (nutrients Nutrients) Round() Nutrients {
+	return nutrients.Times(1)
+}
+
+// Times function returns the nutrients multiplied by the factor and rounded to one decimal
+func (nutrients Nutrients) Times(factor float64) Nutrients {
+	round := func(value float64) float64 {
+		return math.Round(value*factor*10) / 10
+	}
+
+	return Nutrients{
+		Calories: round(nutrients.Calories),
+		Protein:  round(nutrients.Protein),
+		Fat:      round(nutrients.Fat),
+		Carbs:    round(nutrients.Carbs),
+		Fibre:    round(nutrients.Fibre),
+	}
+}
+
+func (nutrients Nutrients) plus(other Nutrients, factor float64) Nutrients {
+	return Nutrients{
+		Calories: nutrients.Calories + other.Calories*factor,
+		Protein:  nutrients.Protein + other.Protein*factor,
+		Fat:      nutrients.Fat + other.Fat*factor,
+		Carbs:    nutrients.Carbs + other.Carbs*factor,
+		Fibre:    nutrients.Fibre + other.Fibre*factor,
+	}
+}
+
+// Grams function converts the quantity of the ingredient in the measurement to grams
+// Returns the reason if the quantity cannot be converted, because the weight of a milliliter
+// or a piece of the ingredient is not known
+func (ingredient IngredientNutrients) Grams(quantity units.Quantity, measurement string) (float64, string) {
+	unit, ok := units.Lookup(measurement)
+	if !ok {
+		return 0, "the unit " + measurement + " is not known"
+	}
+
+	base := float64(units.ToBase(quantity, unit))
+	switch {
+	case unit.Dimension == units.Mass:
+		return base, ""
+	case unit.Dimension == units.Volume && ingredient.GramsPerMl > 0:
+		return base * ingredient.GramsPerMl, ""
+	case unit.Symbol == "pinch":
+		return base * gramsPerPinch, ""
+	case unit.Dimension == units.Count && ingredient.GramsPerPiece > 0:
+		return base * ingredient.GramsPerPiece, ""
+	}
+
+	return 0, "the grams in " + unit.Symbol + " of the ingredient are not known"
+}
+
+// CalculateNutrition function adds up the nutrients of the ingredients of the recipe from the nutrients
+// of the ingredients, keyed by ingredient name
+func CalculateNutrition(recipe *Recipe, table map[string]IngredientNutrients) Nutrition {
+	nutrition := Nutrition{RecipeID: recipe.ID, Servings: recipe.Servings, Unmatched: []UnmatchedIngredient{}}
+	var total Nutrients
+
+	for _, ingredient := range recipe.Ingredients {
+		nutrients, ok := table[ingredient.Name]
+		if !ok {
+			nutrition.Unmatched = append(nutrition.Unmatched,
+				UnmatchedIngredient{Name: ingredient.Name, Reason: "the nutrients of the ingredient are not known"})
+			continue
+		}
+
+		grams, reason := nutrients.Grams(ingredient.Quantity, ingredient.Measurement)
+		if reason != "" {
+			nutrition.Unmatched = append(nutrition.Unmatched, UnmatchedIngredient{Name: ingredient.Name, Reason: reason})
+			continue
+		}
+
+		total = total.plus(nutrients.Per100g, grams/100)
+	}
+
+	nutrition.Total = total.Round()
+	if recipe.Servings > 0 {
+		perServing := total.Times(1 / float64(recipe.Servings))
+		nutrition.PerServing = &perServing
+	}
+
+	return nutrition
+}
+
+// nutrientColumns maps the fields of IngredientNutrients to the beginnings of the headers of the csv columns
+// holding them, like the columns of a USDA FoodData Central export
+var nutrientColumns = map[string][]string{
+	"name":            {"name", "description", "food", "ingredient"},
+	"calories":        {"calories", "energy", "kcal"},
+	"protein":         {"protein"},
+	"fat":             {"fat", "total lipid", "total fat"},
+	"carbs":           {"carbs", "carbohydrate"},
+	"fibre":           {"fibre", "fiber"},
+	"grams_per_ml":    {"grams_per_ml", "density"},
+	"grams_per_piece": {"grams_per_piece", "piece"},
+}
+
+// requiredNutrientColumns are the columns every nutrient csv must have
+var requiredNutrientColumns = []string{"name", "calories", "protein", "fat", "carbs", "fibre"}
+
+// ParseNutrientsCSV function reads the nutrients in 100 grams of ingredients from a csv with a header row,
+// the name of an ingredient is the text of its name column before the first comma, so "Tomatoes, red, raw" is tomatoes
+// Energy columns in kJ are skipped and empty values are 0
+// Returns an error naming the line of invalid values or the missing columns
+func ParseNutrientsCSV(reader io.Reader) ([]IngredientNutrients, error) {
+	rows := csv.NewReader(reader)
+	rows.FieldsPerRecord = -1
+
+	header, err := rows.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the header of the nutrients: %v", err)
+	}
+
+	columns := nutrientColumnIndexes(header)
+	for _, column := range requiredNutrientColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("the nutrients have no %s column", column)
+		}
+	}
+
+	var ingredients []IngredientNutrients
+	for line := 2; ; line++ {
+		record, err := rows.Read()
+		if err == io.EOF {
+			return ingredients, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := map[string]float64{}
+		for column, index := range columns {
+			if column == "name" || index >= len(record) || strings.TrimSpace(record[index]) == "" {
+				continue
+			}
+
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[index]), 64)
+			if err != nil || value < 0 {
+				return nil, fmt.Errorf("line %d: %s must be a number of at least 0", line, column)
+			}
+			values[column] = value
+		}
+
+		name := ""
+		if columns["name"] < len(record) {
+			name = strings.TrimSpace(strings.Split(record[columns["name"]], ",")[0])
+		}
+		if name == "" {
+			return nil, fmt.Errorf("line %d: name must not be empty", line)
+		}
+
+		ingredients = append(ingredients, IngredientNutrients{
+			Name: name,
+			Per100g: Nutrients{
+				Calories: values["calories"],
+				Protein:  values["protein"],
+				Fat:      values["fat"],
+				Carbs:    values["carbs"],
+				Fibre:    values["fibre"],
+			},
+			GramsPerMl:    values["grams_per_ml"],
+			GramsPerPiece: values["grams_per_piece"],
+		})
+	}
+}
+
+// nutrientColumnIndexes finds the first column of the header for every field, skipping energy in kJ
+func nutrientColumnIndexes(header []string) map[string]int {
+	columns := map[string]int{}
+
+	for index, title := range header {
+		title = strings.ToLower(strings.TrimSpace(title))
+		if strings.Contains(title, "kj") {
+			continue
+		}
+
+		for column, prefixes := range nutrientColumns {
+			for _, prefix := range prefixes {
+				if _, found := columns[column]; !found && strings.HasPrefix(title, prefix) {
+					columns[column] = index
+				}
+			}
+		}
+	}
+
+	return columns
+}
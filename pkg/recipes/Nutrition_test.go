@@ -0,0 +1,81 @@
+package recipes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCalculateNutrition(t *testing.T) {
+	table := map[string]IngredientNutrients{
+		"flour":     {Name: "flour", Per100g: Nutrients{Calories: 364, Protein: 10.3, Fat: 1, Carbs: 76.3, Fibre: 2.7}, GramsPerMl: 0.53},
+		"egg":       {Name: "egg", Per100g: Nutrients{Calories: 143, Protein: 12.6, Fat: 9.5, Carbs: 0.7}, GramsPerPiece: 50},
+		"milk":      {Name: "milk", Per100g: Nutrients{Calories: 61, Protein: 3.2, Fat: 3.3, Carbs: 4.8}, GramsPerMl: 1.03},
+		"salt":      {Name: "salt"},
+		"blueberry": {Name: "blueberry", Per100g: Nutrients{Calories: 57, Protein: 0.7, Fat: 0.3, Carbs: 14.5, Fibre: 2.4}},
+	}
+
+	recipe := &Recipe{
+		ID:       7,
+		Servings: 4,
+		Ingredients: []Ingredient{
+			{Name: "flour", Quantity: 200, Measurement: "g"},
+			{Name: "egg", Quantity: 2, Measurement: "pcs"},
+			{Name: "milk", Quantity: 0.3, Measurement: "l"},
+			{Name: "salt", Quantity: 1, Measurement: "pinch"},
+			{Name: "blueberry", Quantity: 1, Measurement: "cup"},
+			{Name: "vanilla", Quantity: 1, Measurement: "tsp"},
+		},
+	}
+
+	nutrition := CalculateNutrition(recipe, table)
+
+	expected := Nutrition{
+		RecipeID:   7,
+		Servings:   4,
+		Total:      Nutrients{Calories: 1059.5, Protein: 43.1, Fat: 21.7, Carbs: 168.1, Fibre: 5.4},
+		PerServing: &Nutrients{Calories: 264.9, Protein: 10.8, Fat: 5.4, Carbs: 42, Fibre: 1.4},
+		Unmatched: []UnmatchedIngredient{
+			{Name: "blueberry", Reason: "the grams in cup of the ingredient are not known"},
+			{Name: "vanilla", Reason: "the nutrients of the ingredient are not known"},
+		},
+	}
+	if !reflect.DeepEqual(nutrition, expected) {
+		t.Errorf("Got nutrition = %+v, %+v but wanted %+v, %+v", nutrition, nutrition.PerServing, expected, expected.PerServing)
+	}
+
+	recipe.Servings = 0
+	if nutrition = CalculateNutrition(recipe, table); nutrition.PerServing != nil {
+		t.Errorf("Got nutrition per serving = %v for unknown servings", nutrition.PerServing)
+	}
+}
+
+func TestParseNutrientsCSV(t *testing.T) {
+	usda := "Description,Energy (kJ),Energy (kcal),Protein (g),Total lipid (fat) (g),\"Carbohydrate, by difference (g)\",\"Fiber, total dietary (g)\"\n" +
+		"\"Tomatoes, red, ripe, raw\",74,18,0.88,0.2,3.89,1.2\n" +
+		"\"Butter, salted\",2999,717,0.85,81.11,0.06,\n"
+
+	nutrients, err := ParseNutrientsCSV(strings.NewReader(usda))
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	expected := []IngredientNutrients{
+		{Name: "Tomatoes", Per100g: Nutrients{Calories: 18, Protein: 0.88, Fat: 0.2, Carbs: 3.89, Fibre: 1.2}},
+		{Name: "Butter", Per100g: Nutrients{Calories: 717, Protein: 0.85, Fat: 81.11, Carbs: 0.06}},
+	}
+	if !reflect.DeepEqual(nutrients, expected) {
+		t.Errorf("Got nutrients = %v but wanted %v", nutrients, expected)
+	}
+
+	own := "name,calories,protein,fat,carbs,fibre,grams_per_ml,grams_per_piece\negg,143,12.6,9.5,0.7,0,,50\n"
+	if nutrients, err = ParseNutrientsCSV(strings.NewReader(own)); err != nil || nutrients[0].GramsPerPiece != 50 {
+		t.Errorf("Got nutrients = %v, error = %v but wanted 50 grams per egg", nutrients, err)
+	}
+
+	for _, invalid := range []string{"name,calories\negg,143\n", own + "milk,a lot,3.2,3.3,4.8,0,1.03,\n", own + ",1,1,1,1,1,,\n"} {
+		if _, err = ParseNutrientsCSV(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
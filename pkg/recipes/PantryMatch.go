@@ -0,0 +1,123 @@
+package recipes
+
+import (
+	"sort"
+	"strings"
+)
+
+// AnyMissing allows a pantry match to miss any number of recipe ingredients
+const AnyMissing = -1
+
+// PantryQuery describes the ingredients the user has, the ingredients a recipe must not contain
+// and how many recipe ingredients the user may be missing
+type PantryQuery struct {
+	Available  []string
+	Excluded   []string
+	MaxMissing int
+}
+
+// RecipeIngredients pairs a recipe summary with the names of all of its ingredients
+type RecipeIngredients struct {
+	RecipeSearchResult
+	Ingredients []string
+}
+
+// PantryMatch serves as a result from the pantry search describing how well a recipe fits the available ingredients
+// Coverage is the share of the recipe ingredients which are available, between 0 and 1
+type PantryMatch struct {
+	RecipeSearchResult
+	Matched  []string `json:"matched"`
+	Missing  []string `json:"missing"`
+	Coverage float64  `json:"coverage"`
+}
+
+// PantryMatchPage is a single page of pantry matches ordered from the best match
+type PantryMatchPage struct {
+	Items      []PantryMatch `json:"items"`
+	NextCursor string        `json:"next_cursor"`
+	Total      int           `json:"total"`
+}
+
+// ParsePantry function splits a comma separated list of ingredient names into a pantry query
+// Names prefixed with - are excluded and empty names are skipped
+func ParsePantry(list string) PantryQuery {
+	query := PantryQuery{MaxMissing: AnyMissing}
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+
+		if strings.HasPrefix(name, "-") {
+			if excluded := strings.TrimSpace(name[1:]); excluded != "" {
+				query.Excluded = append(query.Excluded, excluded)
+			}
+		} else if name != "" {
+			query.Available = append(query.Available, name)
+		}
+	}
+
+	return query
+}
+
+// MatchPantry function scores the candidate recipes against the query
+// Returns the matches which contain no excluded ingredient and miss at most MaxMissing ingredients,
+// ordered by coverage, then by fewest missing ingredients and then by title
+func MatchPantry(candidates []RecipeIngredients, query PantryQuery) []PantryMatch {
+	available := nameSet(query.Available)
+	excluded := nameSet(query.Excluded)
+
+	matches := []PantryMatch{}
+	for _, candidate := range candidates {
+		match := PantryMatch{RecipeSearchResult: candidate.RecipeSearchResult, Matched: []string{}, Missing: []string{}}
+		allowed := true
+
+		for _, ingredient := range candidate.Ingredients {
+			name := NormalizeIngredientName(ingredient)
+
+			if excluded[name] {
+				allowed = false
+				break
+			}
+
+			if available[name] {
+				match.Matched = append(match.Matched, ingredient)
+			} else {
+				match.Missing = append(match.Missing, ingredient)
+			}
+		}
+
+		if !allowed || len(match.Matched) == 0 {
+			continue
+		}
+
+		if query.MaxMissing != AnyMissing && len(match.Missing) > query.MaxMissing {
+			continue
+		}
+
+		match.Coverage = float64(len(match.Matched)) / float64(len(candidate.Ingredients))
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Coverage != b.Coverage {
+			return a.Coverage > b.Coverage
+		}
+		if len(a.Missing) != len(b.Missing) {
+			return len(a.Missing) < len(b.Missing)
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	})
+
+	return matches
+}
+
+func nameSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[NormalizeIngredientName(name)] = true
+	}
+	return set
+}
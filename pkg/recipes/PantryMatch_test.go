@@ -0,0 +1,86 @@
+package recipes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePantry(t *testing.T) {
+	query := ParsePantry("tomato, Onion ,-garlic,, - ,-")
+
+	expected := PantryQuery{
+		Available:  []string{"tomato", "Onion"},
+		Excluded:   []string{"garlic"},
+		MaxMissing: AnyMissing,
+	}
+
+	if !reflect.DeepEqual(query, expected) {
+		t.Errorf("Got query = %v but wanted %v", query, expected)
+	}
+}
+
+func TestMatchPantry(t *testing.T) {
+	candidates := []RecipeIngredients{
+		{RecipeSearchResult: RecipeSearchResult{ID: 1, Title: "Salad"}, Ingredients: []string{"tomato", "onion", "oil"}},
+		{RecipeSearchResult: RecipeSearchResult{ID: 2, Title: "Sauce"}, Ingredients: []string{"Tomato", "garlic"}},
+		{RecipeSearchResult: RecipeSearchResult{ID: 3, Title: "Soup"}, Ingredients: []string{"tomato", "onion"}},
+		{RecipeSearchResult: RecipeSearchResult{ID: 4, Title: "Bread"}, Ingredients: []string{"flour", "water"}},
+	}
+
+	tests := []struct {
+		name        string
+		query       PantryQuery
+		expectedIds []uint
+		expected    *PantryMatch
+	}{
+		{
+			name:        "Ranked by coverage",
+			query:       PantryQuery{Available: []string{"tomato", "onion"}, MaxMissing: AnyMissing},
+			expectedIds: []uint{3, 1, 2},
+			expected: &PantryMatch{
+				RecipeSearchResult: RecipeSearchResult{ID: 1, Title: "Salad"},
+				Matched:            []string{"tomato", "onion"},
+				Missing:            []string{"oil"},
+				Coverage:           2.0 / 3.0,
+			},
+		},
+		{
+			name:        "Require all ingredients",
+			query:       PantryQuery{Available: []string{"tomato", "onion"}, MaxMissing: 0},
+			expectedIds: []uint{3},
+		},
+		{
+			name:        "Allow one missing",
+			query:       PantryQuery{Available: []string{"tomato", "onion"}, MaxMissing: 1},
+			expectedIds: []uint{3, 1, 2},
+		},
+		{
+			name:        "Exclude ingredient",
+			query:       PantryQuery{Available: []string{"tomato"}, Excluded: []string{"Garlic"}, MaxMissing: AnyMissing},
+			expectedIds: []uint{3, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matches := MatchPantry(candidates, test.query)
+
+			var ids []uint
+			for _, match := range matches {
+				ids = append(ids, match.ID)
+			}
+
+			if !reflect.DeepEqual(ids, test.expectedIds) {
+				t.Errorf("Got matches = %v but wanted %v", ids, test.expectedIds)
+			}
+
+			if test.expected != nil {
+				for _, match := range matches {
+					if match.ID == test.expected.ID && !reflect.DeepEqual(match, *test.expected) {
+						t.Errorf("Got match = %v but wanted %v", match, *test.expected)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package recipes
+
+import "github.com/krasimiraMilkova/cookit/pkg/apperror"
+
+// Bounds of the stars a user can give to a recipe
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// ErrRecipeNotFound is returned when a recipe that does not exist is fetched or rated
+var ErrRecipeNotFound error = apperror.NotFound("recipe not found")
+
+// Rating struct describes the stars a user gave to a recipe, a user has a single rating per recipe
+type Rating struct {
+	RecipeID uint `json:"recipe_id"`
+	UserID   uint `json:"user_id"`
+	Rating   int  `json:"rating"`
+}
+
+// RatingSummary struct describes the average rating of a recipe and how many users rated it
+// AverageRating is 0 for recipes which have not been rated
+type RatingSummary struct {
+	AverageRating float64 `json:"average_rating"`
+	RatingCount   int     `json:"rating_count"`
+}
+
+// RatingResult serves as a result from rating a recipe carrying the rating of the user and the updated summary
+type RatingResult struct {
+	Rating
+	RatingSummary
+}
+
+// Valid function checks if the rating is within the allowed bounds
+func (rating Rating) Valid() bool {
+	return rating.Rating >= MinRating && rating.Rating <= MaxRating
+}
+
+// RatedAtLeast function checks if the average rating of the result is not lower than the minimum rating
+func (result RecipeSearchResult) RatedAtLeast(minRating float64) bool {
+	return minRating == 0 || result.AverageRating >= minRating
+}
@@ -1,7 +1,25 @@
 package recipes
 
+import "time"
+
 // RecipeSearchResult serves as a result form the recipe search operations
+// carrying the summary shown in recipe listings
 type RecipeSearchResult struct {
-	ID    uint   `json:"id"`
-	Title string `json:"title"`
+	ID              uint      `json:"id"`
+	Title           string    `json:"title"`
+	AuthorID        uint      `json:"author_id"`
+	Author          string    `json:"author"`
+	IngredientCount int       `json:"ingredient_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	RatingSummary
+}
+
+// RecipeSearchPage is a single page of search results
+// NextCursor is empty when there are no more results and Total is the number of results on all pages
+// Facets counts the results on all pages for every value of the curated facets and for the most used free tags
+type RecipeSearchPage struct {
+	Items      []RecipeSearchResult    `json:"items"`
+	NextCursor string                  `json:"next_cursor"`
+	Total      int                     `json:"total"`
+	Facets     map[string][]FacetCount `json:"facets,omitempty"`
 }
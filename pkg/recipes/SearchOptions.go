@@ -0,0 +1,18 @@
+package recipes
+
+// Fields by which the search results can be sorted
+const (
+	SortByTitle     = "title"
+	SortByCreatedAt = "created_at"
+	SortByRating    = "rating"
+)
+
+// SearchOptions describes which page of the search results is returned, how the results are sorted
+// and the lowest average rating of the returned recipes, where 0 includes recipes which are not rated
+type SearchOptions struct {
+	Limit      int
+	Offset     int
+	SortBy     string
+	Descending bool
+	MinRating  float64
+}
@@ -0,0 +1,86 @@
+package recipes
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/units"
+	"strings"
+)
+
+// Kinds of steps, the minutes of the prep steps add up to the prep time of a recipe
+// and the minutes of the cook steps to its cook time
+const (
+	StepPrep = "prep"
+	StepCook = "cook"
+)
+
+// MaxStepMinutes is the longest duration of a step, a week of curing or proofing
+const MaxStepMinutes = 10080
+
+// Step struct describes one of the ordered steps of the directions of a recipe with the minutes it takes,
+// the temperature of the oven or the pan and the names of the ingredients of the recipe it uses
+// Steps sent without a kind are cook steps if they have a temperature and prep steps otherwise
+type Step struct {
+	Text            string       `json:"text" validate:"required,max=5000"`
+	Kind            string       `json:"kind" validate:"oneof=prep cook"`
+	DurationMinutes uint         `json:"duration_minutes,omitempty" validate:"max=10080"`
+	Temperature     *Temperature `json:"temperature,omitempty" validate:"dive"`
+	Ingredients     []string     `json:"ingredients,omitempty" validate:"max=100"`
+}
+
+// Temperature struct describes the temperature a step cooks at in degrees of a temperature unit
+type Temperature struct {
+	Degrees units.Quantity `json:"degrees" validate:"min=-100,max=1000"`
+	Unit    string         `json:"unit" validate:"required,temperature"`
+}
+
+// SplitDirections function splits free text directions into steps, one for every line which is not blank
+func SplitDirections(directions string) []Step {
+	var steps []Step
+
+	for _, line := range strings.Split(directions, "\n") {
+		if text := strings.TrimSpace(line); text != "" {
+			steps = append(steps, Step{Text: text})
+		}
+	}
+
+	return steps
+}
+
+// JoinSteps function returns the text of the steps as free text directions, one step per line
+func JoinSteps(steps []Step) string {
+	lines := make([]string, len(steps))
+	for i, step := range steps {
+		lines[i] = step.Text
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// withKind returns the step with the kind it is inferred to have if it is sent without one
+func (step Step) withKind() Step {
+	if step.Kind != "" {
+		return step
+	}
+
+	step.Kind = StepPrep
+	if step.Temperature != nil {
+		step.Kind = StepCook
+	}
+
+	return step
+}
+
+// InSystem function returns the step with its temperature converted to the unit system
+func (step Step) InSystem(system units.System) Step {
+	if step.Temperature == nil {
+		return step
+	}
+
+	unit, ok := units.Lookup(step.Temperature.Unit)
+	if !ok {
+		return step
+	}
+
+	degrees, unit := units.ToSystem(step.Temperature.Degrees, unit, system)
+	step.Temperature = &Temperature{Degrees: degrees, Unit: unit.Symbol}
+	return step
+}
@@ -0,0 +1,115 @@
+package recipes
+
+import (
+	"encoding/json"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/units"
+	"reflect"
+	"testing"
+)
+
+func TestSplitDirections(t *testing.T) {
+	steps := SplitDirections("Chop the onion\r\n\n   Fry it  \nServe\n")
+
+	expected := []Step{{Text: "Chop the onion"}, {Text: "Fry it"}, {Text: "Serve"}}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("Got steps = %v but wanted %v", steps, expected)
+	}
+
+	if directions := JoinSteps(steps); directions != "Chop the onion\nFry it\nServe" {
+		t.Errorf("Got directions = %q", directions)
+	}
+}
+
+func TestRecipe_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name                string
+		body                string
+		expectedSteps       []Step
+		expectedDirections  string
+		expectedPrepMinutes uint
+		expectedCookMinutes uint
+	}{
+		{
+			name:               "Free text directions",
+			body:               `{"directions":"Whisk the eggs\nFry them"}`,
+			expectedSteps:      []Step{{Text: "Whisk the eggs", Kind: StepPrep}, {Text: "Fry them", Kind: StepPrep}},
+			expectedDirections: "Whisk the eggs\nFry them",
+		},
+		{
+			name: "Steps",
+			body: `{"directions":"ignored","steps":[` +
+				`{"text":"Knead the dough","duration_minutes":10,"ingredients":["flour"]},` +
+				`{"text":"Let it rise","kind":"prep","duration_minutes":60},` +
+				`{"text":"Bake","duration_minutes":25,"temperature":{"degrees":220,"unit":"°C"}},` +
+				`{"text":"Simmer the sauce","kind":"cook","duration_minutes":15}]}`,
+			expectedSteps: []Step{
+				{Text: "Knead the dough", Kind: StepPrep, DurationMinutes: 10, Ingredients: []string{"flour"}},
+				{Text: "Let it rise", Kind: StepPrep, DurationMinutes: 60},
+				{Text: "Bake", Kind: StepCook, DurationMinutes: 25, Temperature: &Temperature{Degrees: 220, Unit: "°C"}},
+				{Text: "Simmer the sauce", Kind: StepCook, DurationMinutes: 15},
+			},
+			expectedDirections:  "Knead the dough\nLet it rise\nBake\nSimmer the sauce",
+			expectedPrepMinutes: 70,
+			expectedCookMinutes: 40,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recipe := Recipe{}
+			if err := json.Unmarshal([]byte(test.body), &recipe); err != nil {
+				t.Fatal("error from unmarshal", err)
+			}
+
+			if !reflect.DeepEqual(recipe.Steps, test.expectedSteps) || recipe.Directions != test.expectedDirections {
+				t.Errorf("Got steps = %v, directions = %q but wanted %v, %q",
+					recipe.Steps, recipe.Directions, test.expectedSteps, test.expectedDirections)
+			}
+
+			if recipe.PrepMinutes != test.expectedPrepMinutes || recipe.CookMinutes != test.expectedCookMinutes {
+				t.Errorf("Got prep = %v, cook = %v minutes but wanted %v, %v", recipe.PrepMinutes, recipe.CookMinutes,
+					test.expectedPrepMinutes, test.expectedCookMinutes)
+			}
+		})
+	}
+}
+
+func TestRecipe_ValidateSteps(t *testing.T) {
+	recipe := Recipe{
+		Title:       "Bread",
+		Ingredients: []Ingredient{{Name: "flour", Quantity: 500, Measurement: "g"}},
+		Steps: []Step{
+			{Text: "Bake", Kind: "roast", Temperature: &Temperature{Degrees: 220, Unit: "g"}},
+			{Text: " ", Kind: StepPrep, DurationMinutes: MaxStepMinutes + 1},
+		},
+	}
+
+	err := recipe.Validate()
+
+	var fields []string
+	if typed, ok := apperror.As(err); ok {
+		for _, field := range typed.Fields {
+			fields = append(fields, field.Field)
+		}
+	}
+
+	expected := []string{"steps[0].kind", "steps[0].temperature.unit", "steps[1].text", "steps[1].duration_minutes"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Got error = %v with fields %v but wanted %v", err, fields, expected)
+	}
+}
+
+func TestRecipe_InSystem_ConvertsTemperatures(t *testing.T) {
+	recipe := Recipe{Steps: []Step{{Text: "Bake", Temperature: &Temperature{Degrees: 180, Unit: "°C"}}, {Text: "Serve"}}}
+
+	recipe.InSystem(units.Imperial)
+
+	if temperature := recipe.Steps[0].Temperature; *temperature != (Temperature{Degrees: 356, Unit: "°F"}) {
+		t.Errorf("Got temperature = %v but wanted 356 °F", *temperature)
+	}
+
+	if recipe.Steps[1].Temperature != nil {
+		t.Errorf("Got temperature = %v for a step without one", recipe.Steps[1].Temperature)
+	}
+}
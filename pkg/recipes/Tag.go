@@ -0,0 +1,110 @@
+package recipes
+
+import (
+	"fmt"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/validation"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// Facets are the curated kinds of tags, whose values are picked from FacetValues
+const (
+	FacetCuisine    = "cuisine"
+	FacetCourse     = "course"
+	FacetMealType   = "meal_type"
+	FacetDifficulty = "difficulty"
+	// FacetTag is the kind of the free tags, which can be any words
+	FacetTag = "tag"
+)
+
+// Limits of the free tags of a recipe
+const (
+	MaxTags      = 20
+	MaxTagLength = 30
+)
+
+// Facets lists the curated facets in the order they are shown
+var Facets = []string{FacetCuisine, FacetCourse, FacetMealType, FacetDifficulty}
+
+// FacetValues lists the values recipes can have for every curated facet
+var FacetValues = map[string][]string{
+	FacetCuisine: {"american", "british", "bulgarian", "chinese", "french", "greek", "indian", "italian", "japanese",
+		"korean", "mediterranean", "mexican", "middle-eastern", "spanish", "thai", "turkish", "vietnamese"},
+	FacetCourse:     {"appetizer", "main", "side", "soup", "salad", "dessert", "drink"},
+	FacetMealType:   {"breakfast", "brunch", "lunch", "dinner", "snack"},
+	FacetDifficulty: {"easy", "medium", "hard"},
+}
+
+// ErrEmptySearchFilter is returned when a search would find every recipe
+var ErrEmptySearchFilter error = apperror.Validation("the search needs a title, ingredients, tags, facets, diets or excluded allergens")
+
+// FacetCount struct describes how many of the recipes found by a search have a value of a facet
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SearchFilter struct describes which recipes a search finds, the recipes with a title containing the title,
+// any of the ingredients, all of the free tags and the values of the facets, suitable for all of the diets
+// and without any of the excluded allergens, empty fields do not filter
+type SearchFilter struct {
+	Title             string
+	Ingredients       []string
+	Tags              []string
+	Facets            map[string]string
+	Diets             []string
+	ExcludedAllergens []string
+}
+
+// IsEmpty function checks if the filter finds every recipe
+func (filter SearchFilter) IsEmpty() bool {
+	return filter.Title == "" && len(filter.Ingredients) == 0 && len(filter.Tags) == 0 && len(filter.Facets) == 0 &&
+		len(filter.Diets) == 0 && len(filter.ExcludedAllergens) == 0
+}
+
+// NormalizeTag function returns the tag in lower case with its words joined by dashes, like "gluten-free"
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// IsFacetValue function checks if the value is one of the values of the curated facet
+func IsFacetValue(facet string, value string) bool {
+	return contains(FacetValues[facet], value)
+}
+
+// normalizeTags returns the normalized tags without duplicates and blank tags
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized
+}
+
+// the rules are available in the validate tags of the recipes and of the payloads using them
+func init() {
+	validation.Register("facet", func(value reflect.Value, facet string) string {
+		if value.String() != "" && !IsFacetValue(facet, value.String()) {
+			return "must be one of " + strings.Join(FacetValues[facet], ", ")
+		}
+		return ""
+	})
+
+	validation.Register("tags", func(value reflect.Value, _ string) string {
+		for i := 0; i < value.Len(); i++ {
+			if length := utf8.RuneCountInString(value.Index(i).String()); length == 0 || length > MaxTagLength {
+				return fmt.Sprintf("must be words of 1 to %d characters", MaxTagLength)
+			}
+		}
+		return ""
+	})
+}
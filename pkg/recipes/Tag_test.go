@@ -0,0 +1,47 @@
+package recipes
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecipe_NormalizeTags(t *testing.T) {
+	recipe := Recipe{Tags: []string{" Gluten  Free", "vegan", "", "VEGAN"}, Cuisine: " Middle Eastern ", Course: "Main"}
+
+	recipe.Normalize()
+
+	if !reflect.DeepEqual(recipe.Tags, []string{"gluten-free", "vegan"}) {
+		t.Errorf("Got tags = %v", recipe.Tags)
+	}
+
+	expected := map[string]string{FacetCuisine: "middle-eastern", FacetCourse: "main"}
+	if facets := recipe.FacetValues(); !reflect.DeepEqual(facets, expected) {
+		t.Errorf("Got facets = %v but wanted %v", facets, expected)
+	}
+}
+
+func TestRecipe_ValidateTags(t *testing.T) {
+	recipe := Recipe{
+		Title:       "Soup",
+		Ingredients: []Ingredient{{Name: "water", Quantity: 1, Measurement: "l"}},
+		Steps:       []Step{{Text: "Boil", Kind: StepCook}},
+		Tags:        []string{"warm", strings.Repeat("a", MaxTagLength+1)},
+		Cuisine:     "martian",
+		MealType:    "dinner",
+	}
+
+	err := recipe.Validate()
+
+	var fields []string
+	if typed, ok := apperror.As(err); ok {
+		for _, field := range typed.Fields {
+			fields = append(fields, field.Field)
+		}
+	}
+
+	if expected := []string{"tags", "cuisine"}; !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Got error = %v with fields %v but wanted %v", err, fields, expected)
+	}
+}
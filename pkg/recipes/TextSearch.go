@@ -0,0 +1,353 @@
+package recipes
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Fields of a recipe covered by the full text index
+const (
+	FieldTitle       = "title"
+	FieldIngredients = "ingredients"
+	FieldDirections  = "directions"
+)
+
+// searchFields lists the indexed fields in a fixed order, so scores are summed the same way every time
+var searchFields = []string{FieldTitle, FieldIngredients, FieldDirections}
+
+// fieldWeights makes a term found in the title count more than one found in the ingredients or directions
+var fieldWeights = map[string]float64{
+	FieldTitle:       3,
+	FieldIngredients: 2,
+	FieldDirections:  1,
+}
+
+// stopWords are skipped when they are a separate query term, but are still indexed so phrases can contain them
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "for": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// SearchTerm is a single stemmed word of an indexed recipe field together with its position in the field
+type SearchTerm struct {
+	Field    string
+	Term     string
+	Position int
+}
+
+// RecipeTerms pairs a recipe summary with the indexed terms of the recipe matching a text query
+type RecipeTerms struct {
+	RecipeSearchResult
+	Terms []SearchTerm
+}
+
+// TextQuery is a parsed full text query, every term and every phrase has to be found in a matching recipe
+type TextQuery struct {
+	Terms   []string
+	Phrases [][]string
+}
+
+// TextMatch serves as a result from the full text search, a higher score means a more relevant recipe
+type TextMatch struct {
+	RecipeSearchResult
+	Score float64 `json:"score"`
+}
+
+// TextMatchPage is a single page of full text matches ordered from the most relevant
+type TextMatchPage struct {
+	Items      []TextMatch `json:"items"`
+	NextCursor string      `json:"next_cursor"`
+	Total      int         `json:"total"`
+}
+
+// MaxTermLength is the longest indexed word in bytes, longer words are skipped
+const MaxTermLength = 100
+
+// Tokenize function splits the text into lower case stemmed words
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if len(word) <= MaxTermLength {
+			terms = append(terms, Stem(word))
+		}
+	}
+
+	return terms
+}
+
+// IndexRecipe function returns the search terms of the title, ingredient names and directions of the recipe
+// Positions of different ingredients are not consecutive, so a phrase cannot span two ingredients
+func IndexRecipe(recipe *Recipe) []SearchTerm {
+	terms := fieldTerms(FieldTitle, recipe.Title, 0)
+
+	position := 0
+	for _, ingredient := range recipe.Ingredients {
+		ingredientTerms := fieldTerms(FieldIngredients, ingredient.Name, position)
+		terms = append(terms, ingredientTerms...)
+		position += len(ingredientTerms) + 1
+	}
+
+	return append(terms, fieldTerms(FieldDirections, recipe.Directions, 0)...)
+}
+
+func fieldTerms(field string, text string, start int) []SearchTerm {
+	words := Tokenize(text)
+	terms := make([]SearchTerm, len(words))
+
+	for i, word := range words {
+		terms[i] = SearchTerm{Field: field, Term: word, Position: start + i}
+	}
+
+	return terms
+}
+
+// ParseTextQuery function splits the query into terms and double quoted phrases
+// Stop words outside of phrases are skipped, as well as repeated terms
+func ParseTextQuery(q string) TextQuery {
+	query := TextQuery{}
+	seen := map[string]bool{}
+
+	for i, part := range strings.Split(q, "\"") {
+		words := Tokenize(part)
+
+		// odd parts are between quotes, an unclosed quote makes a phrase till the end of the query
+		if i%2 == 1 {
+			if len(words) > 1 {
+				query.Phrases = append(query.Phrases, words)
+				continue
+			}
+			if len(words) == 1 {
+				seen[words[0]] = true
+				query.Terms = append(query.Terms, words[0])
+			}
+			continue
+		}
+
+		for _, word := range words {
+			if stopWords[word] || seen[word] {
+				continue
+			}
+			seen[word] = true
+			query.Terms = append(query.Terms, word)
+		}
+	}
+
+	return query
+}
+
+// Empty function tells if the query has nothing to search for
+func (query TextQuery) Empty() bool {
+	return len(query.Terms) == 0 && len(query.Phrases) == 0
+}
+
+// AllTerms function returns the distinct terms of the query including the words of its phrases
+func (query TextQuery) AllTerms() []string {
+	seen := map[string]bool{}
+	var terms []string
+
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	for _, term := range query.Terms {
+		add(term)
+	}
+	for _, phrase := range query.Phrases {
+		for _, term := range phrase {
+			add(term)
+		}
+	}
+
+	return terms
+}
+
+// MatchText function scores the candidate recipes against the query out of the given number of indexed recipes
+// Returns the recipes containing every term and phrase of the query, ordered by score and then by title
+// Terms are weighted by the field they are found in and by how rare they are among the recipes
+func MatchText(candidates []RecipeTerms, query TextQuery, documents int) []TextMatch {
+	frequencies := documentFrequencies(candidates)
+
+	matches := []TextMatch{}
+	for _, candidate := range candidates {
+		score, ok := scoreCandidate(candidate, query, documents, frequencies)
+		if !ok {
+			continue
+		}
+
+		matches = append(matches, TextMatch{RecipeSearchResult: candidate.RecipeSearchResult, Score: score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	})
+
+	return matches
+}
+
+// documentFrequencies counts in how many of the candidates every term is found
+func documentFrequencies(candidates []RecipeTerms) map[string]int {
+	frequencies := map[string]int{}
+
+	for _, candidate := range candidates {
+		seen := map[string]bool{}
+		for _, term := range candidate.Terms {
+			if !seen[term.Term] {
+				seen[term.Term] = true
+				frequencies[term.Term]++
+			}
+		}
+	}
+
+	return frequencies
+}
+
+func scoreCandidate(candidate RecipeTerms, query TextQuery, documents int, frequencies map[string]int) (float64, bool) {
+	// positions of every term by field
+	positions := map[string]map[string][]int{}
+	for _, term := range candidate.Terms {
+		if positions[term.Term] == nil {
+			positions[term.Term] = map[string][]int{}
+		}
+		positions[term.Term][term.Field] = append(positions[term.Term][term.Field], term.Position)
+	}
+
+	idf := func(term string) float64 {
+		return math.Log(1 + float64(documents)/float64(frequencies[term]))
+	}
+
+	score := 0.0
+	for _, term := range query.Terms {
+		fields, ok := positions[term]
+		if !ok {
+			return 0, false
+		}
+
+		for _, field := range searchFields {
+			if found := len(fields[field]); found > 0 {
+				score += idf(term) * fieldWeights[field] * (1 + math.Log(float64(found)))
+			}
+		}
+	}
+
+	for _, phrase := range query.Phrases {
+		bestWeight := 0.0
+		for _, field := range searchFields {
+			if weight := fieldWeights[field]; weight > bestWeight && containsPhrase(positions, field, phrase) {
+				bestWeight = weight
+			}
+		}
+
+		if bestWeight == 0 {
+			return 0, false
+		}
+
+		for _, term := range phrase {
+			score += idf(term) * bestWeight
+		}
+	}
+
+	return score, true
+}
+
+// containsPhrase tells if the words of the phrase are found one after another in the field
+func containsPhrase(positions map[string]map[string][]int, field string, phrase []string) bool {
+	for _, start := range positions[phrase[0]][field] {
+		found := true
+
+		for offset, term := range phrase[1:] {
+			if !containsPosition(positions[term][field], start+offset+1) {
+				found = false
+				break
+			}
+		}
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsPosition(positions []int, position int) bool {
+	for _, p := range positions {
+		if p == position {
+			return true
+		}
+	}
+	return false
+}
+
+// Stem function reduces an english word to its stem by removing plural and verb suffixes,
+// so that words like bake, baked and baking or berry and berries are indexed as the same term
+func Stem(word string) string {
+	if len(word) <= 3 {
+		return yToI(word)
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"), strings.HasSuffix(word, "ied"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "xes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") &&
+		!strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			word = undouble(word[:len(word)-len(suffix)])
+			break
+		}
+	}
+
+	word = yToI(word)
+	if strings.HasSuffix(word, "e") && len(word) > 3 {
+		word = word[:len(word)-1]
+	}
+
+	return word
+}
+
+// yToI replaces the last y following a consonant with i, as it becomes in the plural form
+func yToI(word string) string {
+	n := len(word)
+	if n < 2 || word[n-1] != 'y' || strings.ContainsRune("aeiouy", rune(word[n-2])) {
+		return word
+	}
+
+	return word[:n-1] + "i"
+}
+
+// undouble removes the last letter of words like chopp or stirr left after removing a suffix
+func undouble(word string) string {
+	n := len(word)
+	if n < 2 || word[n-1] != word[n-2] {
+		return word
+	}
+
+	switch word[n-1] {
+	case 'l', 's', 'z', 'e', 'o':
+		return word
+	}
+
+	return word[:n-1]
+}
@@ -0,0 +1,110 @@
+package recipes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string][]string{
+		"bak":    {"bake", "baked", "baking", "bakes"},
+		"tomato": {"tomato", "tomatoes"},
+		"berri":  {"berry", "berries"},
+		"chop":   {"chop", "chopped", "chopping"},
+		"glass":  {"glass", "glasses"},
+		"egg":    {"egg", "eggs"},
+		"fri":    {"fry", "fried", "fries"},
+	}
+
+	for stem, words := range tests {
+		for _, word := range words {
+			if got := Stem(word); got != stem {
+				t.Errorf("Got stem = %v for %v but wanted %v", got, word, stem)
+			}
+		}
+	}
+}
+
+func TestParseTextQuery(t *testing.T) {
+	query := ParseTextQuery(`Baked "olive oil" and the tomatoes, tomato "salt" "pepper`)
+
+	expected := TextQuery{
+		Terms:   []string{"bak", "tomato", "salt", "pepper"},
+		Phrases: [][]string{{"oliv", "oil"}},
+	}
+
+	if !reflect.DeepEqual(query, expected) {
+		t.Errorf("Got query = %v but wanted %v", query, expected)
+	}
+
+	if !ParseTextQuery(` the "" , `).Empty() {
+		t.Error("expected a query of stop words to be empty")
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	recipes := []*Recipe{
+		{ID: 1, Title: "Tomato soup", Ingredients: []Ingredient{{Name: "tomato"}, {Name: "olive oil"}}, Directions: "Boil the tomatoes"},
+		{ID: 2, Title: "Salad", Ingredients: []Ingredient{{Name: "tomato"}, {Name: "oil"}, {Name: "olive"}}, Directions: "Mix"},
+		{ID: 3, Title: "Bread", Ingredients: []Ingredient{{Name: "flour"}}, Directions: "Bake with a tomato on top"},
+		{ID: 4, Title: "Cake", Ingredients: []Ingredient{{Name: "flour"}}, Directions: "Bake"},
+	}
+
+	tests := []struct {
+		name        string
+		q           string
+		expectedIds []uint
+	}{
+		{
+			name:        "Ranked by field",
+			q:           "tomatoes",
+			expectedIds: []uint{1, 2, 3},
+		},
+		{
+			name:        "All terms required",
+			q:           "tomato baking",
+			expectedIds: []uint{3},
+		},
+		{
+			name:        "Phrase",
+			q:           `"olive oil"`,
+			expectedIds: []uint{1},
+		},
+		{
+			name: "No matches",
+			q:    "pasta",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := ParseTextQuery(test.q)
+			terms := map[string]bool{}
+			for _, term := range query.AllTerms() {
+				terms[term] = true
+			}
+
+			var candidates []RecipeTerms
+			for _, recipe := range recipes {
+				candidate := RecipeTerms{RecipeSearchResult: RecipeSearchResult{ID: recipe.ID, Title: recipe.Title}}
+				for _, term := range IndexRecipe(recipe) {
+					if terms[term.Term] {
+						candidate.Terms = append(candidate.Terms, term)
+					}
+				}
+				if len(candidate.Terms) > 0 {
+					candidates = append(candidates, candidate)
+				}
+			}
+
+			var ids []uint
+			for _, match := range MatchText(candidates, query, len(recipes)) {
+				ids = append(ids, match.ID)
+			}
+
+			if !reflect.DeepEqual(ids, test.expectedIds) {
+				t.Errorf("Got matches = %v but wanted %v", ids, test.expectedIds)
+			}
+		})
+	}
+}
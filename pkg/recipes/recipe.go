@@ -1,9 +1,156 @@
 package recipes
 
-// Recipe struct describes a recipe for cooking consisting of title, ingredients and directions
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"github.com/krasimiraMilkova/cookit/pkg/units"
+	"github.com/krasimiraMilkova/cookit/pkg/validation"
+	"reflect"
+)
+
+// MaxServings is the largest number of servings a recipe can make or be scaled to
+const MaxServings = 1000
+
+// ErrUnknownServings is returned when a recipe which does not say how many servings it makes is scaled
+var ErrUnknownServings error = apperror.Conflict("the recipe does not say how many servings it makes")
+
+// Recipe struct describes a recipe for cooking consisting of title, ingredients and ordered steps
+// as well as the id of the user who authored it and how it is rated
+// Servings is the number of servings the quantities of the ingredients make, 0 when it is not known
+// Directions are the text of the steps, one per line, and the prep and cook minutes add up the durations
+// of the steps, they are all computed from the steps by Normalize
+// Tags are free words describing the recipe, while its cuisine, course, meal type and difficulty are curated facets
+// Dietary is derived from the attributes of the ingredients when the recipe is fetched and is not stored
 type Recipe struct {
-	ID          uint         `json:"id"`
-	Title       string       `json:"title"`
-	Ingredients []Ingredient `json:"ingredients"`
-	Directions  string       `json:"directions"`
+	ID          uint            `json:"id"`
+	AuthorID    uint            `json:"author_id"`
+	Title       string          `json:"title" validate:"required,max=100"`
+	Servings    uint            `json:"servings" validate:"servings"`
+	Ingredients []Ingredient    `json:"ingredients" validate:"required,max=100,dive"`
+	Steps       []Step          `json:"steps" validate:"required,max=100,dive"`
+	Directions  string          `json:"directions"`
+	PrepMinutes uint            `json:"prep_minutes"`
+	CookMinutes uint            `json:"cook_minutes"`
+	Tags        []string        `json:"tags,omitempty" validate:"max=20,tags"`
+	Cuisine     string          `json:"cuisine,omitempty" validate:"facet=cuisine"`
+	Course      string          `json:"course,omitempty" validate:"facet=course"`
+	MealType    string          `json:"meal_type,omitempty" validate:"facet=meal_type"`
+	Difficulty  string          `json:"difficulty,omitempty" validate:"facet=difficulty"`
+	Dietary     *DietaryProfile `json:"dietary,omitempty"`
+	RatingSummary
+}
+
+// UnmarshalJSON function decodes the recipe and normalizes it, so recipes sent with free text directions
+// instead of steps, like before recipes had steps, are still accepted
+func (recipe *Recipe) UnmarshalJSON(data []byte) error {
+	type plainRecipe Recipe
+	if err := json.Unmarshal(data, (*plainRecipe)(recipe)); err != nil {
+		return err
+	}
+
+	recipe.Normalize()
+	return nil
+}
+
+// Normalize function splits the free text directions of the recipe into steps if it has no steps,
+// infers the kinds of the steps sent without one and computes the directions and the prep and cook minutes
+// from the steps
+// Tags and facet values are normalized like NormalizeTag does and duplicated tags are dropped
+func (recipe *Recipe) Normalize() {
+	recipe.Tags = normalizeTags(recipe.Tags)
+	for _, value := range []*string{&recipe.Cuisine, &recipe.Course, &recipe.MealType, &recipe.Difficulty} {
+		*value = NormalizeTag(*value)
+	}
+
+	if len(recipe.Steps) == 0 {
+		recipe.Steps = SplitDirections(recipe.Directions)
+	}
+
+	recipe.PrepMinutes, recipe.CookMinutes = 0, 0
+	for i, step := range recipe.Steps {
+		step = step.withKind()
+		recipe.Steps[i] = step
+
+		if step.Kind == StepCook {
+			recipe.CookMinutes += step.DurationMinutes
+		} else {
+			recipe.PrepMinutes += step.DurationMinutes
+		}
+	}
+
+	recipe.Directions = JoinSteps(recipe.Steps)
+}
+
+// Validate function checks the recipe and its ingredients against the rules of their validate tags
+// Returns a validation error listing the invalid fields
+func (recipe *Recipe) Validate() error {
+	return validation.Validate("recipe", recipe)
+}
+
+// FacetValues function returns the values of the curated facets the recipe has
+func (recipe *Recipe) FacetValues() map[string]string {
+	values := map[string]string{}
+
+	for facet, value := range map[string]string{FacetCuisine: recipe.Cuisine, FacetCourse: recipe.Course,
+		FacetMealType: recipe.MealType, FacetDifficulty: recipe.Difficulty} {
+		if value != "" {
+			values[facet] = value
+		}
+	}
+
+	return values
+}
+
+// SetFacetValue function sets the value of the curated facet of the recipe, values of unknown facets are ignored
+func (recipe *Recipe) SetFacetValue(facet string, value string) {
+	switch facet {
+	case FacetCuisine:
+		recipe.Cuisine = value
+	case FacetCourse:
+		recipe.Course = value
+	case FacetMealType:
+		recipe.MealType = value
+	case FacetDifficulty:
+		recipe.Difficulty = value
+	}
+}
+
+// InSystem function converts the quantities of the ingredients and the temperatures of the steps
+// of the recipe to the unit system
+func (recipe *Recipe) InSystem(system units.System) {
+	for i, ingredient := range recipe.Ingredients {
+		recipe.Ingredients[i] = ingredient.InSystem(system)
+	}
+
+	for i, step := range recipe.Steps {
+		recipe.Steps[i] = step.InSystem(system)
+	}
+}
+
+// Scale function scales the quantities of the ingredients of the recipe proportionally to the servings,
+// rounded to amounts which can be measured in a kitchen
+// Returns ErrUnknownServings if the recipe does not say how many servings it makes
+func (recipe *Recipe) Scale(servings uint) error {
+	if recipe.Servings == 0 {
+		return ErrUnknownServings
+	}
+
+	factor := float64(servings) / float64(recipe.Servings)
+	for i, ingredient := range recipe.Ingredients {
+		recipe.Ingredients[i] = ingredient.Scale(factor)
+	}
+
+	recipe.Servings = servings
+	return nil
+}
+
+// the rule is available in the validate tags of the recipes, so stored recipes and scaled ones share MaxServings
+func init() {
+	validation.Register("servings", func(value reflect.Value, _ string) string {
+		if value.Uint() > MaxServings {
+			return fmt.Sprintf("must be at most %d", MaxServings)
+		}
+		return ""
+	})
 }
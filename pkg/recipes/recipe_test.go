@@ -0,0 +1,24 @@
+package recipes
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"testing"
+)
+
+func TestRecipe_ValidateServings(t *testing.T) {
+	for servings, valid := range map[uint]bool{0: true, 4: true, MaxServings: true, MaxServings + 1: false} {
+		recipe := Recipe{
+			Title:       "Soup",
+			Servings:    servings,
+			Ingredients: []Ingredient{{Name: "water", Quantity: 1, Measurement: "l"}},
+			Steps:       []Step{{Text: "Boil", Kind: StepCook}},
+		}
+
+		err := recipe.Validate()
+
+		typed, ok := apperror.As(err)
+		if valid != (err == nil) || !valid && (!ok || typed.Fields[0].Field != "servings") {
+			t.Errorf("Got error = %v for %d servings", err, servings)
+		}
+	}
+}
@@ -2,19 +2,91 @@ package recipes
 
 // RecipeRepository interface provides functions for CRUD operations for recipe entity
 type RecipeRepository interface {
-	// CreateRecipe function provide insert db operation for recipe and included ingredients that do not exist yet
-	// Returns an error if such occurs during the db query execution
+	// CreateRecipe function provide insert db operation for recipe, its steps, tags and included ingredients that do not exist yet
+	// The ingredient names of the recipe and of its steps are replaced by their canonical names
+	// Returns a validation error if the recipe has empty fields or its steps use ingredients which are not in the recipe
+	// or an error if such occurs during the db query execution
 	CreateRecipe(recipe *Recipe) error
 
-	// FindRecipesByTitle function provide search operation for recipes by given title
-	// Returns an error if such occurs during the db query execution otherwise returns a List of RecipeSearchResults
-	FindRecipesByTitle(title string) ([]RecipeSearchResult, error)
+	// UpdateRecipe function provide update db operation for the title, servings, ingredients, steps and tags
+	// of the recipe with the same id, replacing all of its previous ingredients, steps and tags
+	// The ingredient names of the recipe and of its steps are replaced by their canonical names
+	// Returns a validation error if the recipe has empty fields or its steps use ingredients which are not in the recipe
+	// or an error if such occurs during the db query execution
+	UpdateRecipe(recipe *Recipe) error
+
+	// DeleteRecipe function provide delete db operation for the recipe with the given id and its ingredients
+	// Returns an error if such occurs during the db query execution
+	DeleteRecipe(id int) error
+
+	// FindRecipes function provide search operation for recipes found by the filter, by title, by ingredient names
+	// matched by their canonical names, tolerating typos in names which are not known ingredients, by tags and facets,
+	// and by diets and excluded allergens, which skip recipes with ingredients known to break the diets or contain the allergens,
+	// returning the page of results selected and filtered by the options
+	// Returns ErrEmptySearchFilter if the filter is empty or an error if such occurs during the db query execution
+	// otherwise returns a List of RecipeSearchResults and the total number of matching recipes
+	FindRecipes(filter SearchFilter, options SearchOptions) ([]RecipeSearchResult, int, error)
+
+	// CountFacets function provide operation for counting the recipes found by the filter and the minimum rating
+	// of the options for every value of the curated facets and for the most used free tags
+	// Returns ErrEmptySearchFilter if the filter is empty or an error if such occurs during the db query execution
+	// otherwise returns the FacetCounts by facet, ordered from the most common value
+	CountFacets(filter SearchFilter, options SearchOptions) (map[string][]FacetCount, error)
+
+	// FindRecipesByAuthor function provide search operation for the recipes of the user with the given id
+	// returning the page of results selected and filtered by the options
+	// Returns an error if such occurs during the db query execution
+	// otherwise returns a List of RecipeSearchResults and the total number of the recipes of the user
+	FindRecipesByAuthor(authorId uint, options SearchOptions) ([]RecipeSearchResult, int, error)
+
+	// FindRecipesForPantry function provide search operation for recipes containing at least one of the available
+	// and none of the excluded ingredients of the query, together with the names of all of their ingredients
+	// The available and excluded names of the query are replaced by their canonical names
+	// Returns an error if such occurs during the db query execution otherwise returns the List of candidate recipes
+	FindRecipesForPantry(query *PantryQuery) ([]RecipeIngredients, error)
+
+	// FindRecipesForText function provide search operation in the full text index for recipes containing
+	// any of the terms of the query, together with their indexed terms which are part of the query
+	// Returns an error if such occurs during the db query execution otherwise returns the List of candidate recipes
+	// and the number of all recipes
+	FindRecipesForText(query TextQuery) ([]RecipeTerms, int, error)
+
+	// RateRecipe function provide insert or update db operation for the rating of the user for the recipe
+	// Returns ErrRecipeNotFound if the recipe does not exist or an error if such occurs during the db query execution
+	// otherwise returns the updated RatingSummary of the recipe
+	RateRecipe(rating Rating) (RatingSummary, error)
+
+	// FindIngredientAttributes function provide operation for obtaining the attributes of all classified ingredients
+	// Returns an error if such occurs during the db query execution
+	// otherwise returns a List of IngredientAttributes ordered by ingredient name
+	FindIngredientAttributes() ([]IngredientAttributes, error)
+
+	// SaveIngredientAttributes function provide insert or update db operation for the attributes of the ingredient,
+	// replacing its previous allergens, the name of the ingredient is replaced by its canonical name
+	// Returns a validation error if the attributes are invalid or contradict each other
+	// or an error if such occurs during the db query execution
+	SaveIngredientAttributes(attributes *IngredientAttributes) error
+
+	// DeleteIngredientAttributes function provide delete db operation for the attributes of the ingredient
+	// with the given name, which is matched by its canonical name
+	// Returns ErrIngredientNotClassified if the ingredient has no attributes
+	// or an error if such occurs during the db query execution
+	DeleteIngredientAttributes(name string) error
+
+	// FindIngredientNutrients function provide operation for obtaining the nutrients of the ingredients
+	// with the given canonical names
+	// Returns an error if such occurs during the db query execution
+	// otherwise returns the IngredientNutrients of the ingredients whose nutrients are known, by ingredient name
+	FindIngredientNutrients(names []string) (map[string]IngredientNutrients, error)
 
-	// FindRecipesByIngredients function provide search operation for recipes by given list of ingredient names
-	// Returns an error if such occurs during the db query execution otherwise returns a List of RecipeSearchResults
-	FindRecipesByIngredients(ingredients []string) ([]RecipeSearchResult, error)
+	// SaveIngredientNutrients function provide insert or update db operation for the nutrients of the ingredients,
+	// whose names are replaced by their canonical names, the first ingredient of every canonical name is saved
+	// Returns an error if such occurs during the db query execution otherwise returns the number of saved ingredients
+	SaveIngredientNutrients(nutrients []IngredientNutrients) (int, error)
 
-	// FindRecipeById( function provide operation for obtaining a recipe and its ingredients for the given id
-	// Returns an error if such occurs during the db query execution otherwise returns a Recipe
+	// FindRecipeById function provide operation for obtaining a recipe, its ingredients, steps and tags for the given id
+	// together with its dietary profile derived from the attributes of its ingredients
+	// Returns ErrRecipeNotFound if the recipe does not exist or an error if such occurs during the db query execution
+	// otherwise returns a Recipe
 	FindRecipeById(id int) (*Recipe, error)
 }
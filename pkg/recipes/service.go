@@ -5,33 +5,112 @@ import "net/http"
 
 // RecipeService interface provide handlers for creating and searching for recipes
 type RecipeService interface {
-	// CreateRecipe function handles payload for creating a recipe
-	// Returns Status BadRequest if cannot decode the payload,
+	// CreateRecipe function handles payload for creating a recipe authored by the user from the request context,
+	// recipes sent with free text directions instead of steps get a step for every line of the directions
+	// Returns Status BadRequest if cannot decode the payload or the recipe breaks the rules of its validate tags,
+	// Status RequestEntityTooLarge if the payload is over the size limit,
+	// Status Forbidden if there is no user in the request context,
 	// Status InternalServerError if error occurs during recipe creation and
 	// Status Created if recipe is successfully inserted into the db
 	CreateRecipe(w http.ResponseWriter, r *http.Request)
 
-	// FindRecipesByTitle function handles requests for fetching recipes by title
-	// provided as an query parameter
-	// Returns Status BadRequest if cannot decode the query parameter,
+	// UpdateRecipe function handles payload replacing the title, servings, ingredients and steps
+	// of the recipe with id provided as a path variable
+	// Returns Status BadRequest if cannot parse the recipe id or decode the payload or the updated recipe is invalid,
+	// Status Forbidden if the user is neither the author of the recipe nor an admin,
+	// Status NotFound if a recipe with this id does not exist,
+	// Status InternalServerError if error occurs during recipe update and
+	// Status OK and the updated Recipe if it is successfully updated
+	UpdateRecipe(w http.ResponseWriter, r *http.Request)
+
+	// PatchRecipe function handles payload updating only the provided title, servings, ingredients or steps
+	// of the recipe with id provided as a path variable, free text directions replace the steps
+	// Returns the same statuses as UpdateRecipe
+	PatchRecipe(w http.ResponseWriter, r *http.Request)
+
+	// DeleteRecipe function handles requests for deleting the recipe with id provided as a path variable
+	// Returns Status BadRequest if cannot parse the recipe id,
+	// Status Forbidden if the user is neither the author of the recipe nor an admin,
+	// Status NotFound if a recipe with this id does not exist,
+	// Status InternalServerError if error occurs during deletion and
+	// Status NoContent if the recipe is successfully deleted
+	DeleteRecipe(w http.ResponseWriter, r *http.Request)
+
+	// FindRecipes function handles requests for fetching recipes by the title, the comma separated ingredients,
+	// the free tags (repeated or comma separated tag query parameters), the cuisine, course, meal_type
+	// and difficulty facets, the diets (diet) and the allergens to exclude (exclude_allergens), given like the tags,
+	// provided as query parameters, finding the recipes matching all of them
+	// The limit, cursor or offset and sort (title, created_at or rating, prefixed with - for descending order)
+	// query parameters select the returned page and min_rating skips recipes with a lower average rating
+	// Returns Status BadRequest if none of the filters is given or cannot decode the query parameters,
 	// Status InternalServerError if error occurs during searching and
-	// Status NotFound if no recipes have been found for the title and
-	// Status OK and RecipeSearchResults if such are found
-	FindRecipesByTitle(w http.ResponseWriter, r *http.Request)
+	// Status OK and a RecipeSearchPage with the facet counts of all found recipes,
+	// which is empty if no recipes have been found
+	FindRecipes(w http.ResponseWriter, r *http.Request)
 
-	// FindRecipesByIngredients function handles requests for fetching recipes by list of ingredients
-	// provided as an query parameter
-	// Returns Status BadRequest if cannot decode the query parameter,
+	// FindRecipesByPantry function handles requests for recipes which can be cooked with the comma separated
+	// ingredients provided as the pantry query parameter, where names prefixed with - must not be used
+	// The max_missing query parameter limits how many recipe ingredients may be missing and match=all
+	// requires all of them to be available, the limit and cursor or offset query parameters select the returned page
+	// and min_rating skips recipes with a lower average rating
+	// Returns Status BadRequest if cannot decode the query parameters,
 	// Status InternalServerError if error occurs during searching and
-	// Status NotFound if no recipes have been found for the ingredients and
-	// Status OK and RecipeSearchResults if such are found
-	FindRecipesByIngredients(w http.ResponseWriter, r *http.Request)
+	// Status OK and a PantryMatchPage ordered from the best match
+	FindRecipesByPantry(w http.ResponseWriter, r *http.Request)
+
+	// FindRecipesByText function handles requests for a full text search of the q query parameter
+	// in the titles, ingredients and directions of the recipes, where words are matched by their stem
+	// and double quoted words have to be found together as a phrase
+	// Accepts the same paging query parameters as FindRecipesByPantry
+	// Returns Status BadRequest if cannot decode the query parameters,
+	// Status InternalServerError if error occurs during searching and
+	// Status OK and a TextMatchPage ordered from the most relevant recipe
+	FindRecipesByText(w http.ResponseWriter, r *http.Request)
+
+	// RateRecipe function handles payload with the 1 to 5 stars rating the user from the request context
+	// gives to the recipe with id provided as a path variable, replacing the previous rating of the user
+	// Returns Status BadRequest if cannot parse the recipe id or decode the payload or the rating is out of bounds,
+	// Status Forbidden if there is no user in the request context,
+	// Status NotFound if a recipe with this id does not exist,
+	// Status InternalServerError if error occurs during rating and
+	// Status OK and a RatingResult with the updated rating summary of the recipe
+	RateRecipe(w http.ResponseWriter, r *http.Request)
 
 	// FindRecipesByTitle function handles requests for fetching recipes by id
-	// provided as a path variable
+	// provided as a path variable, the quantities are converted to the system of the units query parameter if it is sent
+	// and scaled to the servings query parameter if it is sent
+	// Returns Status BadRequest if cannot parse the recipe id, the units are neither metric nor imperial
+	// or the servings are not a number from 1 to MaxServings,
+	// Status InternalServerError if error occurs during fetching,
+	// Status NotFound if a recipes with this id does not exist,
+	// Status Conflict if servings are sent but the recipe does not say how many servings it makes and
+	// Status OK and the Recipe with its dietary profile if such are found
+	FindRecipeById(w http.ResponseWriter, r *http.Request)
+
+	// FindRecipeNutrition function handles requests for the nutrition facts of the recipe with id provided
+	// as a path variable, computed from its current ingredients whenever they are requested
 	// Returns Status BadRequest if cannot parse the recipe id,
+	// Status NotFound if a recipe with this id does not exist,
 	// Status InternalServerError if error occurs during fetching and
-	// Status NotFound if a recipes with this id does not exist and
-	// Status OK and the Recipe if such are found
-	FindRecipeById(w http.ResponseWriter, r *http.Request)
+	// Status OK and the Nutrition of the whole recipe and of a serving, listing the ingredients whose nutrients are not known
+	FindRecipeNutrition(w http.ResponseWriter, r *http.Request)
+
+	// ListIngredientAttributes function handles admin requests for the attributes of all classified ingredients
+	// Returns Status InternalServerError if error occurs during fetching and
+	// Status OK and the List of IngredientAttributes ordered by ingredient name
+	ListIngredientAttributes(w http.ResponseWriter, r *http.Request)
+
+	// UpdateIngredientAttributes function handles admin payload with the diets the ingredient with name provided
+	// as a path variable is suitable for and the allergens it contains, replacing its previous attributes
+	// Returns Status BadRequest if cannot decode the payload or the attributes are invalid or contradict each other,
+	// Status InternalServerError if error occurs during saving and
+	// Status OK and the saved IngredientAttributes with the canonical name of the ingredient
+	UpdateIngredientAttributes(w http.ResponseWriter, r *http.Request)
+
+	// DeleteIngredientAttributes function handles admin requests for removing the attributes of the ingredient
+	// with name provided as a path variable, so recipes using it lose their diet labels
+	// Returns Status NotFound if the ingredient is not classified,
+	// Status InternalServerError if error occurs during deletion and
+	// Status NoContent if the attributes are successfully removed
+	DeleteIngredientAttributes(w http.ResponseWriter, r *http.Request)
 }
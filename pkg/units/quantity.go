@@ -0,0 +1,124 @@
+package units
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// quantityPrecision is the number of decimals quantities are kept with, as many as the database stores
+	quantityPrecision = 4
+	// displayPrecision is the number of decimals converted quantities are shown with
+	displayPrecision = 2
+)
+
+// errInvalidQuantity is returned for quantities which are neither decimals nor fractions
+var errInvalidQuantity = errors.New("quantity must be a decimal like 0.25 or a fraction like 1/2 or 1 1/2")
+
+// Quantity is an amount of a unit, it can be fractional
+// It is decoded from a json number or a string holding a decimal or a fraction and encoded as a number
+type Quantity float64
+
+// ParseQuantity function parses a decimal like "0.25", a fraction like "1/2" or a mixed number like "1 1/2"
+// Returns an error if the text is none of them
+func ParseQuantity(text string) (Quantity, error) {
+	parts := strings.Fields(text)
+
+	switch len(parts) {
+	case 1:
+		return parseNumber(parts[0])
+	case 2:
+		whole, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil || !strings.Contains(parts[1], "/") {
+			return 0, errInvalidQuantity
+		}
+
+		fraction, err := parseNumber(parts[1])
+		if err != nil {
+			return 0, err
+		}
+
+		return Quantity(float64(whole) + float64(fraction)).Round(), nil
+	}
+
+	return 0, errInvalidQuantity
+}
+
+func parseNumber(text string) (Quantity, error) {
+	slash := strings.Index(text, "/")
+	if slash < 0 {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, errInvalidQuantity
+		}
+
+		return Quantity(value).Round(), nil
+	}
+
+	numerator, err := strconv.ParseUint(text[:slash], 10, 32)
+	if err != nil {
+		return 0, errInvalidQuantity
+	}
+
+	denominator, err := strconv.ParseUint(text[slash+1:], 10, 32)
+	if err != nil || denominator == 0 {
+		return 0, errInvalidQuantity
+	}
+
+	return Quantity(float64(numerator) / float64(denominator)).Round(), nil
+}
+
+// Round function rounds the quantity to the decimals it is stored with, so 1/3 and its stored value are equal
+func (quantity Quantity) Round() Quantity {
+	return round(quantity, quantityPrecision)
+}
+
+func round(quantity Quantity, decimals int) Quantity {
+	scale := math.Pow(10, float64(decimals))
+	return Quantity(math.Round(float64(quantity)*scale) / scale)
+}
+
+func (quantity *Quantity) UnmarshalJSON(data []byte) error {
+	// a missing quantity is left zero and rejected by the validation
+	if string(data) == "null" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		text = string(data)
+	}
+
+	parsed, err := ParseQuantity(text)
+	if err != nil {
+		return err
+	}
+
+	*quantity = parsed
+	return nil
+}
+
+// String function formats the quantity as a mixed number when its fraction is a common one,
+// like 1 1/2 or 3/4, and as a decimal otherwise
+func (quantity Quantity) String() string {
+	whole, fraction := math.Modf(float64(quantity))
+
+	for _, denominator := range []float64{2, 3, 4, 8} {
+		numerator := math.Round(fraction * denominator)
+		if numerator == 0 || numerator == denominator || math.Abs(fraction*denominator-numerator) > 0.01 {
+			continue
+		}
+
+		text := strconv.Itoa(int(numerator)) + "/" + strconv.Itoa(int(denominator))
+		if whole == 0 {
+			return text
+		}
+
+		return strconv.Itoa(int(whole)) + " " + text
+	}
+
+	return strconv.FormatFloat(float64(quantity), 'f', -1, 64)
+}
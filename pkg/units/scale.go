@@ -0,0 +1,93 @@
+package units
+
+import "math"
+
+// promotionTolerance lets conversions which miss a whole unit only by the precision of the factors,
+// like 3 tsp giving 0.99999 tbsp, promote the quantity
+const promotionTolerance = 0.001
+
+// kitchenFractions are the fractions scaled quantities of non metric units are rounded to
+var kitchenFractions = []float64{0, 1.0 / 8, 1.0 / 4, 1.0 / 3, 3.0 / 8, 1.0 / 2, 5.0 / 8, 2.0 / 3, 3.0 / 4, 7.0 / 8, 1}
+
+// Scale function multiplies the quantity of the unit by the factor, promotes or demotes it to the unit
+// of its system it is best read in, like 48 tsp to 1 cup, and rounds it to an amount which can be measured
+// in a kitchen, metric amounts to round numbers and the others to fractions like 1/3 or 3/4
+// Temperatures are not scaled, an oven is as hot for two as for six
+func Scale(quantity Quantity, unit Unit, factor float64) (Quantity, Unit) {
+	if unit.Dimension == Temperature {
+		return quantity, unit
+	}
+
+	scaled := Quantity(float64(quantity) * factor)
+
+	if candidates := displayUnits[unit.System][unit.Dimension]; contains(candidates, unit) {
+		target := candidates[0]
+		for _, candidate := range candidates[1:] {
+			if converted, _ := Convert(scaled, unit, candidate); converted >= 1-promotionTolerance {
+				target = candidate
+			}
+		}
+
+		scaled, _ = Convert(scaled, unit, target)
+		unit = target
+	}
+
+	if unit.System == Metric {
+		return roundMetric(scaled), unit
+	}
+
+	return roundToFraction(scaled), unit
+}
+
+// roundMetric rounds large amounts to 5, medium ones to whole numbers and small ones to 0.05,
+// so 0.4 stays 0.4 but 237.3 ml becomes 235 ml
+func roundMetric(quantity Quantity) Quantity {
+	step := 0.05
+
+	switch {
+	case quantity >= 100:
+		step = 5
+	case quantity >= 10:
+		step = 1
+	}
+
+	rounded := math.Round(float64(quantity)/step) * step
+	if rounded == 0 {
+		rounded = step
+	}
+
+	return round(Quantity(rounded), quantityPrecision)
+}
+
+// roundToFraction rounds amounts below 10 to the nearest kitchen fraction and larger ones to whole numbers,
+// amounts are never rounded to zero
+func roundToFraction(quantity Quantity) Quantity {
+	if quantity >= 10 {
+		return Quantity(math.Round(float64(quantity)))
+	}
+
+	whole, fraction := math.Modf(float64(quantity))
+
+	nearest := kitchenFractions[0]
+	for _, candidate := range kitchenFractions[1:] {
+		if math.Abs(fraction-candidate) < math.Abs(fraction-nearest) {
+			nearest = candidate
+		}
+	}
+
+	if whole == 0 && nearest == 0 {
+		nearest = kitchenFractions[1]
+	}
+
+	return Quantity(whole + nearest).Round()
+}
+
+func contains(units []Unit, unit Unit) bool {
+	for _, candidate := range units {
+		if candidate == unit {
+			return true
+		}
+	}
+
+	return false
+}
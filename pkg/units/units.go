@@ -0,0 +1,174 @@
+// Package units knows the units of measurement of the ingredients and converts quantities
+// between them and between the metric and the imperial systems
+package units
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Dimension is what a unit measures, quantities are converted only between units of the same dimension
+type Dimension string
+
+const (
+	Volume      Dimension = "volume"
+	Mass        Dimension = "mass"
+	Count       Dimension = "count"
+	Temperature Dimension = "temperature"
+)
+
+// System is a system of units a recipe can be shown in
+type System string
+
+const (
+	Metric System = "metric"
+	// Imperial is the system of the kitchens using cups and pounds, its volumes are the US customary ones
+	Imperial System = "imperial"
+)
+
+// ErrIncompatibleUnits is returned when a quantity is converted to a unit of another dimension
+var ErrIncompatibleUnits = errors.New("the units measure different dimensions")
+
+// Unit struct describes a unit by its symbol, the symbol is what the ingredients store
+// Units of no system, like pieces, are the same in every system
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	System    System
+	// a quantity in the base unit of the dimension (ml, g, piece or °C) is quantity * factor + offset
+	factor float64
+	offset float64
+}
+
+// the display units are ordered from the smallest, conversions pick the largest one giving at least 1
+var (
+	milliliter = Unit{Symbol: "ml", Dimension: Volume, System: Metric, factor: 1}
+	liter      = Unit{Symbol: "l", Dimension: Volume, System: Metric, factor: 1000}
+	teaspoon   = Unit{Symbol: "tsp", Dimension: Volume, System: Imperial, factor: 4.92892}
+	tablespoon = Unit{Symbol: "tbsp", Dimension: Volume, System: Imperial, factor: 14.7868}
+	fluidOunce = Unit{Symbol: "fl oz", Dimension: Volume, System: Imperial, factor: 29.5735}
+	cup        = Unit{Symbol: "cup", Dimension: Volume, System: Imperial, factor: 236.588}
+	pint       = Unit{Symbol: "pt", Dimension: Volume, System: Imperial, factor: 473.176}
+	quart      = Unit{Symbol: "qt", Dimension: Volume, System: Imperial, factor: 946.353}
+	gallon     = Unit{Symbol: "gal", Dimension: Volume, System: Imperial, factor: 3785.41}
+
+	milligram = Unit{Symbol: "mg", Dimension: Mass, System: Metric, factor: 0.001}
+	gram      = Unit{Symbol: "g", Dimension: Mass, System: Metric, factor: 1}
+	kilogram  = Unit{Symbol: "kg", Dimension: Mass, System: Metric, factor: 1000}
+	ounce     = Unit{Symbol: "oz", Dimension: Mass, System: Imperial, factor: 28.3495}
+	pound     = Unit{Symbol: "lb", Dimension: Mass, System: Imperial, factor: 453.592}
+
+	piece = Unit{Symbol: "pcs", Dimension: Count, factor: 1}
+	pinch = Unit{Symbol: "pinch", Dimension: Count, factor: 1}
+
+	celsius    = Unit{Symbol: "°C", Dimension: Temperature, System: Metric, factor: 1}
+	fahrenheit = Unit{Symbol: "°F", Dimension: Temperature, System: Imperial, factor: 5.0 / 9, offset: -32 * 5.0 / 9}
+)
+
+var displayUnits = map[System]map[Dimension][]Unit{
+	Metric: {
+		Volume:      {milliliter, liter},
+		Mass:        {milligram, gram, kilogram},
+		Temperature: {celsius},
+	},
+	Imperial: {
+		Volume:      {teaspoon, tablespoon, cup, quart, gallon},
+		Mass:        {ounce, pound},
+		Temperature: {fahrenheit},
+	},
+}
+
+// names maps the symbols and the other names of the units in lower case to the units
+var names = map[string]Unit{}
+
+func init() {
+	aliases := map[Unit][]string{
+		milliliter: {"milliliter", "milliliters", "millilitre", "millilitres"},
+		liter:      {"liter", "liters", "litre", "litres"},
+		teaspoon:   {"teaspoon", "teaspoons"},
+		tablespoon: {"tbs", "tablespoon", "tablespoons"},
+		fluidOunce: {"floz", "fluid ounce", "fluid ounces"},
+		cup:        {"cups"},
+		pint:       {"pint", "pints"},
+		quart:      {"quart", "quarts"},
+		gallon:     {"gallon", "gallons"},
+		milligram:  {"milligram", "milligrams"},
+		gram:       {"gr", "gram", "grams"},
+		kilogram:   {"kilo", "kilos", "kilogram", "kilograms"},
+		ounce:      {"ounce", "ounces"},
+		pound:      {"lbs", "pound", "pounds"},
+		piece:      {"pc", "piece", "pieces"},
+		pinch:      {"pinches"},
+		celsius:    {"c°", "celsius", "degc"},
+		fahrenheit: {"f°", "fahrenheit", "degf"},
+	}
+
+	for unit, unitAliases := range aliases {
+		names[strings.ToLower(unit.Symbol)] = unit
+		for _, alias := range unitAliases {
+			names[alias] = unit
+		}
+	}
+}
+
+// Lookup function finds the unit with the symbol or another name, like "tbsp", "Tablespoons" or "°F"
+// Returns false if there is no such unit
+func Lookup(name string) (Unit, bool) {
+	unit, ok := names[strings.ToLower(strings.Join(strings.Fields(name), " "))]
+	return unit, ok
+}
+
+// ParseSystem function finds the system with the name
+// Returns false if there is no such system
+func ParseSystem(name string) (System, bool) {
+	switch System(strings.ToLower(name)) {
+	case Metric:
+		return Metric, true
+	case Imperial:
+		return Imperial, true
+	}
+
+	return "", false
+}
+
+// Convert function converts the quantity of the unit to the other unit
+// Returns ErrIncompatibleUnits if the units measure different dimensions
+func Convert(quantity Quantity, from Unit, to Unit) (Quantity, error) {
+	if from.Dimension != to.Dimension {
+		return 0, ErrIncompatibleUnits
+	}
+
+	if from == to {
+		return quantity, nil
+	}
+
+	base := float64(quantity)*from.factor + from.offset
+	return Quantity((base - to.offset) / to.factor), nil
+}
+
+// ToBase function converts the quantity of the unit to the base unit of its dimension, ml, g, piece or °C
+func ToBase(quantity Quantity, unit Unit) Quantity {
+	return Quantity(float64(quantity)*unit.factor + unit.offset)
+}
+
+// ToSystem function converts the quantity of the unit to the unit of the system it is best read in,
+// the largest unit giving at least 1, and rounds it to two decimals
+// Units already in the system and units of no system are kept as they are
+func ToSystem(quantity Quantity, unit Unit, system System) (Quantity, Unit) {
+	candidates := displayUnits[system][unit.Dimension]
+
+	if unit.System == "" || unit.System == system || len(candidates) == 0 {
+		return quantity, unit
+	}
+
+	target := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if converted, _ := Convert(quantity, unit, candidate); math.Abs(float64(converted)) >= 1 {
+			target = candidate
+		}
+	}
+
+	converted, _ := Convert(quantity, unit, target)
+	return round(converted, displayPrecision), target
+}
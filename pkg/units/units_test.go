@@ -0,0 +1,187 @@
+package units
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Quantity
+		valid    bool
+	}{
+		{text: "2", expected: 2, valid: true},
+		{text: "0.25", expected: 0.25, valid: true},
+		{text: "1/2", expected: 0.5, valid: true},
+		{text: " 1 1/2 ", expected: 1.5, valid: true},
+		{text: "2/3", expected: 0.6667, valid: true},
+		{text: "1/0", valid: false},
+		{text: "1 0.5", valid: false},
+		{text: "half", valid: false},
+		{text: "", valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			quantity, err := ParseQuantity(test.text)
+
+			if (err == nil) != test.valid || quantity != test.expected {
+				t.Errorf("Got quantity = %v, error = %v but wanted %v", quantity, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestQuantity_JSON(t *testing.T) {
+	var ingredient struct {
+		Quantity Quantity `json:"quantity"`
+	}
+
+	for body, expected := range map[string]Quantity{`{"quantity":0.5}`: 0.5, `{"quantity":"1 3/4"}`: 1.75, `{"quantity":null}`: 0} {
+		ingredient.Quantity = 0
+		if err := json.Unmarshal([]byte(body), &ingredient); err != nil || ingredient.Quantity != expected {
+			t.Errorf("Got quantity = %v, error = %v from %s but wanted %v", ingredient.Quantity, err, body, expected)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"quantity":"a lot"}`), &ingredient); err == nil {
+		t.Error("expected a quantity which is not a number to be rejected")
+	}
+
+	if encoded, _ := json.Marshal(Quantity(0.25)); string(encoded) != "0.25" {
+		t.Errorf("Got encoded quantity = %s", encoded)
+	}
+}
+
+func TestQuantity_String(t *testing.T) {
+	for quantity, expected := range map[Quantity]string{2: "2", 0.5: "1/2", 1.75: "1 3/4", 0.3333: "1/3", 0.125: "1/8", 1.06: "1.06"} {
+		if text := quantity.String(); text != expected {
+			t.Errorf("Got %q for %v but wanted %q", text, float64(quantity), expected)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for name, symbol := range map[string]string{"tbsp": "tbsp", "Tablespoons": "tbsp", "fluid  ounce": "fl oz", "°F": "°F", "KG": "kg"} {
+		unit, ok := Lookup(name)
+		if !ok || unit.Symbol != symbol {
+			t.Errorf("Got unit = %v, %v for %q but wanted %v", unit, ok, name, symbol)
+		}
+	}
+
+	if _, ok := Lookup("handful"); ok {
+		t.Error("expected an unknown unit not to be found")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity Quantity
+		from     Unit
+		to       Unit
+		expected Quantity
+	}{
+		{name: "Cups to milliliters", quantity: 2, from: cup, to: milliliter, expected: 473.18},
+		{name: "Pounds to grams", quantity: 1, from: pound, to: gram, expected: 453.59},
+		{name: "Tablespoons to teaspoons", quantity: 1, from: tablespoon, to: teaspoon, expected: 3},
+		{name: "Celsius to Fahrenheit", quantity: 180, from: celsius, to: fahrenheit, expected: 356},
+		{name: "Fahrenheit to Celsius", quantity: 212, from: fahrenheit, to: celsius, expected: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			converted, err := Convert(test.quantity, test.from, test.to)
+
+			if err != nil || round(converted, displayPrecision) != test.expected {
+				t.Errorf("Got %v, %v but wanted %v", converted, err, test.expected)
+			}
+		})
+	}
+
+	if _, err := Convert(1, cup, gram); err != ErrIncompatibleUnits {
+		t.Errorf("Got error = %v but wanted ErrIncompatibleUnits", err)
+	}
+}
+
+func TestToBase(t *testing.T) {
+	for _, test := range []struct {
+		quantity Quantity
+		unit     Unit
+		expected Quantity
+	}{
+		{quantity: 1.5, unit: kilogram, expected: 1500},
+		{quantity: 2, unit: tablespoon, expected: 29.57},
+		{quantity: 3, unit: piece, expected: 3},
+		{quantity: 212, unit: fahrenheit, expected: 100},
+	} {
+		if base := round(ToBase(test.quantity, test.unit), displayPrecision); base != test.expected {
+			t.Errorf("Got %v %s in the base unit but wanted %v", base, test.unit.Symbol, test.expected)
+		}
+	}
+}
+
+func TestToSystem(t *testing.T) {
+	tests := []struct {
+		name             string
+		quantity         Quantity
+		unit             Unit
+		system           System
+		expectedQuantity Quantity
+		expectedUnit     Unit
+	}{
+		{name: "Small volume", quantity: 30, unit: milliliter, system: Imperial, expectedQuantity: 2.03, expectedUnit: tablespoon},
+		{name: "Large volume", quantity: 2, unit: liter, system: Imperial, expectedQuantity: 2.11, expectedUnit: quart},
+		{name: "Less than the smallest unit", quantity: 5, unit: gram, system: Imperial, expectedQuantity: 0.18, expectedUnit: ounce},
+		{name: "Imperial to metric", quantity: 2, unit: pound, system: Metric, expectedQuantity: 907.18, expectedUnit: gram},
+		{name: "Heavy imperial to metric", quantity: 3, unit: pound, system: Metric, expectedQuantity: 1.36, expectedUnit: kilogram},
+		{name: "Ounces to grams", quantity: 4, unit: ounce, system: Metric, expectedQuantity: 113.4, expectedUnit: gram},
+		{name: "Oven temperature", quantity: 350, unit: fahrenheit, system: Metric, expectedQuantity: 176.67, expectedUnit: celsius},
+		{name: "Already in the system", quantity: 1500, unit: gram, system: Metric, expectedQuantity: 1500, expectedUnit: gram},
+		{name: "Unit of no system", quantity: 3, unit: piece, system: Imperial, expectedQuantity: 3, expectedUnit: piece},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quantity, unit := ToSystem(test.quantity, test.unit, test.system)
+
+			if quantity != test.expectedQuantity || unit != test.expectedUnit {
+				t.Errorf("Got %v %v but wanted %v %v", quantity, unit.Symbol, test.expectedQuantity, test.expectedUnit.Symbol)
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name             string
+		quantity         Quantity
+		unit             Unit
+		factor           float64
+		expectedQuantity Quantity
+		expectedUnit     Unit
+	}{
+		{name: "Teaspoons promoted to a cup", quantity: 12, unit: teaspoon, factor: 4, expectedQuantity: 1, expectedUnit: cup},
+		{name: "Teaspoons promoted to a tablespoon", quantity: 1, unit: teaspoon, factor: 3, expectedQuantity: 1, expectedUnit: tablespoon},
+		{name: "Tablespoons demoted to teaspoons", quantity: 1, unit: tablespoon, factor: 0.5, expectedQuantity: 1.5, expectedUnit: teaspoon},
+		{name: "Rounded to a kitchen fraction", quantity: 1, unit: cup, factor: 1.4, expectedQuantity: 1.375, expectedUnit: cup},
+		{name: "Large imperial amount rounded to whole", quantity: 7, unit: ounce, factor: 2, expectedQuantity: 14, expectedUnit: ounce},
+		{name: "Metric promoted to kilograms", quantity: 400, unit: gram, factor: 3, expectedQuantity: 1.2, expectedUnit: kilogram},
+		{name: "Large metric amount rounded to 5", quantity: 250, unit: milliliter, factor: 0.95, expectedQuantity: 240, expectedUnit: milliliter},
+		{name: "Medium metric amount rounded to whole", quantity: 25, unit: gram, factor: 0.5, expectedQuantity: 13, expectedUnit: gram},
+		{name: "Pieces", quantity: 3, unit: piece, factor: 0.5, expectedQuantity: 1.5, expectedUnit: piece},
+		{name: "Never rounded to zero", quantity: 1, unit: pinch, factor: 0.01, expectedQuantity: 0.125, expectedUnit: pinch},
+		{name: "Oven temperature", quantity: 180, unit: celsius, factor: 2, expectedQuantity: 180, expectedUnit: celsius},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quantity, unit := Scale(test.quantity, test.unit, test.factor)
+
+			if quantity != test.expectedQuantity || unit != test.expectedUnit {
+				t.Errorf("Got %v %v but wanted %v %v", quantity, unit.Symbol, test.expectedQuantity, test.expectedUnit.Symbol)
+			}
+		})
+	}
+}
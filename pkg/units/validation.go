@@ -0,0 +1,34 @@
+package units
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/validation"
+	"reflect"
+	"strconv"
+)
+
+// MaxQuantity is the largest quantity of an ingredient
+const MaxQuantity = 100000
+
+// the rules are available in the validate tags of every package using the units
+func init() {
+	validation.Register("unit", func(value reflect.Value, _ string) string {
+		if _, ok := Lookup(value.String()); !ok {
+			return "must be a known unit like g, ml, tbsp, cup, oz or pcs"
+		}
+		return ""
+	})
+
+	validation.Register("temperature", func(value reflect.Value, _ string) string {
+		if unit, ok := Lookup(value.String()); !ok || unit.Dimension != Temperature {
+			return "must be a unit of temperature like °C or °F"
+		}
+		return ""
+	})
+
+	validation.Register("quantity", func(value reflect.Value, _ string) string {
+		if quantity := value.Float(); quantity <= 0 || quantity > MaxQuantity {
+			return "must be more than 0 and at most " + strconv.Itoa(MaxQuantity)
+		}
+		return ""
+	})
+}
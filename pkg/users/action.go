@@ -0,0 +1,39 @@
+package users
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"time"
+)
+
+// Purposes of the single use tokens sent by email, a token can be used only for its own purpose
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+// ErrInvalidActionToken is returned when a token sent by email is not valid, expired or already used
+var ErrInvalidActionToken error = apperror.Validation("the token is not valid, expired or already used")
+
+// ActionToken struct describes a single use token sent by email, only its id is stored
+type ActionToken struct {
+	ID        string
+	UserID    uint
+	Purpose   string
+	ExpiresAt time.Time
+}
+
+// TokenPayload struct is the body of requests confirming an email address
+type TokenPayload struct {
+	Token string `json:"token"`
+}
+
+// EmailPayload struct is the body of requests for a password reset email
+type EmailPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// PasswordResetPayload struct is the body of requests setting a new password with a reset token
+type PasswordResetPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"password"`
+}
@@ -2,14 +2,41 @@ package users
 
 import "net/http"
 
-// UserAuthenticator provides methods for JWT management
+// UserAuthenticator provides methods for JWT and session management
 type UserAuthenticator interface {
-	// GenerateTokenForUser function generate JWT for given user
-	// Return an error if such occurs during token signing or the token string
-	GenerateTokenForUser(user *User) (string, error)
+	// StartSession function starts a new session for the given user
+	// Return an error if such occurs during token signing or session storing
+	// otherwise returns the access and refresh tokens of the session
+	StartSession(user *User) (*Tokens, error)
+
+	// RefreshSession function rotates the given refresh token and issues a new access token for its session
+	// Returns ErrInvalidRefreshToken if the refresh token cannot be used, revoking its session
+	// if the token has already been rotated, otherwise returns the new tokens of the session
+	RefreshSession(refreshToken string) (*Tokens, error)
+
+	// EndSession function revokes the session with the given id
+	// Returns an error if such occurs during revocation
+	EndSession(sessionId string) error
+
+	// EndAllSessions function revokes all sessions of the user with the given id
+	// Returns an error if such occurs during revocation
+	EndAllSessions(userId uint) error
+
+	// IssueActionToken function creates a signed single use token for the given purpose of the user
+	// Returns an error if such occurs during signing or storing the token otherwise returns the token
+	IssueActionToken(user *User, purpose string) (string, error)
+
+	// ConsumeActionToken function verifies the token was issued for the given purpose and marks it as used
+	// Returns ErrInvalidActionToken if the token is not valid, expired or already used
+	// otherwise returns the id of the user the token was issued for
+	ConsumeActionToken(token string, purpose string) (uint, error)
 
 	// VerifyJWT function serves as a middleware handler for user token verification
-	// Returns status Forbidden if token is invalid
-	// or sets the user context otherwise
+	// Returns status Forbidden if token is invalid or its session is revoked
+	// or sets the user and session context otherwise
 	VerifyJWT(next http.Handler) http.Handler
+
+	// JWKS function handles requests for the public keys verifying the access tokens
+	// Returns Status OK and a JSONWebKeySet containing every key which is not removed yet
+	JWKS(w http.ResponseWriter, r *http.Request)
 }
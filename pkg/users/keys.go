@@ -0,0 +1,20 @@
+package users
+
+// JSONWebKey struct describes a public key verifying access tokens in the JWK format (RFC 7517)
+// Only the members of the key type are set: n and e for RSA, crv, x and y for EC and crv and x for OKP keys
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n,omitempty"`
+	Exponent  string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet struct is the document listing every key which access tokens may be signed with
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
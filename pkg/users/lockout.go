@@ -0,0 +1,94 @@
+package users
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"time"
+)
+
+// ErrInvalidCredentials is returned for an unknown email and for a wrong password alike,
+// so a failed login does not tell which emails have an account
+var ErrInvalidCredentials error = apperror.Unauthorized("invalid email or password")
+
+// LoginAttempts struct describes the recent failed logins counted for an account or a client address
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+	// LockedUntil is the time before which logins are rejected without checking the password
+	LockedUntil time.Time
+}
+
+// LockoutPolicy struct describes how long logins are blocked after failed attempts
+// Every failure doubles the delay before the next attempt, starting at BaseDelay and up to MaxDelay,
+// and MaxFailures failures lock the logins out for the Lockout duration
+type LockoutPolicy struct {
+	MaxFailures int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Lockout is also the time after which failures are forgotten
+	Lockout time.Duration
+}
+
+// AddFailure function returns the attempts with one more failure at the given time and the logins blocked
+// as long as the policy says, failures older than the lockout duration are not counted
+func (policy LockoutPolicy) AddFailure(attempts LoginAttempts, now time.Time) LoginAttempts {
+	if now.Sub(attempts.LastFailure) > policy.Lockout {
+		attempts.Failures = 0
+	}
+
+	attempts.Failures++
+	attempts.LastFailure = now
+
+	if attempts.Failures >= policy.MaxFailures {
+		attempts.LockedUntil = now.Add(policy.Lockout)
+		return attempts
+	}
+
+	delay := policy.BaseDelay
+	for i := 1; i < attempts.Failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	attempts.LockedUntil = now.Add(delay)
+	return attempts
+}
+
+// Expired function tells if the attempts are old enough to be forgotten
+func (policy LockoutPolicy) Expired(attempts LoginAttempts, now time.Time) bool {
+	return now.Sub(attempts.LastFailure) > policy.Lockout && !now.Before(attempts.LockedUntil)
+}
+
+// LoginAttemptRepository interface provides functions for counting failed logins by a key,
+// which is either an account or a client address
+type LoginAttemptRepository interface {
+	// FindLoginAttempts function fetches the failed logins counted for the key
+	// Returns an error if such occurs while reading them otherwise returns the attempts or nil if there are none
+	FindLoginAttempts(key string) (*LoginAttempts, error)
+
+	// AddLoginFailure function counts a failed login for the key at the given time as the policy says
+	// Returns the updated attempts or an error if such occurs while storing them
+	AddLoginFailure(key string, now time.Time, policy LockoutPolicy) (*LoginAttempts, error)
+
+	// ResetLoginAttempts function forgets the failed logins counted for the key
+	// Returns an error if such occurs while deleting them
+	ResetLoginAttempts(key string) error
+}
+
+// LoginLimiter interface provides functions for throttling logins by account and by client address
+type LoginLimiter interface {
+	// Check function tells how long the logins with the email from the client address are blocked
+	// Returns zero if the login can be attempted or an error if such occurs while reading the attempts
+	Check(email string, address string) (time.Duration, error)
+
+	// Fail function counts a failed login with the email from the client address
+	// Returns an error if such occurs while storing the attempts
+	Fail(email string, address string) error
+
+	// Succeed function forgets the failed logins with the email after a successful login,
+	// the failures counted for the client address are kept
+	// Returns an error if such occurs while deleting the attempts
+	Succeed(email string) error
+}
@@ -0,0 +1,38 @@
+package users
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicy_AddFailure(t *testing.T) {
+	policy := LockoutPolicy{MaxFailures: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second, Lockout: time.Hour}
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	expectedDelays := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, time.Hour}
+
+	attempts := LoginAttempts{}
+	for i, expected := range expectedDelays {
+		attempts = policy.AddFailure(attempts, now)
+
+		if attempts.Failures != i+1 || attempts.LockedUntil.Sub(now) != expected {
+			t.Errorf("Got %v failures locked for %v but wanted %v locked for %v",
+				attempts.Failures, attempts.LockedUntil.Sub(now), i+1, expected)
+		}
+	}
+
+	if policy.Expired(attempts, now.Add(time.Hour)) {
+		t.Error("expected locked out attempts not to expire")
+	}
+
+	// failures older than the lockout are forgotten
+	later := now.Add(time.Hour + time.Second)
+	if !policy.Expired(attempts, later) {
+		t.Error("expected attempts to expire after the lockout")
+	}
+
+	attempts = policy.AddFailure(attempts, later)
+	if attempts.Failures != 1 || attempts.LockedUntil != later.Add(time.Second) {
+		t.Errorf("Got %v failures locked until %v but wanted a first failure", attempts.Failures, attempts.LockedUntil)
+	}
+}
@@ -0,0 +1,30 @@
+package users
+
+import "github.com/krasimiraMilkova/cookit/pkg/recipes"
+
+// ProfilePayload struct is the body of requests changing the name or the email of the user,
+// the fields which are not sent are kept
+type ProfilePayload struct {
+	Name  *string `json:"name" validate:"required,max=100"`
+	Email *string `json:"email" validate:"required,max=100,email"`
+}
+
+// PasswordChangePayload struct is the body of requests replacing the password of the user
+type PasswordChangePayload struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"password"`
+}
+
+// PasswordPayload struct is the body of requests which have to be confirmed with the password of the user
+type PasswordPayload struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// Profile struct is the public profile of a user showing a page of their newest recipes,
+// it carries neither the email nor the role of the user
+type Profile struct {
+	ID          uint                         `json:"id"`
+	Name        string                       `json:"name"`
+	Recipes     []recipes.RecipeSearchResult `json:"recipes"`
+	RecipeCount int                          `json:"recipe_count"`
+}
@@ -1,9 +1,12 @@
 package users
 
+import "time"
+
 // UserRepository interface provides functions for CRUD operations for user entity
 type UserRepository interface {
 	// CreateUser function provides create db operation for user entity that do not exist yet
-	// Returns an error if such occurs during the db query execution or if the password encryption fails
+	// Returns a validation error if the user has empty fields or an error if such occurs
+	// during the db query execution or if the password encryption fails
 	CreateUser(user *User) error
 
 	// ExistUser function checks if a user with given email exists in the db
@@ -11,6 +14,92 @@ type UserRepository interface {
 	ExistUser(email string) bool
 
 	// FindUser function fetches a user with given email and password if such exists in the db
-	// Returns the user if such exists or an error otherwise
+	// Returns the user if such exists, ErrInvalidCredentials if there is no user with the email or the password
+	// is wrong, or an error if such occurs during the db query execution
 	FindUser(email, password string) (*User, error)
+
+	// FindUserById function fetches the user with the given id without the password
+	// Returns an error if such occurs during the db query execution otherwise returns the user or nil if not found
+	FindUserById(id uint) (*User, error)
+
+	// FindUserByEmail function fetches the user with the given email without the password
+	// Returns an error if such occurs during the db query execution otherwise returns the user or nil if not found
+	FindUserByEmail(email string) (*User, error)
+
+	// ListUsers function fetches a page of users ordered by id without their passwords
+	// Returns an error if such occurs during the db query execution otherwise returns the users and the total count
+	ListUsers(limit, offset int) ([]User, int, error)
+
+	// UpdateUserRole function changes the role of the user with the given id
+	// Returns ErrUserNotFound if there is no such user or an error if such occurs during the db query execution
+	UpdateUserRole(id uint, role Role) error
+
+	// MarkEmailVerified function marks the email of the user with the given id as verified
+	// Returns ErrUserNotFound if there is no such user or an error if such occurs during the db query execution
+	MarkEmailVerified(id uint) error
+
+	// UpdateUser function changes the name and the email of the user with the same id,
+	// a changed email has to be verified again
+	// Returns a validation error if the name or the email is empty, ErrUserNotFound if there is no such user
+	// or an error if such occurs during the db query execution
+	UpdateUser(user *User) error
+
+	// VerifyPassword function checks the password of the user with the given id
+	// Returns ErrInvalidCredentials if the password is wrong, ErrUserNotFound if there is no such user
+	// or an error if such occurs during the db query execution
+	VerifyPassword(id uint, password string) error
+
+	// DeleteUser function deletes the user with the given id together with their recipes, comments,
+	// ratings and sessions
+	// Returns ErrUserNotFound if there is no such user or an error if such occurs during the db query execution
+	DeleteUser(id uint) error
+
+	// UpdatePassword function encrypts and stores the new password of the user with the given id
+	// Returns a validation error if the password is empty, ErrUserNotFound if there is no such user
+	// or an error if such occurs during the encryption or the db query execution
+	UpdatePassword(id uint, password string) error
+}
+
+// ActionTokenRepository interface provides functions for storing the single use tokens sent by email
+type ActionTokenRepository interface {
+	// CreateActionToken function stores the token replacing the unused tokens of the user with the same purpose
+	// Returns an error if such occurs during the db query execution
+	CreateActionToken(token *ActionToken) error
+
+	// UseActionToken function marks the unexpired token with the given id and purpose as used
+	// Returns an error if such occurs during the db query execution otherwise returns false
+	// if there is no such token or it has already been used
+	UseActionToken(id string, purpose string) (bool, error)
+}
+
+// SessionRepository interface provides functions for storing user sessions and revoking them
+type SessionRepository interface {
+	// CreateSession function provides insert db operation for a new session
+	// Returns an error if such occurs during the db query execution
+	CreateSession(session *Session) error
+
+	// FindSessionByRefreshToken function fetches the session with the given current or previous refresh token hash
+	// together with its user
+	// Returns an error if such occurs during the db query execution otherwise returns the Session,
+	// or nil if there is no such session, and true if the hash belongs to an already rotated refresh token
+	FindSessionByRefreshToken(tokenHash string) (*Session, bool, error)
+
+	// RotateRefreshToken function replaces the refresh token hash and expiration of the session
+	// if the session still has the same refresh token and is not revoked
+	// Returns an error if such occurs during the db query execution otherwise returns false
+	// if the refresh token has been changed or the session revoked in the meantime
+	RotateRefreshToken(session *Session, tokenHash string, expiresAt time.Time) (bool, error)
+
+	// RevokeSession function marks the session with the given id as revoked
+	// Returns an error if such occurs during the db query execution
+	RevokeSession(id string) error
+
+	// RevokeUserSessions function marks all sessions of the user with the given id as revoked
+	// Returns an error if such occurs during the db query execution
+	RevokeUserSessions(userId uint) error
+
+	// IsSessionRevoked function checks the revocation list for the session with the given id
+	// Returns an error if such occurs during the db query execution otherwise returns true
+	// if the session is revoked or does not exist
+	IsSessionRevoked(id string) (bool, error)
 }
@@ -0,0 +1,52 @@
+package users
+
+import "github.com/krasimiraMilkova/cookit/pkg/apperror"
+
+// Role of a user, every role is allowed everything the roles ranked below it are
+type Role string
+
+const (
+	// RoleUser can only modify the content they created
+	RoleUser Role = "user"
+	// RoleModerator can also delete comments of other users
+	RoleModerator Role = "moderator"
+	// RoleAdmin can also edit and delete recipes of other users and manage the users
+	RoleAdmin Role = "admin"
+)
+
+// ErrUserNotFound is returned when there is no user with the requested id
+var ErrUserNotFound error = apperror.NotFound("user not found")
+
+var roleRanks = map[Role]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// Valid function tells if the role is one of the known roles
+func (role Role) Valid() bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Includes function tells if the role is allowed everything the other role is
+// Unknown roles include nothing and are included in nothing
+func (role Role) Includes(other Role) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[other] && other.Valid()
+}
+
+// HasRole function tells if the user has the given role or a role ranked above it
+func (user *User) HasRole(role Role) bool {
+	return user.Role.Includes(role)
+}
+
+// RolePayload struct is the body of requests changing the role of a user
+type RolePayload struct {
+	Role Role `json:"role"`
+}
+
+// UserPage is a single page of users ordered by id
+type UserPage struct {
+	Items []User `json:"items"`
+	Total int    `json:"total"`
+}
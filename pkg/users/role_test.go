@@ -0,0 +1,25 @@
+package users
+
+import "testing"
+
+func TestRole_Includes(t *testing.T) {
+	tests := []struct {
+		role     Role
+		other    Role
+		expected bool
+	}{
+		{RoleAdmin, RoleModerator, true},
+		{RoleAdmin, RoleUser, true},
+		{RoleModerator, RoleModerator, true},
+		{RoleModerator, RoleAdmin, false},
+		{RoleUser, RoleModerator, false},
+		{RoleAdmin, "owner", false},
+		{"", RoleUser, false},
+	}
+
+	for _, test := range tests {
+		if included := test.role.Includes(test.other); included != test.expected {
+			t.Errorf("Got %v includes %v = %v but wanted %v", test.role, test.other, included, test.expected)
+		}
+	}
+}
@@ -6,17 +6,121 @@ import "net/http"
 
 // UserService interface provides handlers for user login and registration
 type UserService interface {
-	// CreateUser function handles payload for user registration
-	// Returns Status BadRequest if cannot decode the payload
-	// or the user with the same email already exists
+	// CreateUser function handles payload for user registration and emails the user a link verifying their email
+	// Returns Status BadRequest if cannot decode the payload, the name or the email is invalid
+	// or the password does not follow the password policy
+	// Status RequestEntityTooLarge if the payload is over the size limit
+	// Status Conflict if the user with the same email already exists
 	// Status InternalServerError if error occurs during user creation
-	// Status Created if user is successfully inserted into the db
+	// Status Created if user is successfully inserted into the db, even if the email could not be sent
 	CreateUser(w http.ResponseWriter, r *http.Request)
 
-	// Login function handles payload for user login
+	// VerifyEmail function handles the email verification token from the token query parameter or a TokenPayload
+	// Returns Status BadRequest if the token is missing, invalid, expired or already used
+	// Status InternalServerError if error occurs during the verification
+	// Status NoContent if the email is verified, which the access tokens show after a refresh or the next login
+	VerifyEmail(w http.ResponseWriter, r *http.Request)
+
+	// ResendVerification function handles requests of the user from the request context for a new verification email,
+	// the tokens sent before it cannot be used anymore
+	// Returns Status Conflict if the email is already verified
+	// Status NotFound if the user does not exist anymore
+	// Status InternalServerError if error occurs during sending
+	// Status Accepted if the email is sent
+	ResendVerification(w http.ResponseWriter, r *http.Request)
+
+	// ForgotPassword function handles an EmailPayload and emails the user with the email a password reset token
+	// Returns Status BadRequest if cannot decode the payload or the email is invalid
+	// Status InternalServerError if error occurs during sending
+	// Status Accepted whether or not there is a user with the email
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
+
+	// ResetPassword function handles a PasswordResetPayload, sets the new password of the user of the token
+	// and ends all sessions of the user
+	// Returns Status BadRequest if cannot decode the payload, the password does not follow the password policy
+	// or the token is invalid, expired or already used
+	// Status InternalServerError if error occurs during the update
+	// Status NoContent if the password is changed
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+
+	// Login function handles payload for user login, failed logins delay the next attempts with the same email
+	// or from the same client address and too many of them lock the logins out for a while
 	// Returns Status BadRequest if cannot decode the payload
-	// Status NotFound if user does not exist
-	// Status InternalServerError if error occurs during JWT generation
-	// Status OK and a cookie containing the token
+	// Status TooManyRequests and a Retry-After header if the logins are blocked
+	// Status Unauthorized if there is no user with the email or the password is wrong
+	// Status InternalServerError if error occurs during the user search or JWT generation
+	// Status OK, cookies containing the access and refresh tokens of a new session
+	// and the access token in an AccessTokenResponse body
 	Login(w http.ResponseWriter, r *http.Request)
+
+	// Refresh function handles requests exchanging the refresh token cookie for new access and refresh tokens,
+	// after which the old refresh token cannot be used anymore
+	// Returns Status Forbidden if the refresh token is missing, unknown, expired or revoked
+	// Status InternalServerError if error occurs during JWT generation
+	// Status OK, cookies containing the new tokens and the new access token in an AccessTokenResponse body
+	Refresh(w http.ResponseWriter, r *http.Request)
+
+	// Logout function handles requests revoking the session of the access token
+	// Returns Status Forbidden if there is no session in the request context
+	// Status InternalServerError if error occurs during revocation
+	// Status NoContent and expired token cookies
+	Logout(w http.ResponseWriter, r *http.Request)
+
+	// LogoutAll function handles requests revoking all sessions of the user from the request context
+	// Returns the same statuses as Logout
+	LogoutAll(w http.ResponseWriter, r *http.Request)
+
+	// CurrentUser function handles requests for the account of the user from the request context
+	// Returns Status NotFound if the user does not exist anymore
+	// Status InternalServerError if error occurs during the search
+	// Status OK and the user otherwise
+	CurrentUser(w http.ResponseWriter, r *http.Request)
+
+	// UpdateCurrentUser function handles a ProfilePayload changing the name or the email of the user
+	// from the request context, a changed email has to be verified again and the verification email is sent to it
+	// Returns Status BadRequest if cannot decode the payload, the name is empty or too long or the email is invalid
+	// Status Conflict if another user has the email
+	// Status NotFound if the user does not exist anymore
+	// Status InternalServerError if error occurs during the update
+	// Status OK and the updated user otherwise
+	UpdateCurrentUser(w http.ResponseWriter, r *http.Request)
+
+	// DeleteCurrentUser function handles a PasswordPayload deleting the account of the user from the request context
+	// together with their recipes, comments, ratings and sessions
+	// Returns Status BadRequest if cannot decode the payload or the password is empty
+	// Status Forbidden if the password is wrong
+	// Status InternalServerError if error occurs during the deletion
+	// Status NoContent and expired token cookies otherwise
+	DeleteCurrentUser(w http.ResponseWriter, r *http.Request)
+
+	// ChangePassword function handles a PasswordChangePayload replacing the password of the user
+	// from the request context, all sessions of the user are ended and a new one is started for the request
+	// Returns Status BadRequest if cannot decode the payload or the new password does not follow the password policy
+	// Status Forbidden if the current password is wrong
+	// Status InternalServerError if error occurs during the update
+	// Status OK, cookies containing the tokens of the new session and the access token in an AccessTokenResponse body
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+
+	// FindUserProfile function handles requests for the public Profile of the user with the id from the path
+	// with the page of their newest recipes selected by the limit and offset query parameters
+	// Returns Status BadRequest if the id or the parameters are invalid
+	// Status NotFound if there is no such user
+	// Status InternalServerError if error occurs during the search
+	// Status OK and the Profile otherwise
+	FindUserProfile(w http.ResponseWriter, r *http.Request)
+
+	// ListUsers function handles admin requests for a page of users selected by the limit and offset query parameters
+	// Returns Status BadRequest if the parameters are invalid
+	// Status InternalServerError if error occurs during the search
+	// Status OK and a UserPage otherwise
+	ListUsers(w http.ResponseWriter, r *http.Request)
+
+	// UpdateUserRole function handles admin requests changing the role of the user with the id from the path
+	// and ends the sessions of the user, so the new role is used from their next login
+	// Returns Status BadRequest if the id or the role payload are invalid
+	// Status Conflict if the admin tries to change their own role
+	// Status NotFound if there is no such user
+	// Status InternalServerError if error occurs during the update
+	// Status OK and the updated user otherwise
+	UpdateUserRole(w http.ResponseWriter, r *http.Request)
 }
@@ -0,0 +1,56 @@
+package users
+
+import (
+	"context"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"time"
+)
+
+// sessionContextKey is the request context key under which the id of the authenticated session is stored,
+// unexported so the id is only reached through NewSessionContext and SessionFromContext
+type sessionContextKey struct{}
+
+// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired, revoked or already used
+var ErrInvalidRefreshToken error = apperror.Forbidden("invalid refresh token")
+
+// Session struct describes a login of a user which lasts until it is revoked or its refresh token expires
+// Only the hash of the current refresh token is stored
+type Session struct {
+	ID               string
+	User             User
+	RefreshTokenHash string
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+}
+
+// Tokens struct holds the short lived access token and the long lived refresh token of a session
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// TokenType is the authorization scheme under which access tokens are sent in the Authorization header
+const TokenType = "Bearer"
+
+// AccessTokenResponse struct is the body of a successful login or refresh, for clients which send
+// the access token in the Authorization header instead of the cookie
+type AccessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// NewSessionContext function returns a copy of the given context carrying the id of the authenticated session
+func NewSessionContext(ctx context.Context, sessionId string) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, sessionId)
+}
+
+// SessionFromContext function returns the id of the authenticated session stored in the given context
+// Returns false if the context does not carry a session
+func SessionFromContext(ctx context.Context) (string, bool) {
+	sessionId, ok := ctx.Value(sessionContextKey{}).(string)
+	return sessionId, ok && sessionId != ""
+}
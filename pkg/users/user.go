@@ -1,9 +1,48 @@
 package users
 
-// Recipe struct describes a user entity with name, email and password
+import (
+	"context"
+	"encoding/json"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+)
+
+// contextKey is the request context key under which the authenticated user is stored,
+// unexported so the user is only reached through NewContext and FromContext
+type contextKey struct{}
+
+// ErrMissingUser is returned when a request which needs an authenticated user carries none
+var ErrMissingUser error = apperror.Forbidden("the request has no authenticated user")
+
+// ErrEmailTaken is returned when a user is created or updated with the email of another user
+var ErrEmailTaken error = apperror.Conflict("the email is used by another user")
+
+// User struct describes a user entity with name, email, password and role
+// Users with an unverified email cannot post content
 type User struct {
-	ID       uint   `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	ID            uint   `json:"id"`
+	Name          string `json:"name" validate:"required,max=100"`
+	Email         string `json:"email" validate:"required,max=100,email"`
+	Password      string `json:"password,omitempty" validate:"password"`
+	Role          Role   `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// MarshalJSON function encodes the user without the password, which is only ever read from requests
+func (user User) MarshalJSON() ([]byte, error) {
+	type userWithoutPassword User
+	encoded := userWithoutPassword(user)
+	encoded.Password = ""
+	return json.Marshal(encoded)
+}
+
+// NewContext function returns a copy of the given context carrying the authenticated user
+func NewContext(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// FromContext function returns the authenticated user stored in the given context
+// Returns false if the context does not carry a user
+func FromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(contextKey{}).(*User)
+	return user, ok && user != nil
 }
@@ -0,0 +1,53 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUser_MarshalJSON(t *testing.T) {
+	user := User{ID: 3, Name: "Chef", Email: "chef@test.com", Password: "secret", Role: RoleAdmin}
+
+	for _, value := range []interface{}{user, &user, []User{user}, UserPage{Items: []User{user}}} {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal("unexpected error on marshal", err)
+		}
+
+		if strings.Contains(string(encoded), "password") || !strings.Contains(string(encoded), `"email":"chef@test.com"`) {
+			t.Errorf("Got %s but wanted the user without the password", encoded)
+		}
+	}
+
+	// the password is still read from requests
+	decoded := User{}
+	json.Unmarshal([]byte(`{"email":"chef@test.com","password":"secret"}`), &decoded)
+	if decoded.Password != "secret" {
+		t.Errorf("Got password = %v but wanted secret", decoded.Password)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	user := &User{ID: 3, Name: "Chef"}
+	ctx := NewSessionContext(NewContext(context.Background(), user), "session")
+
+	if found, ok := FromContext(ctx); !ok || found != user {
+		t.Errorf("Got user = %v, %v but wanted %v", found, ok, user)
+	}
+
+	if sessionId, ok := SessionFromContext(ctx); !ok || sessionId != "session" {
+		t.Errorf("Got session = %v, %v but wanted session", sessionId, ok)
+	}
+
+	// a plain string key must not reach the user
+	ctx = context.WithValue(context.Background(), "user", user)
+	if found, ok := FromContext(ctx); ok {
+		t.Errorf("Got user = %v from a string key", found)
+	}
+
+	if _, ok := FromContext(NewContext(context.Background(), nil)); ok {
+		t.Error("expected no user for a nil user")
+	}
+}
@@ -0,0 +1,157 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy checked by the password rule, bcrypt ignores the bytes after the 72nd
+const (
+	MinPasswordLength = 8
+	MaxPasswordBytes  = 72
+)
+
+// required checks that strings are not blank, slices and maps are not empty and numbers are not zero
+func required(value reflect.Value, _ string) string {
+	empty := false
+
+	switch value.Kind() {
+	case reflect.String:
+		empty = strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		empty = value.Len() == 0
+	default:
+		empty = value.IsZero()
+	}
+
+	if empty {
+		return "must not be empty"
+	}
+
+	return ""
+}
+
+// minimum checks the number of characters of strings, the number of items of slices and the value of numbers
+func minimum(value reflect.Value, param string) string {
+	limit := parseLimit(param)
+
+	if size, unit, ok := sizeOf(value); ok {
+		if size < int(limit) {
+			return fmt.Sprintf("must have at least %s %s", param, unit)
+		}
+		return ""
+	}
+
+	if number := numberOf(value); number < limit {
+		return "must be at least " + param
+	}
+
+	return ""
+}
+
+// maximum checks the number of characters of strings, the number of items of slices and the value of numbers
+func maximum(value reflect.Value, param string) string {
+	limit := parseLimit(param)
+
+	if size, unit, ok := sizeOf(value); ok {
+		if size > int(limit) {
+			return fmt.Sprintf("must have at most %s %s", param, unit)
+		}
+		return ""
+	}
+
+	if number := numberOf(value); number > limit {
+		return "must be at most " + param
+	}
+
+	return ""
+}
+
+// oneOf checks that the string is one of the space separated values of the parameter
+func oneOf(value reflect.Value, param string) string {
+	allowed := strings.Fields(param)
+
+	for _, option := range allowed {
+		if value.String() == option {
+			return ""
+		}
+	}
+
+	return "must be one of " + strings.Join(allowed, ", ")
+}
+
+// email checks that the string is a plain email address without a display name
+func email(value reflect.Value, _ string) string {
+	address, err := mail.ParseAddress(value.String())
+
+	if err != nil || address.Address != value.String() {
+		return "must be a valid email address"
+	}
+
+	return ""
+}
+
+// password checks that the string follows the password policy
+func password(value reflect.Value, _ string) string {
+	text := value.String()
+
+	if utf8.RuneCountInString(text) < MinPasswordLength {
+		return fmt.Sprintf("must have at least %d characters", MinPasswordLength)
+	}
+
+	if len(text) > MaxPasswordBytes {
+		return fmt.Sprintf("must have at most %d bytes", MaxPasswordBytes)
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, char := range text {
+		hasLetter = hasLetter || unicode.IsLetter(char)
+		hasDigit = hasDigit || unicode.IsDigit(char)
+	}
+
+	if !hasLetter || !hasDigit {
+		return "must contain a letter and a digit"
+	}
+
+	return ""
+}
+
+func parseLimit(param string) float64 {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid validation limit %q", param))
+	}
+
+	return limit
+}
+
+// sizeOf returns the number of characters of strings or items of slices and maps
+// Returns false for the other kinds, whose value is compared instead
+func sizeOf(value reflect.Value) (int, string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), "characters", true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len(), "items", true
+	}
+
+	return 0, "", false
+}
+
+func numberOf(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+
+	panic(fmt.Sprintf("validation limits do not apply to %s values", value.Kind()))
+}
@@ -0,0 +1,148 @@
+// Package validation checks payloads against the declarative rules written in the validate tags of their fields
+//
+// The tag lists comma separated rules which are checked in order until one of them fails:
+//
+//	Title string `json:"title" validate:"required,max=100"`
+//
+// A rule takes a parameter after an equal sign, dive checks every struct of a slice or the struct of the field
+// and nil pointers are skipped, so optional fields of partial updates are checked only when they are sent.
+// Fields are reported by their json names, the fields of the items of a slice as name[index].field
+package validation
+
+import (
+	"fmt"
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"reflect"
+	"strings"
+)
+
+// Rule is a validator function checking the value of a field with the parameter written in the tag
+// Returns the message telling why the value is invalid or an empty message if it is valid
+type Rule func(value reflect.Value, param string) string
+
+// dive is the rule checking the structs of a slice field with their own tags
+const dive = "dive"
+
+var rules = map[string]Rule{
+	"required": required,
+	"min":      minimum,
+	"max":      maximum,
+	"oneof":    oneOf,
+	"email":    email,
+	"password": password,
+}
+
+// Register function adds a named validator function which can be used in the validate tags,
+// it is meant to be called from init functions before any value is validated
+// Panics if a rule with the name already exists, since tags would silently change their meaning
+func Register(name string, rule Rule) {
+	if _, ok := rules[name]; ok || name == dive {
+		panic(fmt.Sprintf("validation rule %q is already registered", name))
+	}
+
+	rules[name] = rule
+}
+
+// Validate function checks the fields of the struct, or the struct the value points to, against their tags
+// Returns a validation error with the given name listing every invalid field or nil if the value is valid
+// Panics if a tag uses an unknown rule, which is a programming error
+func Validate(name string, value interface{}) error {
+	fields := checkStruct(reflect.ValueOf(value), "")
+
+	if len(fields) > 0 {
+		return apperror.Validation("invalid "+name, fields...)
+	}
+
+	return nil
+}
+
+func checkStruct(value reflect.Value, prefix string) []apperror.FieldError {
+	value, ok := indirect(value)
+	if !ok || value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []apperror.FieldError
+	structType := value.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if field.Anonymous {
+			fields = append(fields, checkStruct(value.Field(i), prefix)...)
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		fields = append(fields, checkField(value.Field(i), prefix+name, field.Tag.Get("validate"))...)
+	}
+
+	return fields
+}
+
+func checkField(value reflect.Value, name string, tag string) []apperror.FieldError {
+	value, ok := indirect(value)
+	if !ok || tag == "" {
+		return nil
+	}
+
+	var fields []apperror.FieldError
+
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == dive && value.Kind() == reflect.Struct {
+			fields = append(fields, checkStruct(value, name+".")...)
+			continue
+		}
+
+		if rule == dive {
+			for i := 0; i < value.Len(); i++ {
+				fields = append(fields, checkStruct(value.Index(i), fmt.Sprintf("%s[%d].", name, i))...)
+			}
+			continue
+		}
+
+		ruleName, param := rule, ""
+		if index := strings.Index(rule, "="); index >= 0 {
+			ruleName, param = rule[:index], rule[index+1:]
+		}
+
+		check, ok := rules[ruleName]
+		if !ok {
+			panic(fmt.Sprintf("unknown validation rule %q of field %s", ruleName, name))
+		}
+
+		if message := check(value, param); message != "" {
+			return append(fields, apperror.FieldError{Field: name, Message: message})
+		}
+	}
+
+	return fields
+}
+
+// indirect returns the value nil pointers point to or false for nil pointers
+func indirect(value reflect.Value) (reflect.Value, bool) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+
+	return value, value.IsValid()
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
@@ -0,0 +1,178 @@
+package validation
+
+import (
+	"github.com/krasimiraMilkova/cookit/pkg/apperror"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Name     string `json:"name" validate:"required,max=5"`
+	Quantity uint   `json:"quantity" validate:"min=1,max=10"`
+	Unit     string `json:"unit" validate:"oneof=g kg"`
+}
+
+type payload struct {
+	Title    string  `json:"title" validate:"required,max=10"`
+	Email    string  `json:"email,omitempty" validate:"email"`
+	Password string  `json:"password" validate:"password"`
+	Items    []item  `json:"items" validate:"required,max=2,dive"`
+	Nickname *string `json:"nickname" validate:"required"`
+	Favorite *item   `json:"favorite" validate:"dive"`
+	Note     string
+	internal string `validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	blank := " "
+
+	valid := payload{
+		Title:    "Soup",
+		Email:    "chef@cookit.com",
+		Password: "cookit42",
+		Items:    []item{{Name: "Salt", Quantity: 1, Unit: "g"}},
+	}
+
+	tests := []struct {
+		name           string
+		change         func(value *payload)
+		expectedFields []apperror.FieldError
+	}{
+		{
+			name:   "Valid",
+			change: func(value *payload) {},
+		},
+		{
+			name: "Empty fields",
+			change: func(value *payload) {
+				value.Title = "  "
+				value.Items = nil
+			},
+			expectedFields: []apperror.FieldError{
+				{Field: "title", Message: "must not be empty"},
+				{Field: "items", Message: "must not be empty"},
+			},
+		},
+		{
+			name: "Limits",
+			change: func(value *payload) {
+				value.Title = "Soup of the day"
+				value.Items = []item{{Name: "Salt", Quantity: 1, Unit: "g"}, {}, {}}
+			},
+			expectedFields: []apperror.FieldError{
+				{Field: "title", Message: "must have at most 10 characters"},
+				{Field: "items", Message: "must have at most 2 items"},
+			},
+		},
+		{
+			name: "Items",
+			change: func(value *payload) {
+				value.Items = []item{{Name: "Pepper", Quantity: 11, Unit: "g"}, {Name: "Salt", Unit: "lb"}}
+			},
+			expectedFields: []apperror.FieldError{
+				{Field: "items[0].name", Message: "must have at most 5 characters"},
+				{Field: "items[0].quantity", Message: "must be at most 10"},
+				{Field: "items[1].quantity", Message: "must be at least 1"},
+				{Field: "items[1].unit", Message: "must be one of g, kg"},
+			},
+		},
+		{
+			name: "Struct field",
+			change: func(value *payload) {
+				value.Favorite = &item{Name: "Pepper", Quantity: 1, Unit: "g"}
+			},
+			expectedFields: []apperror.FieldError{
+				{Field: "favorite.name", Message: "must have at most 5 characters"},
+			},
+		},
+		{
+			name: "Email",
+			change: func(value *payload) {
+				value.Email = "Chef <chef@cookit.com>"
+			},
+			expectedFields: []apperror.FieldError{{Field: "email", Message: "must be a valid email address"}},
+		},
+		{
+			name: "Short password",
+			change: func(value *payload) {
+				value.Password = "cook42"
+			},
+			expectedFields: []apperror.FieldError{{Field: "password", Message: "must have at least 8 characters"}},
+		},
+		{
+			name: "Long password",
+			change: func(value *payload) {
+				value.Password = strings.Repeat("cook42", 13)
+			},
+			expectedFields: []apperror.FieldError{{Field: "password", Message: "must have at most 72 bytes"}},
+		},
+		{
+			name: "Password without digit",
+			change: func(value *payload) {
+				value.Password = "cookitcookit"
+			},
+			expectedFields: []apperror.FieldError{{Field: "password", Message: "must contain a letter and a digit"}},
+		},
+		{
+			name: "Pointer is checked when set",
+			change: func(value *payload) {
+				value.Nickname = &blank
+			},
+			expectedFields: []apperror.FieldError{{Field: "nickname", Message: "must not be empty"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := valid
+			test.change(&value)
+
+			err := Validate("payload", &value)
+
+			if test.expectedFields == nil {
+				if err != nil {
+					t.Errorf("Got error = %v but wanted none", err)
+				}
+				return
+			}
+
+			typed, ok := apperror.As(err)
+			if !ok || typed.Kind != apperror.KindValidation || typed.Message != "invalid payload" {
+				t.Fatalf("Got error = %v but wanted a validation error", err)
+			}
+
+			if !reflect.DeepEqual(typed.Fields, test.expectedFields) {
+				t.Errorf("Got fields = %v but wanted %v", typed.Fields, test.expectedFields)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("even", func(value reflect.Value, _ string) string {
+		if value.Int()%2 != 0 {
+			return "must be even"
+		}
+		return ""
+	})
+
+	type counter struct {
+		Count int `json:"count" validate:"even"`
+	}
+
+	if err := Validate("counter", counter{Count: 2}); err != nil {
+		t.Errorf("Got error = %v but wanted none", err)
+	}
+
+	if err := Validate("counter", counter{Count: 3}); err == nil || err.Error() != "invalid counter: count must be even" {
+		t.Errorf("Got error = %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a registered rule not to be replaced")
+		}
+	}()
+	Register("even", nil)
+}